@@ -1,9 +1,23 @@
 package main
 
-import "github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64"
+import (
+	"fmt"
+	"os"
 
-// This is the app entry point
+	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64"
+)
+
+// This is the app entry point.  With no arguments the REPL is started in a window,
+// otherwise "run prog.BAS" runs a program on the command line.
 
 func main() {
-	rmbasicx64.StartRepl()
+	if len(os.Args) == 1 {
+		rmbasicx64.StartRepl()
+		return
+	}
+	if len(os.Args) == 3 && os.Args[1] == "run" {
+		os.Exit(rmbasicx64.RunFile(os.Args[2], os.Stdin, os.Stdout, os.Stderr))
+	}
+	fmt.Fprintln(os.Stderr, "usage: rmbasicx64 [run program.BAS]")
+	os.Exit(2)
 }
@@ -5,10 +5,10 @@ go 1.16
 require (
 	github.com/StackExchange/wmi v1.2.1 // indirect
 	github.com/StephaneBunel/bresenham v0.0.0-20190213085234-b50c292e2054 // indirect
-	github.com/elastic/go-sysinfo v1.6.0 // indirect
-	github.com/hajimehoshi/ebiten/v2 v2.0.8 // indirect
-	github.com/hajimehoshi/file2byteslice v0.0.0-20210813153925-5340248a8f41 // indirect
-	github.com/shirou/gopsutil v3.21.7+incompatible // indirect
+	github.com/elastic/go-sysinfo v1.6.0
+	github.com/hajimehoshi/ebiten/v2 v2.0.8
+	github.com/hajimehoshi/file2byteslice v0.0.0-20210813153925-5340248a8f41
+	github.com/shirou/gopsutil v3.21.7+incompatible
 	github.com/tklauser/go-sysconf v0.3.8 // indirect
-	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b // indirect
+	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
 )
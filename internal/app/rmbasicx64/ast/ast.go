@@ -116,6 +116,7 @@ type IfStatement struct {
 	Condition   Expression
 	Consequence *Line
 	Alternative *Line
+	Block       bool // True if THEN ends the line and the consequence follows on the next lines
 }
 
 func (s *IfStatement) statementNode() {}
@@ -135,6 +136,41 @@ func (s *IfStatement) String() string {
 	return out.String()
 }
 
+// ElseStatement starts the next branch of a block IF.  Condition is nil for a plain ELSE.
+type ElseStatement struct {
+	Token     token.Token
+	Condition Expression
+}
+
+func (s *ElseStatement) statementNode() {}
+func (s *ElseStatement) TokenLiteral() string {
+	return s.Token.Literal
+}
+func (s *ElseStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("ELSE")
+	if s.Condition != nil {
+		out.WriteString(" IF ")
+		out.WriteString(s.Condition.String())
+		out.WriteString(" THEN")
+	}
+	return out.String()
+}
+
+type EndifStatement struct {
+	Token token.Token
+}
+
+func (s *EndifStatement) statementNode() {}
+func (s *EndifStatement) TokenLiteral() string {
+	return s.Token.Literal
+}
+func (s *EndifStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(s.TokenLiteral())
+	return out.String()
+}
+
 type UntilStatement struct {
 	Token     token.Token
 	Condition Expression
@@ -213,7 +249,22 @@ func (i *Identifier) TokenLiteral() string {
 	return i.Token.Literal
 }
 func (i *Identifier) String() string {
-	return i.Value
+	if i.IsArrayReference {
+		return i.Value + "()"
+	}
+	if len(i.Subscripts) == 0 {
+		return i.Value
+	}
+	var out bytes.Buffer
+	subscripts := []string{}
+	for _, s := range i.Subscripts {
+		subscripts = append(subscripts, s.String())
+	}
+	out.WriteString(i.Value)
+	out.WriteString("(")
+	out.WriteString(strings.Join(subscripts, ","))
+	out.WriteString(")")
+	return out.String()
 }
 
 type ExpressionStatement struct {
@@ -436,7 +487,11 @@ func (s *KeepStatement) String() string {
 }
 
 type RenumberStatement struct {
-	Token token.Token
+	Token     token.Token
+	Start     Expression
+	Increment Expression
+	From      Expression
+	To        Expression
 }
 
 func (s *RenumberStatement) statementNode() {}
@@ -479,6 +534,64 @@ func (s *SetModeStatement) String() string {
 	return out.String()
 }
 
+type SetDateStatement struct {
+	Token token.Token
+	Value Expression
+}
+
+func (s *SetDateStatement) statementNode() {}
+func (s *SetDateStatement) TokenLiteral() string {
+	return s.Token.Literal
+}
+func (s *SetDateStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(s.TokenLiteral())
+	return out.String()
+}
+
+type SetTimeStatement struct {
+	Token token.Token
+	Value Expression
+}
+
+func (s *SetTimeStatement) statementNode() {}
+func (s *SetTimeStatement) TokenLiteral() string {
+	return s.Token.Literal
+}
+func (s *SetTimeStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(s.TokenLiteral())
+	return out.String()
+}
+
+type SetTraceStatement struct {
+	Token     token.Token
+	On        bool
+	Variables bool       // Also trace assignments to variables
+	Channel   Expression // Write the trace to a file channel instead of the screen
+}
+
+func (s *SetTraceStatement) statementNode() {}
+func (s *SetTraceStatement) TokenLiteral() string {
+	return s.Token.Literal
+}
+func (s *SetTraceStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("SET " + s.TokenLiteral())
+	switch {
+	case s.Variables:
+		out.WriteString(" LVAR")
+	case s.On:
+		out.WriteString(" ON")
+	default:
+		out.WriteString(" OFF")
+	}
+	if s.Channel != nil {
+		out.WriteString(" #" + s.Channel.String())
+	}
+	return out.String()
+}
+
 type SetPaperStatement struct {
 	Token token.Token
 	Value Expression
@@ -1269,6 +1382,7 @@ func (s *SaveStatement) String() string {
 	return out.String()
 }
 
+// LoadStatement is used for LOAD, LOADGO, MERGE and MERGEGO
 type LoadStatement struct {
 	Token token.Token
 	Value Expression
@@ -1315,6 +1429,39 @@ func (s *RestoreStatement) String() string {
 	return out.String()
 }
 
+type DeleteStatement struct {
+	Token          token.Token
+	FromLinenumber token.Token
+	ToLinenumber   token.Token
+	FromLineOnly   bool
+}
+
+func (s *DeleteStatement) statementNode() {}
+func (s *DeleteStatement) TokenLiteral() string {
+	return s.Token.Literal
+}
+func (s *DeleteStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(s.TokenLiteral())
+	return out.String()
+}
+
+type AutoStatement struct {
+	Token token.Token
+	Start Expression
+	Step  Expression
+}
+
+func (s *AutoStatement) statementNode() {}
+func (s *AutoStatement) TokenLiteral() string {
+	return s.Token.Literal
+}
+func (s *AutoStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(s.TokenLiteral())
+	return out.String()
+}
+
 type EditStatement struct {
 	Token      token.Token
 	Linenumber token.Token
@@ -1430,6 +1577,99 @@ func (s *GosubStatement) String() string {
 	return out.String()
 }
 
+type OnErrorStatement struct {
+	Token  token.Token
+	Branch Statement // *GotoStatement or *GosubStatement, or nil to turn error trapping off
+}
+
+func (s *OnErrorStatement) statementNode() {}
+func (s *OnErrorStatement) TokenLiteral() string {
+	return s.Token.Literal
+}
+func (s *OnErrorStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(s.TokenLiteral() + " ERROR")
+	if s.Branch != nil {
+		out.WriteString(" " + s.Branch.String())
+	}
+	return out.String()
+}
+
+// OnJumpStatement is ON e GOTO or ON e GOSUB.  The value of e picks which of the branches to take.
+type OnJumpStatement struct {
+	Token    token.Token
+	Selector Expression
+	Branches []Statement // *GotoStatement or *GosubStatement for each destination in the list
+}
+
+func (s *OnJumpStatement) statementNode() {}
+func (s *OnJumpStatement) TokenLiteral() string {
+	return s.Token.Literal
+}
+func (s *OnJumpStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(s.TokenLiteral() + " ")
+	out.WriteString(s.Selector.String())
+	for i, branch := range s.Branches {
+		switch branch := branch.(type) {
+		case *GotoStatement:
+			if i == 0 {
+				out.WriteString(" " + branch.TokenLiteral() + " ")
+			}
+			out.WriteString(branch.Linenumber.Literal)
+		case *GosubStatement:
+			if i == 0 {
+				out.WriteString(" " + branch.TokenLiteral() + " ")
+			}
+			out.WriteString(branch.Name.String())
+		}
+		if i < len(s.Branches)-1 {
+			out.WriteString(", ")
+		}
+	}
+	return out.String()
+}
+
+type OnBreakStatement struct {
+	Token  token.Token
+	Branch Statement // *GotoStatement or *GosubStatement, or nil to turn break trapping off
+}
+
+func (s *OnBreakStatement) statementNode() {}
+func (s *OnBreakStatement) TokenLiteral() string {
+	return s.Token.Literal
+}
+func (s *OnBreakStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(s.TokenLiteral() + " BREAK")
+	if s.Branch != nil {
+		out.WriteString(" " + s.Branch.String())
+	}
+	return out.String()
+}
+
+type ResumeStatement struct {
+	Token      token.Token
+	Next       bool
+	Linenumber token.Token
+}
+
+func (s *ResumeStatement) statementNode() {}
+func (s *ResumeStatement) TokenLiteral() string {
+	return s.Token.Literal
+}
+func (s *ResumeStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(s.TokenLiteral())
+	if s.Next {
+		out.WriteString(" NEXT")
+	}
+	if s.Linenumber.Literal != "" {
+		out.WriteString(" " + s.Linenumber.Literal)
+	}
+	return out.String()
+}
+
 type FunctionDeclaration struct {
 	Token           token.Token
 	Name            *Identifier
@@ -1560,9 +1800,65 @@ func (bs *EndStatement) String() string {
 	return out.String()
 }
 
+type StopStatement struct {
+	Token token.Token
+}
+
+func (s *StopStatement) statementNode() {}
+func (s *StopStatement) TokenLiteral() string {
+	return s.Token.Literal
+}
+func (s *StopStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(s.TokenLiteral())
+	return out.String()
+}
+
+type ContinueStatement struct {
+	Token token.Token
+}
+
+func (s *ContinueStatement) statementNode() {}
+func (s *ContinueStatement) TokenLiteral() string {
+	return s.Token.Literal
+}
+func (s *ContinueStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(s.TokenLiteral())
+	return out.String()
+}
+
+type LvarStatement struct {
+	Token token.Token
+}
+
+func (s *LvarStatement) statementNode() {}
+func (s *LvarStatement) TokenLiteral() string {
+	return s.Token.Literal
+}
+func (s *LvarStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(s.TokenLiteral())
+	return out.String()
+}
+
+type ProcsStatement struct {
+	Token token.Token
+}
+
+func (s *ProcsStatement) statementNode() {}
+func (s *ProcsStatement) TokenLiteral() string {
+	return s.Token.Literal
+}
+func (s *ProcsStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString(s.TokenLiteral())
+	return out.String()
+}
+
 type NextStatement struct {
 	Token token.Token
-	Name  *Identifier
+	Names []*Identifier // The loop variables, e.g. NEXT J, I, or empty to end the innermost loop
 }
 
 func (s *NextStatement) statementNode() {}
@@ -1571,8 +1867,15 @@ func (s *NextStatement) TokenLiteral() string {
 }
 func (s *NextStatement) String() string {
 	var out bytes.Buffer
-	out.WriteString(s.TokenLiteral() + " ")
-	out.WriteString(s.Name.String())
+	out.WriteString(s.TokenLiteral())
+	for i, name := range s.Names {
+		if i == 0 {
+			out.WriteString(" ")
+		} else {
+			out.WriteString(", ")
+		}
+		out.WriteString(name.String())
+	}
 	return out.String()
 }
 
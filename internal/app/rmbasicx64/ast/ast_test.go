@@ -20,6 +20,10 @@ func TestString(t *testing.T) {
 						Literal:   "Myvar"},
 					Value: "Myvar",
 				},
+				BindToken: token.Token{
+					TokenType: token.Assign,
+					Literal:   ":=",
+				},
 				Value: &Identifier{
 					Token: token.Token{
 						TokenType: token.IdentifierLiteral,
@@ -30,7 +34,7 @@ func TestString(t *testing.T) {
 			},
 		},
 	}
-	if program.String() != "LET Myvar := Anothervar;" {
+	if program.String() != "LET Myvar := Anothervar" {
 		t.Errorf("program.String() wrong, got %q", program.String())
 	}
 }
@@ -0,0 +1,90 @@
+/*
+Package console defines the interface the interpreter uses to talk to the outside world.  All
+text, graphics, sound, keyboard and mouse I/O goes through a Console so that the same
+interpreter can drive the ebiten-backed Nimbus display or run headless, e.g. in tests or
+from the command line.
+*/
+package console
+
+import "github.com/adamstimb/rmbasicx64/pkg/nimgobus/options"
+
+// Console is implemented by anything that can stand in for the Nimbus screen, keyboard,
+// mouse and sound chip.  The method set mirrors nimgobus.Nimbus so that the Nimbus can
+// satisfy it directly.
+type Console interface {
+	// Text
+	Print(s string)
+	Put(c int)
+	Get() int
+	Input(prepopulateBuffer string) string
+	// SetInputHistory sets the previous inputs that Input can recall, newest last, or turns
+	// recall off if history is nil.
+	SetInputHistory(history []string)
+	// SetInputCompletion sets the functions Input calls to complete the word before the
+	// cursor when TAB is pressed and to get a hint about the word under the cursor.
+	SetInputCompletion(complete func(buffer string, position int) (string, int), hint func(buffer string, position int) string)
+	Cls(p ...int)
+	SetMode(columns int)
+	AskMode() int
+	SetWriting(p ...int)
+	AskWriting(p ...int) (slot, col1, row1, col2, row2 int)
+	SetCurpos(col, row int)
+	AskCurpos() (int, int)
+	SetPaper(c int)
+	SetPen(c int)
+	SetBorder(c int)
+	SetColour(paletteSlot, basicColour, flashSpeed, flashColour int)
+
+	// Graphics
+	ValidateColour(c int) bool
+	ValidateBrush(c int) bool
+	ValidateStyle(s int) bool
+	Clg()
+	SetDrawing(p ...int)
+	SetPattern(slot, row, c1, c2, c3, c4 int)
+	SetFillStyle(style, hatching, colour2 int)
+	Plot(opt options.PlotOptions, text string, x, y int)
+	Line(opt options.LineOptions, coordList []options.XyCoord)
+	Area(opt options.AreaOptions, coordList []options.XyCoord)
+	Circle(opt options.CircleOptions, r, x, y int)
+	Points(opt options.PointsOptions, coordList []options.XyCoord)
+	Flood(opt options.FloodOptions, coord options.XyCoord)
+	PlonkLogo(x, y int)
+
+	// Image blocks
+	Clearblock()
+	Fetch(b int, path string) bool
+	Readblock(b, x1, y1, x2, y2 int)
+	Writeblock(b, x, y int, over bool)
+	Squash(b, x, y int, over bool)
+	AskBlocksize(b int) (width, height, mode int)
+	Delblock(b int)
+	Keep(b int, format, path string) error
+
+	// Sound
+	AskSound() bool
+	SetSound(v bool)
+	SetTone(t bool)
+	SetVoice(v int)
+	SetEnvelope(slot, attackTime, attackLevel, decayTime, decayLevel, sustainTime, sustainLevel, releaseTime int)
+	Note(pitch1, pitch2, duration, volume, envelope int) bool
+
+	// Mouse
+	SetMouse(mouseOn bool)
+	AskMouse() (x, y, button int)
+
+	// AskBreak returns true if the user has made a <BREAK> and SetBreak sets or clears
+	// the flag.
+	AskBreak() bool
+	SetBreak(b bool)
+
+	// Boot plays the boot sequence, if there is one.
+	Boot()
+}
+
+// InputEnder is implemented by consoles whose keyboard input can run out, such as a Headless
+// console reading from a file.  InputEnded returns true once there is nothing left to read, so
+// anything waiting for a key to be pressed would wait forever.
+type InputEnder interface {
+	InputEnded() bool
+}
@@ -0,0 +1,289 @@
+package console
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/adamstimb/rmbasicx64/pkg/nimgobus/options"
+)
+
+// textBox defines the bounding box of a text box in columns and rows
+type textBox struct {
+	col1 int
+	row1 int
+	col2 int
+	row2 int
+}
+
+// Headless is a Console that doesn't need a display.  Text output is written to an
+// io.Writer and keyboard input is read from an io.Reader.  Graphics and sound commands
+// are accepted but have no effect, although the screen mode, text boxes and cursor
+// position are tracked so that anything the interpreter asks for comes back sensible.
+type Headless struct {
+	in              *bufio.Reader
+	out             io.Writer
+	EchoInput       bool // If true, anything read by Input is echoed to the output like it would be on screen
+	mode            int
+	textBoxes       [10]textBox
+	selectedTextBox int
+	col             int
+	row             int
+	sound           bool
+	breakDetected   bool
+	inputEnded      bool
+}
+
+// NewHeadless returns a Headless console in mode 80 that reads keyboard input from in
+// and writes text output to out.
+func NewHeadless(in io.Reader, out io.Writer) *Headless {
+	h := &Headless{
+		in:        bufio.NewReader(in),
+		out:       out,
+		EchoInput: true,
+	}
+	h.SetMode(80)
+	return h
+}
+
+// SetOutput sends text output to out from now on
+func (h *Headless) SetOutput(out io.Writer) {
+	h.out = out
+}
+
+// Text
+
+func (h *Headless) Print(s string) {
+	for _, c := range s {
+		h.Put(int(c))
+	}
+}
+
+func (h *Headless) Put(c int) {
+	switch c {
+	case 7:
+		// Bell doesn't print anything
+		return
+	case 13:
+		io.WriteString(h.out, "\n")
+		h.advanceCursor(true)
+	default:
+		io.WriteString(h.out, string(rune(c)))
+		h.advanceCursor(false)
+	}
+}
+
+// Get returns the next character code from the input.  ENTER is returned as -11, the
+// same as the Nimbus keyboard, and -1 is returned if there is nothing left to read.
+func (h *Headless) Get() int {
+	r, _, err := h.in.ReadRune()
+	if err != nil {
+		h.inputEnded = true
+		return -1
+	}
+	if r == '\n' || r == '\r' {
+		return -11
+	}
+	return int(r)
+}
+
+// InputEnded returns true once Get has found there is nothing left to read.
+func (h *Headless) InputEnded() bool {
+	return h.inputEnded
+}
+
+// Input reads a line from the input and returns it appended to prepopulateBuffer.
+func (h *Headless) Input(prepopulateBuffer string) string {
+	line, _ := h.in.ReadString('\n')
+	line = prepopulateBuffer + strings.TrimRight(line, "\r\n")
+	if h.EchoInput {
+		h.Print(line)
+		h.Put(13)
+	} else {
+		h.advanceCursor(true)
+	}
+	return line
+}
+
+// SetInputHistory does nothing because Input reads whole lines, so there's no way to recall
+// anything.
+func (h *Headless) SetInputHistory(history []string) {}
+
+// SetInputCompletion does nothing for the same reason.
+func (h *Headless) SetInputCompletion(complete func(buffer string, position int) (string, int), hint func(buffer string, position int) string) {
+}
+
+func (h *Headless) Cls(p ...int) {
+	h.col, h.row = 1, 1
+}
+
+func (h *Headless) SetMode(columns int) {
+	if columns != 40 && columns != 80 {
+		return
+	}
+	h.mode = columns
+	for i := 0; i < len(h.textBoxes); i++ {
+		h.textBoxes[i] = textBox{1, 1, columns, 25}
+	}
+	h.selectedTextBox = 0
+	h.col, h.row = 1, 1
+}
+
+func (h *Headless) AskMode() int {
+	return h.mode
+}
+
+func (h *Headless) SetWriting(p ...int) {
+	if len(p) == 1 {
+		if p[0] < 0 || p[0] >= len(h.textBoxes) {
+			return
+		}
+		if p[0] != h.selectedTextBox {
+			h.col, h.row = 1, 1
+		}
+		h.selectedTextBox = p[0]
+		return
+	}
+	if len(p) == 5 && p[0] > 0 && p[0] < len(h.textBoxes) {
+		left, right := p[1], p[3]
+		if left > right {
+			left, right = right, left
+		}
+		upper, lower := p[2], p[4]
+		if upper > lower {
+			upper, lower = lower, upper
+		}
+		h.textBoxes[p[0]] = textBox{left, upper, right, lower}
+	}
+}
+
+func (h *Headless) AskWriting(p ...int) (slot, col1, row1, col2, row2 int) {
+	slot = h.selectedTextBox
+	if len(p) == 1 {
+		slot = p[0]
+	}
+	box := h.textBoxes[slot]
+	return slot, box.col1, box.row1, box.col2, box.row2
+}
+
+func (h *Headless) SetCurpos(col, row int) {
+	box := h.textBoxes[h.selectedTextBox]
+	width := box.col2 - box.col1 + 1
+	height := box.row2 - box.row1 + 1
+	if col > width || col < 1 {
+		col = 1
+	}
+	if row > height || row < 1 {
+		row = 1
+	}
+	h.col, h.row = col, row
+}
+
+func (h *Headless) AskCurpos() (int, int) {
+	return h.col, h.row
+}
+
+// advanceCursor moves the cursor forward the same way the Nimbus does, except that
+// there is nothing to scroll.
+func (h *Headless) advanceCursor(forceCarriageReturn bool) {
+	box := h.textBoxes[h.selectedTextBox]
+	width := box.col2 - box.col1
+	height := box.row2 - box.row1
+	h.col++
+	if h.col > width+1 || forceCarriageReturn {
+		h.col = 1
+		h.row++
+	}
+	if h.row > height+1 {
+		h.row--
+	}
+}
+
+func (h *Headless) SetPaper(c int)                                                  {}
+func (h *Headless) SetPen(c int)                                                    {}
+func (h *Headless) SetBorder(c int)                                                 {}
+func (h *Headless) SetColour(paletteSlot, basicColour, flashSpeed, flashColour int) {}
+
+// Graphics
+
+func (h *Headless) ValidateColour(c int) bool {
+	maxC := 15
+	if h.mode == 80 {
+		maxC = 3
+	}
+	return c >= 0 && c <= maxC
+}
+
+func (h *Headless) ValidateBrush(c int) bool {
+	if c >= 128 && c <= 135 {
+		return true
+	}
+	return h.ValidateColour(c)
+}
+
+func (h *Headless) ValidateStyle(s int) bool {
+	return s >= 1 && s <= 5
+}
+
+func (h *Headless) Clg()                                                          {}
+func (h *Headless) SetDrawing(p ...int)                                           {}
+func (h *Headless) SetPattern(slot, row, c1, c2, c3, c4 int)                      {}
+func (h *Headless) SetFillStyle(style, hatching, colour2 int)                     {}
+func (h *Headless) Plot(opt options.PlotOptions, text string, x, y int)           {}
+func (h *Headless) Line(opt options.LineOptions, coordList []options.XyCoord)     {}
+func (h *Headless) Area(opt options.AreaOptions, coordList []options.XyCoord)     {}
+func (h *Headless) Circle(opt options.CircleOptions, r, x, y int)                 {}
+func (h *Headless) Points(opt options.PointsOptions, coordList []options.XyCoord) {}
+func (h *Headless) Flood(opt options.FloodOptions, coord options.XyCoord)         {}
+func (h *Headless) PlonkLogo(x, y int)                                            {}
+
+// Image blocks
+
+func (h *Headless) Clearblock() {}
+
+// Fetch only checks that the image file exists since there's nowhere to put it.
+func (h *Headless) Fetch(b int, path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func (h *Headless) Readblock(b, x1, y1, x2, y2 int)              {}
+func (h *Headless) Writeblock(b, x, y int, over bool)            {}
+func (h *Headless) Squash(b, x, y int, over bool)                {}
+func (h *Headless) AskBlocksize(b int) (width, height, mode int) { return 0, 0, 0 }
+func (h *Headless) Delblock(b int)                               {}
+func (h *Headless) Keep(b int, format, path string) error        { return nil }
+
+// Sound
+
+func (h *Headless) AskSound() bool {
+	return h.sound
+}
+
+func (h *Headless) SetSound(v bool) {
+	h.sound = v
+}
+
+func (h *Headless) SetTone(t bool) {}
+func (h *Headless) SetVoice(v int) {}
+func (h *Headless) SetEnvelope(slot, attackTime, attackLevel, decayTime, decayLevel, sustainTime, sustainLevel, releaseTime int) {
+}
+func (h *Headless) Note(pitch1, pitch2, duration, volume, envelope int) bool { return true }
+
+// Mouse
+
+func (h *Headless) SetMouse(mouseOn bool)        {}
+func (h *Headless) AskMouse() (x, y, button int) { return 0, 0, 0 }
+
+// Break
+
+func (h *Headless) AskBreak() bool {
+	return h.breakDetected
+}
+
+func (h *Headless) SetBreak(b bool) {
+	h.breakDetected = b
+}
+
+func (h *Headless) Boot() {}
@@ -0,0 +1,37 @@
+package console
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetCurpos(t *testing.T) {
+	h := NewHeadless(strings.NewReader(""), &strings.Builder{})
+	tests := []struct {
+		col, row         int
+		wantCol, wantRow int
+	}{
+		{10, 5, 10, 5},
+		{80, 25, 80, 25},
+		{81, 25, 1, 25},
+		{80, 26, 80, 1},
+		{0, 0, 1, 1},
+	}
+	for _, tt := range tests {
+		h.SetCurpos(tt.col, tt.row)
+		if col, row := h.AskCurpos(); col != tt.wantCol || row != tt.wantRow {
+			t.Errorf("SetCurpos(%d, %d) moved to %d, %d, want %d, %d", tt.col, tt.row, col, row, tt.wantCol, tt.wantRow)
+		}
+	}
+}
+
+func TestSetOutput(t *testing.T) {
+	var first, second strings.Builder
+	h := NewHeadless(strings.NewReader(""), &first)
+	h.Print("A")
+	h.SetOutput(&second)
+	h.Print("B")
+	if first.String() != "A" || second.String() != "B" {
+		t.Errorf("SetOutput split the output into %q and %q, want %q and %q", first.String(), second.String(), "A", "B")
+	}
+}
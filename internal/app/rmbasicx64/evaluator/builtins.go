@@ -6,8 +6,10 @@ import (
 	"math"
 	"math/rand"
 	"os"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/game"
 	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/object"
@@ -17,6 +19,7 @@ import (
 // don't forget to add builtins to the map in lexer as well (better solution?)
 var builtins = map[string]*object.Builtin{
 	"LEN": &object.Builtin{
+		Syntax: "LEN(e$)",
 		Fn: func(env *object.Environment, g *game.Game, args []object.Object) object.Object {
 			if len(args) != 1 {
 				return newError("wrong number of arguments, got %d, want %d", len(args), 1)
@@ -33,6 +36,7 @@ var builtins = map[string]*object.Builtin{
 		},
 	},
 	"ABS": &object.Builtin{
+		Syntax: "ABS(e)",
 		Fn: func(env *object.Environment, g *game.Game, args []object.Object) object.Object {
 			if len(args) != 1 {
 				return newError("wrong number of arguments, got %d, want %d", len(args), 1)
@@ -49,6 +53,7 @@ var builtins = map[string]*object.Builtin{
 		},
 	},
 	"ATN": &object.Builtin{
+		Syntax: "ATN(e)",
 		Fn: func(env *object.Environment, g *game.Game, args []object.Object) object.Object {
 			if len(args) != 1 {
 				return newError("wrong number of arguments, got %d, want %d", len(args), 1)
@@ -65,6 +70,7 @@ var builtins = map[string]*object.Builtin{
 		},
 	},
 	"COS": &object.Builtin{
+		Syntax: "COS(e)",
 		Fn: func(env *object.Environment, g *game.Game, args []object.Object) object.Object {
 			if len(args) != 1 {
 				return newError("wrong number of arguments, got %d, want %d", len(args), 1)
@@ -81,6 +87,7 @@ var builtins = map[string]*object.Builtin{
 		},
 	},
 	"SIN": &object.Builtin{
+		Syntax: "SIN(e)",
 		Fn: func(env *object.Environment, g *game.Game, args []object.Object) object.Object {
 			if len(args) != 1 {
 				return newError("wrong number of arguments, got %d, want %d", len(args), 1)
@@ -98,6 +105,7 @@ var builtins = map[string]*object.Builtin{
 		},
 	},
 	"EXP": &object.Builtin{
+		Syntax: "EXP(e)",
 		Fn: func(env *object.Environment, g *game.Game, args []object.Object) object.Object {
 			if len(args) != 1 {
 				return newError("wrong number of arguments, got %d, want %d", len(args), 1)
@@ -114,6 +122,7 @@ var builtins = map[string]*object.Builtin{
 		},
 	},
 	"INT": &object.Builtin{
+		Syntax: "INT(e)",
 		Fn: func(env *object.Environment, g *game.Game, args []object.Object) object.Object {
 			if len(args) != 1 {
 				return newError("wrong number of arguments, got %d, want %d", len(args), 1)
@@ -130,6 +139,7 @@ var builtins = map[string]*object.Builtin{
 		},
 	},
 	"LN": &object.Builtin{
+		Syntax: "LN(e)",
 		Fn: func(env *object.Environment, g *game.Game, args []object.Object) object.Object {
 			if len(args) != 1 {
 				return newError("wrong number of arguments, got %d, want %d", len(args), 1)
@@ -146,6 +156,7 @@ var builtins = map[string]*object.Builtin{
 		},
 	},
 	"LOG": &object.Builtin{
+		Syntax: "LOG(e)",
 		Fn: func(env *object.Environment, g *game.Game, args []object.Object) object.Object {
 			if len(args) != 1 {
 				return newError("wrong number of arguments, got %d, want %d", len(args), 1)
@@ -162,6 +173,7 @@ var builtins = map[string]*object.Builtin{
 		},
 	},
 	"RND": &object.Builtin{
+		Syntax: "RND(e)",
 		Fn: func(env *object.Environment, g *game.Game, args []object.Object) object.Object {
 			if len(args) != 1 {
 				return newError("wrong number of arguments, got %d, want %d", len(args), 1)
@@ -193,6 +205,7 @@ var builtins = map[string]*object.Builtin{
 		},
 	},
 	"SGN": &object.Builtin{
+		Syntax: "SGN(e)",
 		Fn: func(env *object.Environment, g *game.Game, args []object.Object) object.Object {
 			if len(args) != 1 {
 				return newError("wrong number of arguments, got %d, want %d", len(args), 1)
@@ -216,6 +229,7 @@ var builtins = map[string]*object.Builtin{
 		},
 	},
 	"SQR": &object.Builtin{
+		Syntax: "SQR(e)",
 		Fn: func(env *object.Environment, g *game.Game, args []object.Object) object.Object {
 			if len(args) != 1 {
 				return newError("wrong number of arguments, got %d, want %d", len(args), 1)
@@ -232,6 +246,7 @@ var builtins = map[string]*object.Builtin{
 		},
 	},
 	"TAN": &object.Builtin{
+		Syntax: "TAN(e)",
 		Fn: func(env *object.Environment, g *game.Game, args []object.Object) object.Object {
 			if len(args) != 1 {
 				return newError("wrong number of arguments, got %d, want %d", len(args), 1)
@@ -248,6 +263,7 @@ var builtins = map[string]*object.Builtin{
 		},
 	},
 	"LOOKUP": &object.Builtin{
+		Syntax: "LOOKUP(e$)",
 		Fn: func(env *object.Environment, g *game.Game, args []object.Object) object.Object {
 			if len(args) != 1 {
 				return newError("wrong number of arguments, got %d, want %d", len(args), 1)
@@ -258,11 +274,11 @@ var builtins = map[string]*object.Builtin{
 			if stringVal, ok := obj.(*object.String); ok {
 				val = stringVal.Value
 			} else {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.StringExpressionNeeded), ErrorTokenIndex: 0}
+				return &object.Error{Code: syntaxerror.StringExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.StringExpressionNeeded), ErrorTokenIndex: 0}
 			}
 			// Don't allow * or ?
 			if strings.Contains(val, "*") || strings.Contains(val, "?") {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.ExactFilenameIsNeeded), ErrorTokenIndex: 0}
+				return &object.Error{Code: syntaxerror.ExactFilenameIsNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.ExactFilenameIsNeeded), ErrorTokenIndex: 0}
 			}
 			// Add .BAS if necessary
 			if !strings.HasSuffix(strings.ToUpper(val), ".BAS") {
@@ -284,6 +300,7 @@ var builtins = map[string]*object.Builtin{
 		},
 	},
 	"PATH$": &object.Builtin{
+		Syntax: "PATH$",
 		Fn: func(env *object.Environment, g *game.Game, args []object.Object) object.Object {
 			if len(args) != 0 {
 				return newError("wrong number of arguments, got %d, want %d", len(args), 0)
@@ -297,21 +314,20 @@ var builtins = map[string]*object.Builtin{
 		},
 	},
 	"CHR$": &object.Builtin{
+		Syntax: "CHR$(e)",
 		Fn: func(env *object.Environment, g *game.Game, args []object.Object) object.Object {
-			if len(args) != 1 {
-				return newError("wrong number of arguments, got %d, want %d", len(args), 0)
+			if err := checkParameterCount("CHR$", args, 1, 1); err != nil {
+				return err
 			}
-			switch arg := args[0].(type) {
-			case *object.Numeric:
-				return &object.String{
-					Value: string(int32(arg.Value)),
-				}
-			default:
-				return newError("argument to `GET$` not supported, got %s", args[0].Type())
+			str, err := characterCodeParameter(args[0])
+			if err != nil {
+				return err
 			}
+			return &object.String{Value: str}
 		},
 	},
 	"GET": &object.Builtin{
+		Syntax: "GET([e])",
 		Fn: func(env *object.Environment, g *game.Game, args []object.Object) object.Object {
 			if len(args) > 1 {
 				return newError("wrong number of arguments, got %d, want < %d", len(args), 2)
@@ -360,6 +376,7 @@ var builtins = map[string]*object.Builtin{
 		},
 	},
 	"STR$": &object.Builtin{
+		Syntax: "STR$(e)",
 		Fn: func(env *object.Environment, g *game.Game, args []object.Object) object.Object {
 			if len(args) != 1 {
 				return newError("wrong number of arguments, got %d, want %d", len(args), 1)
@@ -376,6 +393,7 @@ var builtins = map[string]*object.Builtin{
 		},
 	},
 	"PITCH": &object.Builtin{
+		Syntax: "PITCH(e1, e2)",
 		Fn: func(env *object.Environment, g *game.Game, args []object.Object) object.Object {
 			if len(args) != 2 {
 				return newError("wrong number of arguments, got %d, want %d", len(args), 1)
@@ -400,4 +418,321 @@ var builtins = map[string]*object.Builtin{
 
 		},
 	},
+	"ERR": &object.Builtin{
+		Syntax: "ERR",
+		Fn: func(env *object.Environment, g *game.Game, args []object.Object) object.Object {
+			if len(args) != 0 {
+				return &object.Error{Code: syntaxerror.TooManyParametersFor, Message: syntaxerror.ErrorMessage(syntaxerror.TooManyParametersFor) + "ERR", ErrorTokenIndex: 0}
+			}
+			code, _, _, _ := env.LastError()
+			return &object.Numeric{
+				Value: float64(code),
+			}
+		},
+	},
+	"ERL": &object.Builtin{
+		Syntax: "ERL",
+		Fn: func(env *object.Environment, g *game.Game, args []object.Object) object.Object {
+			if len(args) != 0 {
+				return &object.Error{Code: syntaxerror.TooManyParametersFor, Message: syntaxerror.ErrorMessage(syntaxerror.TooManyParametersFor) + "ERL", ErrorTokenIndex: 0}
+			}
+			_, lineNumber, _, _ := env.LastError()
+			return &object.Numeric{
+				Value: float64(lineNumber),
+			}
+		},
+	},
+	"ERR$": &object.Builtin{
+		Syntax: "ERR$",
+		Fn: func(env *object.Environment, g *game.Game, args []object.Object) object.Object {
+			if len(args) != 0 {
+				return &object.Error{Code: syntaxerror.TooManyParametersFor, Message: syntaxerror.ErrorMessage(syntaxerror.TooManyParametersFor) + "ERR$", ErrorTokenIndex: 0}
+			}
+			_, _, _, message := env.LastError()
+			return &object.String{
+				Value: message,
+			}
+		},
+	},
+	"LEFT$": &object.Builtin{
+		Syntax: "LEFT$(e$, n)",
+		Fn: func(env *object.Environment, g *game.Game, args []object.Object) object.Object {
+			if err := checkParameterCount("LEFT$", args, 2, 2); err != nil {
+				return err
+			}
+			str, err := stringParameter(args[0])
+			if err != nil {
+				return err
+			}
+			n, err := countParameter(args[1])
+			if err != nil {
+				return err
+			}
+			if n > len(str) {
+				n = len(str)
+			}
+			return &object.String{Value: str[:n]}
+		},
+	},
+	"RIGHT$": &object.Builtin{
+		Syntax: "RIGHT$(e$, n)",
+		Fn: func(env *object.Environment, g *game.Game, args []object.Object) object.Object {
+			if err := checkParameterCount("RIGHT$", args, 2, 2); err != nil {
+				return err
+			}
+			str, err := stringParameter(args[0])
+			if err != nil {
+				return err
+			}
+			n, err := countParameter(args[1])
+			if err != nil {
+				return err
+			}
+			if n > len(str) {
+				n = len(str)
+			}
+			return &object.String{Value: str[len(str)-n:]}
+		},
+	},
+	"MID$": &object.Builtin{
+		Syntax: "MID$(e$, start [, n])",
+		Fn: func(env *object.Environment, g *game.Game, args []object.Object) object.Object {
+			// MID$(string, start [, length]).  Without a length the rest of the string is returned.
+			if err := checkParameterCount("MID$", args, 2, 3); err != nil {
+				return err
+			}
+			str, err := stringParameter(args[0])
+			if err != nil {
+				return err
+			}
+			start, err := positionParameter(args[1])
+			if err != nil {
+				return err
+			}
+			n := len(str)
+			if len(args) == 3 {
+				if n, err = countParameter(args[2]); err != nil {
+					return err
+				}
+			}
+			if start > len(str) {
+				return &object.String{Value: ""}
+			}
+			end := start - 1 + n
+			if end > len(str) {
+				end = len(str)
+			}
+			return &object.String{Value: str[start-1 : end]}
+		},
+	},
+	"INSTR": &object.Builtin{
+		Syntax: "INSTR(e1$, e2$ [, start])",
+		Fn: func(env *object.Environment, g *game.Game, args []object.Object) object.Object {
+			// INSTR(string, target [, start]) returns the position of target in string, or 0
+			// if it isn't found
+			if err := checkParameterCount("INSTR", args, 2, 3); err != nil {
+				return err
+			}
+			str, err := stringParameter(args[0])
+			if err != nil {
+				return err
+			}
+			target, err := stringParameter(args[1])
+			if err != nil {
+				return err
+			}
+			start := 1
+			if len(args) == 3 {
+				if start, err = positionParameter(args[2]); err != nil {
+					return err
+				}
+			}
+			if start > len(str)+1 {
+				return &object.Numeric{Value: 0}
+			}
+			i := strings.Index(str[start-1:], target)
+			if i < 0 {
+				return &object.Numeric{Value: 0}
+			}
+			return &object.Numeric{Value: float64(start + i)}
+		},
+	},
+	"VAL": &object.Builtin{
+		Syntax: "VAL(e$)",
+		Fn: func(env *object.Environment, g *game.Game, args []object.Object) object.Object {
+			// VAL converts as much of the start of the string as looks like a number, so
+			// VAL("12abc") is 12 and VAL("abc") is 0
+			if err := checkParameterCount("VAL", args, 1, 1); err != nil {
+				return err
+			}
+			str, err := stringParameter(args[0])
+			if err != nil {
+				return err
+			}
+			// Only try the characters that can make up a decimal number, otherwise things
+			// like "nan" and "0x10" would be converted too
+			str = strings.TrimSpace(str)
+			if end := strings.IndexFunc(str, func(r rune) bool { return !strings.ContainsRune("+-.0123456789Ee", r) }); end >= 0 {
+				str = str[:end]
+			}
+			for i := len(str); i > 0; i-- {
+				if val, err := strconv.ParseFloat(str[:i], 64); err == nil {
+					return &object.Numeric{Value: val}
+				}
+			}
+			return &object.Numeric{Value: 0}
+		},
+	},
+	"ASC": &object.Builtin{
+		Syntax: "ASC(e$)",
+		Fn: func(env *object.Environment, g *game.Game, args []object.Object) object.Object {
+			// ASC returns the character code of the first character of the string, or 0 if
+			// the string is empty
+			if err := checkParameterCount("ASC", args, 1, 1); err != nil {
+				return err
+			}
+			str, err := stringParameter(args[0])
+			if err != nil {
+				return err
+			}
+			if str == "" {
+				return &object.Numeric{Value: 0}
+			}
+			// Read a whole character, as made by CHR$, so codes above 127 come back unchanged
+			c, _ := utf8.DecodeRuneInString(str)
+			return &object.Numeric{Value: float64(c)}
+		},
+	},
+	"HEX$": &object.Builtin{
+		Syntax: "HEX$(e)",
+		Fn: func(env *object.Environment, g *game.Game, args []object.Object) object.Object {
+			// HEX$ works on 16-bit values so negative numbers come out in two's complement,
+			// e.g. HEX$(-1) is FFFF
+			if err := checkParameterCount("HEX$", args, 1, 1); err != nil {
+				return err
+			}
+			val, ok := args[0].(*object.Numeric)
+			if !ok {
+				return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: 0}
+			}
+			n := int(val.Value)
+			if n < -32768 || n > 65535 {
+				return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: 0}
+			}
+			return &object.String{Value: fmt.Sprintf("%X", uint16(n))}
+		},
+	},
+	"STRING$": &object.Builtin{
+		Syntax: "STRING$(n, e$)",
+		Fn: func(env *object.Environment, g *game.Game, args []object.Object) object.Object {
+			// STRING$(count, string) repeats the string count times.  A character code can be
+			// passed instead of the string.
+			if err := checkParameterCount("STRING$", args, 2, 2); err != nil {
+				return err
+			}
+			n, err := countParameter(args[0])
+			if err != nil {
+				return err
+			}
+			var str string
+			switch arg := args[1].(type) {
+			case *object.String:
+				str = arg.Value
+			case *object.Numeric:
+				if str, err = characterCodeParameter(arg); err != nil {
+					return err
+				}
+			default:
+				return &object.Error{Code: syntaxerror.NumericOrStringExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericOrStringExpressionNeeded), ErrorTokenIndex: 0}
+			}
+			return &object.String{Value: strings.Repeat(str, n)}
+		},
+	},
+	"DATE$": &object.Builtin{
+		Syntax: "DATE$",
+		Fn: func(env *object.Environment, g *game.Game, args []object.Object) object.Object {
+			if err := checkParameterCount("DATE$", args, 0, 0); err != nil {
+				return err
+			}
+			return &object.String{Value: env.Now().Format(dateFormat)}
+		},
+	},
+	"TIME$": &object.Builtin{
+		Syntax: "TIME$",
+		Fn: func(env *object.Environment, g *game.Game, args []object.Object) object.Object {
+			if err := checkParameterCount("TIME$", args, 0, 0); err != nil {
+				return err
+			}
+			return &object.String{Value: env.Now().Format(timeFormat)}
+		},
+	},
+	"TIME": &object.Builtin{
+		Syntax: "TIME",
+		Fn: func(env *object.Environment, g *game.Game, args []object.Object) object.Object {
+			// TIME counts centiseconds since the interpreter started
+			if err := checkParameterCount("TIME", args, 0, 0); err != nil {
+				return err
+			}
+			return &object.Numeric{Value: float64(env.SinceStarted() / (10 * time.Millisecond))}
+		},
+	},
+}
+
+// checkParameterCount returns an error if a builtin function was passed too few or too many
+// parameters, otherwise nil
+func checkParameterCount(name string, args []object.Object, min, max int) *object.Error {
+	if len(args) < min {
+		return &object.Error{Code: syntaxerror.NotEnoughParametersFor, Message: syntaxerror.ErrorMessage(syntaxerror.NotEnoughParametersFor) + name, ErrorTokenIndex: 0}
+	}
+	if len(args) > max {
+		return &object.Error{Code: syntaxerror.TooManyParametersFor, Message: syntaxerror.ErrorMessage(syntaxerror.TooManyParametersFor) + name, ErrorTokenIndex: 0}
+	}
+	return nil
+}
+
+// stringParameter returns the value of a string parameter or an error if it isn't a string
+func stringParameter(arg object.Object) (string, *object.Error) {
+	if val, ok := arg.(*object.String); ok {
+		return val.Value, nil
+	}
+	return "", &object.Error{Code: syntaxerror.StringExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.StringExpressionNeeded), ErrorTokenIndex: 0}
+}
+
+// countParameter returns the value of a numeric parameter that counts characters, which can't
+// be negative
+func countParameter(arg object.Object) (int, *object.Error) {
+	val, ok := arg.(*object.Numeric)
+	if !ok {
+		return 0, &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: 0}
+	}
+	if val.Value < 0 {
+		return 0, &object.Error{Code: syntaxerror.PositiveValueRequired, Message: syntaxerror.ErrorMessage(syntaxerror.PositiveValueRequired), ErrorTokenIndex: 0}
+	}
+	return int(val.Value), nil
+}
+
+// characterCodeParameter returns the character with the code given by a numeric parameter,
+// which must be from 0 to 255
+func characterCodeParameter(arg object.Object) (string, *object.Error) {
+	val, ok := arg.(*object.Numeric)
+	if !ok {
+		return "", &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: 0}
+	}
+	if val.Value < 0 || val.Value > 255 {
+		return "", &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: 0}
+	}
+	return string(rune(val.Value)), nil
+}
+
+// positionParameter returns the value of a numeric parameter that gives a character position
+// in a string, where the first character is 1
+func positionParameter(arg object.Object) (int, *object.Error) {
+	val, ok := arg.(*object.Numeric)
+	if !ok {
+		return 0, &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: 0}
+	}
+	if val.Value < 1 {
+		return 0, &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: 0}
+	}
+	return int(val.Value), nil
 }
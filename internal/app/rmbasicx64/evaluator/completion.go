@@ -0,0 +1,228 @@
+package evaluator
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/object"
+	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/token"
+)
+
+// commandSyntax gives a one-line summary of how each command is used, for syntax hints.
+// Commands that start with more than one keyword are listed under both of them.
+var commandSyntax = map[string]string{
+	token.AREA:                        "AREA coordinateList [optionList]",
+	token.ASK + " " + token.MOUSE:     "ASK MOUSE [v1, v2][, v3]",
+	token.ASK + " " + token.BLOCKSIZE: "ASK BLOCKSIZE block, xPixels [, yPixels [, screenChars]]",
+	token.AUTO:                        "AUTO [e1 [, e2]]",
+	token.CHDIR:                       "CHDIR e$",
+	token.CIRCLE:                      "CIRCLE e, coordinateList [optionList]",
+	token.CLEARBLOCK:                  "CLEARBLOCK",
+	token.CLOSE:                       "CLOSE [#e]",
+	token.CLS:                         "CLS [~e]",
+	token.CONTINUE:                    "CONTINUE",
+	token.COPYBLOCK:                   "COPYBLOCK xMin, yMin; xMax, yMax; xDest, yDest [, plotMode]",
+	token.CREATE:                      "CREATE #e1, e2$",
+	token.DATA:                        "DATA c1[, c2...]",
+	token.DELBLOCK:                    "DELBLOCK block",
+	token.DELETE:                      "DELETE [n1] [TO [n2]]",
+	token.DIR:                         "DIR [#e1,] [~e2,] [e3$]",
+	token.EDIT:                        "EDIT [lineNumber]",
+	token.END:                         "END",
+	token.ERASE:                       "ERASE e$",
+	token.FETCH:                       "FETCH block, filename",
+	token.FLOOD:                       "FLOOD coordinateList [optionList]",
+	token.FOR:                         "FOR v := e1 TO e2 [STEP e3]",
+	token.FUNCTION:                    "FUNCTION v1([v2 [, v3...]])",
+	token.GLOBAL:                      "GLOBAL v1[, v2...]",
+	token.GOSUB:                       "GOSUB label",
+	token.GOTO:                        "GOTO lineNumber",
+	token.HOME:                        "HOME",
+	token.IF:                          "IF t THEN instructions [ELSE instructions]",
+	token.INPUT:                       "INPUT [#e1,] [~e2,] [e$;] v",
+	token.KEEP:                        "KEEP block, filename",
+	token.LET:                         "[LET] v := e",
+	token.LINE:                        "LINE coordinateList [optionList]",
+	token.LIST:                        "LIST [#e1,] [~e2,] [n1] [TO [n2]]",
+	token.LOAD:                        "LOAD e$",
+	token.LOADGO:                      "LOADGO e$",
+	token.LVAR:                        "LVAR",
+	token.MERGE:                       "MERGE e$",
+	token.MERGEGO:                     "MERGEGO e$",
+	token.MKDIR:                       "MKDIR e$",
+	token.MOVE:                        "MOVE e1, e2",
+	token.NEW:                         "NEW",
+	token.NEXT:                        "NEXT [v1[, v2...]]",
+	token.NOTE:                        "NOTE e1 [TO e2] [, e3 [, e4]] [ENVELOPE e5] [VOICE e6]",
+	token.ON:                          "ON e GOTO n1[, n2...] | ON e GOSUB label1[, label2...]",
+	token.ON + " " + token.BREAK:      "ON BREAK GOTO lineNumber",
+	token.ON + " " + token.ERROR:      "ON ERROR GOTO lineNumber",
+	token.OPEN:                        "OPEN #e1, e2$",
+	token.PLOT:                        "PLOT e$, coordinateList [optionList]",
+	token.POINTS:                      "POINTS coordinateList [optionList]",
+	token.PRINT:                       "PRINT [#e1,] [~e2,] [printList]",
+	token.PROCEDURE:                   "PROCEDURE v1 [v2 [, v3...]] [RETURN v4 [, v5...]]",
+	token.PROCS:                       "PROCS",
+	token.PUT:                         "PUT [~e1] e2[, e3...]",
+	token.READ:                        "READ v1[, v2...]",
+	token.READBLOCK:                   "READBLOCK block, xMin, yMin; xMax, yMax",
+	token.REM:                         "REM comment",
+	token.RENAME:                      "RENAME e1$ TO e2$",
+	token.RENUMBER:                    "RENUMBER [e1 [, e2 [, e3 [, e4]]]]",
+	token.REPEAT:                      "REPEAT ... UNTIL t",
+	token.RESTORE:                     "RESTORE [lineNumber]",
+	token.RESUME:                      "RESUME [NEXT | lineNumber]",
+	token.RMDIR:                       "RMDIR e$",
+	token.RUN:                         "RUN [lineNumber]",
+	token.SAVE:                        "SAVE e$",
+	token.SET + " " + token.BORDER:    "SET BORDER e",
+	token.SET + " " + token.COLOUR:    "SET COLOUR e1 TO e2[, e3, e4]",
+	token.SET + " " + token.CURPOS:    "SET CURPOS e1, e2",
+	token.SET + " " + token.DEG:       "SET DEG t",
+	token.SET + " " + token.MODE:      "SET MODE e",
+	token.SET + " " + token.PAPER:     "SET PAPER e",
+	token.SET + " " + token.PEN:       "SET PEN e",
+	token.SET + " " + token.RAD:       "SET RAD t",
+	token.SET + " " + token.TRACE:     "SET TRACE ON | LVAR [#channel] | OFF",
+	token.SET + " " + token.WRITING:   "SET WRITING e1 [TO e2, e3; e4, e5]",
+	token.SQUASH:                      "SQUASH block, x, y [, plotMode]",
+	token.STOP:                        "STOP",
+	token.SUBROUTINE:                  "SUBROUTINE label",
+	token.UNTIL:                       "UNTIL t",
+	token.WRITEBLOCK:                  "WRITEBLOCK block, x, y [, plotMode]",
+}
+
+// isWordChar returns true if r can be part of a keyword or identifier
+func isWordChar(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '$' || r == '%'
+}
+
+// completionNames returns the keywords, functions, procedures and variables that a partly
+// typed word can be completed to
+func completionNames(env *object.Environment) []string {
+	names := map[string]bool{}
+	for _, keyword := range token.Keywords() {
+		names[keyword] = true
+	}
+	for name := range builtins {
+		names[name] = true
+	}
+	for _, proc := range env.Procedures() {
+		names[proc.Name.Value] = true
+	}
+	for _, fun := range env.Functions() {
+		names[fun.Name.Value] = true
+	}
+	for _, v := range env.Variables() {
+		names[v.Name] = true
+	}
+	sorted := []string{}
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// Complete completes the word that ends at position in buffer and returns the new buffer and
+// position.  If the word could be completed more than one way it's only completed as far as
+// all the ways agree.
+func Complete(env *object.Environment, buffer string, position int) (string, int) {
+	r := []rune(buffer)
+	if position > len(r) {
+		position = len(r)
+	}
+	start := position
+	for start > 0 && isWordChar(r[start-1]) {
+		start--
+	}
+	if start == position || unicode.IsDigit(r[start]) {
+		// Nothing to complete
+		return buffer, position
+	}
+	word := strings.ToUpper(string(r[start:position]))
+	completion := ""
+	for _, name := range completionNames(env) {
+		if !strings.HasPrefix(strings.ToUpper(name), word) {
+			continue
+		}
+		if completion == "" {
+			completion = name
+			continue
+		}
+		// Cut the completion back to the part both names share
+		c, n := []rune(completion), []rune(name)
+		i := 0
+		for i < len(c) && i < len(n) && unicode.ToUpper(c[i]) == unicode.ToUpper(n[i]) {
+			i++
+		}
+		completion = string(c[:i])
+	}
+	if len([]rune(completion)) <= len([]rune(word)) {
+		return buffer, position
+	}
+	return string(r[:start]) + completion + string(r[position:]), start + len([]rune(completion))
+}
+
+// SyntaxHint returns a one-line hint about how to use the keyword, function or procedure at
+// position in buffer, or "" if there's nothing to say
+func SyntaxHint(env *object.Environment, buffer string, position int) string {
+	// Split the buffer into words and find the one the cursor is in or just after
+	r := []rune(buffer)
+	type span struct {
+		start, end int
+	}
+	words := []span{}
+	current := -1
+	for i := 0; i < len(r); {
+		if !isWordChar(r[i]) {
+			i++
+			continue
+		}
+		start := i
+		for i < len(r) && isWordChar(r[i]) {
+			i++
+		}
+		if position >= start && position <= i {
+			current = len(words)
+		}
+		words = append(words, span{start, i})
+	}
+	if current < 0 {
+		return ""
+	}
+	word := func(i int) string {
+		return strings.ToUpper(string(r[words[i].start:words[i].end]))
+	}
+	// Try commands made from two keywords first, e.g. SET WRITING
+	if current+1 < len(words) {
+		if hint, ok := commandSyntax[word(current)+" "+word(current+1)]; ok {
+			return hint
+		}
+	}
+	if current > 0 {
+		if hint, ok := commandSyntax[word(current-1)+" "+word(current)]; ok {
+			return hint
+		}
+	}
+	name := word(current)
+	if builtin, ok := builtins[name]; ok && builtin.Syntax != "" {
+		return builtin.Syntax
+	}
+	if hint, ok := commandSyntax[name]; ok {
+		return hint
+	}
+	// Procedures and functions in the program
+	for _, proc := range env.Procedures() {
+		if strings.EqualFold(proc.Name.Value, name) {
+			return procedureSignature(proc)
+		}
+	}
+	for _, fun := range env.Functions() {
+		if strings.EqualFold(fun.Name.Value, name) {
+			return functionSignature(fun)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,578 @@
+package evaluator
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/game"
+	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/lexer"
+	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/object"
+	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/parser"
+	"github.com/adamstimb/rmbasicx64/pkg/nimgobus/keycode"
+)
+
+// editorHelp is shown in the message row when there is nothing else to say
+const editorHelp = "ESC leave  INS new line  F1 mark  F2 copy  F3 move  F4 delete  F5 find  F6 find next"
+
+// editor is the full-screen program editor.  The stored program is shown in the current text
+// box with one line per row and the bottom row of the box is used for messages.  The line
+// being edited is parsed when the cursor leaves it and, if it has no errors, committed to the
+// program with AddLine.
+type editor struct {
+	g           *game.Game
+	env         *object.Environment
+	lineNumbers []int
+	lines       []string
+	row         int    // index of the line being edited
+	col         int    // position of the cursor in the text of the line being edited
+	top         int    // index of the line shown in the first row
+	left        int    // first column shown, for lines wider than the text box
+	rows        int    // number of rows available for lines
+	width       int    // number of columns available, including the margin
+	changed     bool   // true if the line being edited hasn't been committed
+	errorLine   int    // line number of the line that failed to parse, or -1
+	markFrom    int    // first line number of the marked block, or -1
+	markTo      int    // last line number of the marked block, or -1 while it is being marked
+	search      string // text to find
+	status      string // message shown instead of the help
+	redraw      bool   // true if every row has to be drawn again
+}
+
+func newEditor(g *game.Game, env *object.Environment) *editor {
+	_, col1, row1, col2, row2 := g.AskWriting()
+	e := &editor{
+		g:         g,
+		env:       env,
+		rows:      row2 - row1 - 1,
+		width:     col2 - col1,
+		errorLine: -1,
+		markFrom:  -1,
+		markTo:    -1,
+	}
+	if e.rows < 1 {
+		e.rows = 1
+	}
+	e.load()
+	return e
+}
+
+// run edits the program until ESC or <BREAK> is pressed
+func (e *editor) run() {
+	e.g.Cls()
+	for {
+		e.draw()
+		key := e.g.Get()
+		for key == -1 {
+			if e.g.AskBreak() {
+				e.commit()
+				e.g.Cls()
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+			key = e.g.Get()
+		}
+		if e.handleKey(key) {
+			break
+		}
+	}
+	e.g.Cls()
+}
+
+// handleKey acts on a key press and returns true when it's time to leave the editor
+func (e *editor) handleKey(key int) bool {
+	if e.errorLine < 0 {
+		e.status = ""
+	}
+	var text []rune
+	if e.row < len(e.lines) {
+		text = []rune(e.lines[e.row])
+	}
+	switch key {
+	case keycode.Escape:
+		return e.leave()
+	case keycode.Up:
+		e.moveTo(e.row - 1)
+	case keycode.Down:
+		e.moveTo(e.row + 1)
+	case keycode.PageUp:
+		e.moveTo(e.row - e.rows)
+	case keycode.PageDown:
+		e.moveTo(e.row + e.rows)
+	case keycode.Left:
+		if e.col > 0 {
+			e.col--
+		}
+	case keycode.Right:
+		if e.col < len(text) {
+			e.col++
+		}
+	case keycode.Home:
+		e.col = 0
+	case keycode.End:
+		e.col = len(text)
+	case keycode.Enter:
+		// Go to the start of the next line, adding a new one at the end of the program
+		if e.row >= len(e.lines)-1 {
+			e.insertLine()
+		} else {
+			e.moveTo(e.row + 1)
+			e.col = 0
+		}
+	case keycode.Insert:
+		e.insertLine()
+	case keycode.Backspace:
+		if e.col > 0 {
+			e.col--
+			e.setText(append(text[:e.col], text[e.col+1:]...))
+		}
+	case keycode.Delete:
+		if e.col < len(text) {
+			e.setText(append(text[:e.col], text[e.col+1:]...))
+		}
+	case keycode.F1:
+		e.mark()
+	case keycode.F2:
+		e.copyBlock(false)
+	case keycode.F3:
+		e.copyBlock(true)
+	case keycode.F4:
+		e.deleteLines()
+	case keycode.F5:
+		e.find(true)
+	case keycode.F6:
+		e.find(false)
+	default:
+		if key >= 32 && e.row < len(e.lines) {
+			newText := append([]rune{}, text[:e.col]...)
+			newText = append(newText, rune(key))
+			e.setText(append(newText, text[e.col:]...))
+			e.col++
+		}
+	}
+	return false
+}
+
+// load reads the lines from the stored program
+func (e *editor) load() {
+	sortedIndex, lines := e.env.Program.Dump()
+	e.lineNumbers = append([]int{}, sortedIndex...)
+	e.lines = make([]string, len(sortedIndex))
+	for i, lineNumber := range sortedIndex {
+		e.lines[i] = lines[lineNumber]
+	}
+	e.redraw = true
+}
+
+// gotoLine moves the cursor to lineNumber, or the line after it if there's no such line
+func (e *editor) gotoLine(lineNumber int) {
+	e.row = len(e.lines) - 1
+	for i, n := range e.lineNumbers {
+		if n >= lineNumber {
+			e.row = i
+			break
+		}
+	}
+	if e.row < 0 {
+		e.row = 0
+	}
+	e.clampCol()
+}
+
+// clampCol keeps the cursor within the text of the line being edited
+func (e *editor) clampCol() {
+	length := 0
+	if e.row < len(e.lines) {
+		length = len([]rune(e.lines[e.row]))
+	}
+	if e.col > length {
+		e.col = length
+	}
+}
+
+func (e *editor) setText(text []rune) {
+	e.lines[e.row] = string(text)
+	e.changed = true
+}
+
+// prefix returns the line number shown before the text of a line
+func (e *editor) prefix(i int) string {
+	return fmt.Sprintf("%d ", e.lineNumbers[i])
+}
+
+// commit parses the line being edited and stores it in the program.  If the line has an error
+// it stays in the editor, marked with "!", with the cursor on the error and false is returned.
+func (e *editor) commit() bool {
+	if !e.changed || e.row >= len(e.lines) {
+		return true
+	}
+	lineNumber := e.lineNumbers[e.row]
+	// Check the statements the same way as when the program is run
+	l := &lexer.Lexer{}
+	l.Scan(e.lines[e.row])
+	p := parser.New(l, e.g)
+	p.ParseLine()
+	errorMsg, hasError := p.GetError()
+	if !hasError && len(p.Errors()) > 0 {
+		errorMsg, hasError = p.Errors()[0], true
+	}
+	if hasError {
+		e.status = errorMsg
+		e.errorLine = lineNumber
+		if p.ErrorTokenIndex > 0 && p.ErrorTokenIndex <= len(l.TokenEnds) {
+			// Put the cursor at the start of the token with the error
+			source := e.lines[e.row]
+			pos := l.TokenEnds[p.ErrorTokenIndex-1]
+			for pos < len(source) && source[pos] == ' ' {
+				pos++
+			}
+			e.col = len([]rune(source[:pos]))
+		}
+		e.redraw = true
+		return false
+	}
+	// Then store it the same way as a line typed at the prompt
+	l.Scan(e.prefix(e.row) + e.lines[e.row])
+	line := parser.New(l, e.g).ParseLine()
+	e.env.Program.AddLine(lineNumber, line.LineString)
+	e.changed = false
+	e.errorLine = -1
+	e.status = ""
+	e.load()
+	e.gotoLine(lineNumber)
+	return true
+}
+
+// leave returns true if the editor can be left.  The line being edited is committed first
+// unless it has already been shown to have an error, in which case it is thrown away.
+func (e *editor) leave() bool {
+	if e.changed && e.errorLine >= 0 {
+		e.changed = false
+		e.errorLine = -1
+		return true
+	}
+	if !e.commit() {
+		e.status += " - ESC again to abandon the line"
+		return false
+	}
+	return true
+}
+
+// moveTo moves the cursor to another line, committing the line being edited first
+func (e *editor) moveTo(row int) {
+	if len(e.lines) == 0 {
+		return
+	}
+	if row > len(e.lines)-1 {
+		row = len(e.lines) - 1
+	}
+	if row < 0 {
+		row = 0
+	}
+	if row == e.row {
+		return
+	}
+	lineNumber := e.lineNumbers[row]
+	if !e.commit() {
+		return
+	}
+	e.gotoLine(lineNumber)
+}
+
+// newLineNumbers returns count unused line numbers between the line at row and the next one
+func (e *editor) newLineNumbers(row, count int) ([]int, bool) {
+	from := 0
+	if row < len(e.lineNumbers) {
+		from = e.lineNumbers[row]
+	}
+	to := from + 10*(count+1)
+	if row+1 < len(e.lineNumbers) {
+		to = e.lineNumbers[row+1]
+	}
+	step := (to - from) / (count + 1)
+	if step > 10 {
+		step = 10
+	}
+	if step < 1 {
+		return nil, false
+	}
+	numbers := make([]int, count)
+	for i := range numbers {
+		numbers[i] = from + step*(i+1)
+	}
+	return numbers, true
+}
+
+// insertLine adds an empty line after the line being edited.  It isn't stored in the program
+// until something is typed into it.
+func (e *editor) insertLine() {
+	if !e.commit() {
+		return
+	}
+	numbers, ok := e.newLineNumbers(e.row, 1)
+	if !ok {
+		e.status = "No room for a new line, RENUMBER the program first"
+		return
+	}
+	index := e.row + 1
+	if index > len(e.lines) {
+		index = len(e.lines)
+	}
+	e.lineNumbers = append(e.lineNumbers[:index], append([]int{numbers[0]}, e.lineNumbers[index:]...)...)
+	e.lines = append(e.lines[:index], append([]string{""}, e.lines[index:]...)...)
+	e.row = index
+	e.col = 0
+	e.changed = true
+	e.redraw = true
+}
+
+// mark marks the start of a block, then the end of it, then clears it
+func (e *editor) mark() {
+	if e.row >= len(e.lines) {
+		return
+	}
+	lineNumber := e.lineNumbers[e.row]
+	switch {
+	case e.markFrom < 0:
+		e.markFrom = lineNumber
+		e.status = "Move to the end of the block and press F1"
+	case e.markTo < 0:
+		e.markTo = lineNumber
+		if e.markTo < e.markFrom {
+			e.markFrom, e.markTo = e.markTo, e.markFrom
+		}
+	default:
+		e.markFrom, e.markTo = -1, -1
+	}
+	e.redraw = true
+}
+
+// marked returns true if the line at row is in the marked block
+func (e *editor) marked(row int) bool {
+	if e.markFrom < 0 {
+		return false
+	}
+	lineNumber := e.lineNumbers[row]
+	if e.markTo < 0 {
+		return lineNumber == e.markFrom
+	}
+	return lineNumber >= e.markFrom && lineNumber <= e.markTo
+}
+
+// copyBlock copies the marked block to after the line being edited.  If move is true the
+// block is deleted from where it was.
+func (e *editor) copyBlock(move bool) {
+	if !e.commit() {
+		return
+	}
+	if e.markFrom < 0 || e.markTo < 0 {
+		e.status = "Mark the start and end of a block with F1 first"
+		return
+	}
+	if e.row >= len(e.lines) {
+		return
+	}
+	lineNumber := e.lineNumbers[e.row]
+	if move && lineNumber >= e.markFrom && lineNumber <= e.markTo {
+		e.status = "A block can't be moved inside itself"
+		return
+	}
+	block := []string{}
+	for i := range e.lines {
+		if e.marked(i) {
+			block = append(block, strings.TrimSpace(e.lines[i]))
+		}
+	}
+	numbers, ok := e.newLineNumbers(e.row, len(block))
+	if !ok {
+		e.status = "No room for the block, RENUMBER the program first"
+		return
+	}
+	for i, text := range block {
+		e.env.Program.AddLine(numbers[i], text)
+	}
+	if move {
+		e.env.Program.Delete(e.markFrom, e.markTo)
+	}
+	// The block that was just added is marked so that it can be moved again
+	e.markFrom, e.markTo = numbers[0], numbers[len(numbers)-1]
+	e.load()
+	e.gotoLine(numbers[0])
+}
+
+// deleteLines deletes the marked block or, if there isn't one, the line being edited
+func (e *editor) deleteLines() {
+	if e.row >= len(e.lines) {
+		return
+	}
+	from, to := e.lineNumbers[e.row], e.lineNumbers[e.row]
+	if e.markFrom >= 0 && e.markTo >= 0 {
+		from, to = e.markFrom, e.markTo
+	}
+	count := 0
+	for _, lineNumber := range e.lineNumbers {
+		if lineNumber >= from && lineNumber <= to {
+			count++
+		}
+	}
+	if count > deleteConfirmationLines && !e.confirm(fmt.Sprintf("Delete %d lines?", count)) {
+		return
+	}
+	current := e.lineNumbers[e.row]
+	if current >= from && current <= to {
+		// No point keeping changes to a line that's about to go
+		e.changed = false
+		e.errorLine = -1
+	} else if !e.commit() {
+		return
+	}
+	e.env.Program.Delete(from, to)
+	e.markFrom, e.markTo = -1, -1
+	e.load()
+	e.gotoLine(from)
+}
+
+// confirm asks a question in the message row and returns true if Y is pressed
+func (e *editor) confirm(question string) bool {
+	e.status = question + " (Y/N)"
+	e.drawStatus()
+	defer func() {
+		e.status = ""
+	}()
+	for {
+		key := e.g.Get()
+		switch {
+		case key == 'Y' || key == 'y':
+			return true
+		case key == 'N' || key == 'n' || key == keycode.Escape:
+			return false
+		case key < 0:
+			if e.g.AskBreak() {
+				return false
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
+
+// find moves the cursor to the next place the search text appears, wrapping round to the
+// start of the program.  If prompt is true the search text is asked for first.
+func (e *editor) find(prompt bool) {
+	if prompt {
+		e.g.SetCurpos(1, e.rows+1)
+		e.g.Print(fit("", 0, e.width))
+		e.g.SetCurpos(1, e.rows+1)
+		e.g.Print("Find: ")
+		e.search = e.g.Input(e.search)
+		e.redraw = true
+	}
+	if e.search == "" || len(e.lines) == 0 || !e.commit() {
+		return
+	}
+	search := []rune(strings.ToUpper(e.search))
+	for i := 0; i <= len(e.lines); i++ {
+		row := (e.row + i) % len(e.lines)
+		start := 0
+		if i == 0 {
+			start = e.col + 1
+		}
+		if col := runeIndex([]rune(strings.ToUpper(e.lines[row])), search, start); col >= 0 {
+			e.row = row
+			e.col = col
+			return
+		}
+	}
+	e.status = fmt.Sprintf("Can't find %s", e.search)
+}
+
+// runeIndex returns the position of sub in s at or after from, or -1 if it isn't there
+func runeIndex(s, sub []rune, from int) int {
+	for i := from; i+len(sub) <= len(s); i++ {
+		if string(s[i:i+len(sub)]) == string(sub) {
+			return i
+		}
+	}
+	return -1
+}
+
+// draw updates the text box, scrolling if necessary to keep the cursor in view
+func (e *editor) draw() {
+	if e.row < e.top {
+		e.top = e.row
+		e.redraw = true
+	}
+	if e.row >= e.top+e.rows {
+		e.top = e.row - e.rows + 1
+		e.redraw = true
+	}
+	x := e.col
+	if e.row < len(e.lines) {
+		x += len(e.prefix(e.row))
+	}
+	textWidth := e.width - 1
+	if x < textWidth && e.left != 0 {
+		// No need to scroll sideways any more
+		e.left = 0
+		e.redraw = true
+	}
+	if x < e.left {
+		e.left = x
+		e.redraw = true
+	}
+	if x >= e.left+textWidth {
+		e.left = x - textWidth + 1
+		e.redraw = true
+	}
+	if e.redraw {
+		for i := e.top; i < e.top+e.rows; i++ {
+			e.drawRow(i)
+		}
+		e.redraw = false
+	} else {
+		e.drawRow(e.row)
+	}
+	e.drawStatus()
+	e.g.SetCurpos(2+x-e.left, 1+e.row-e.top)
+}
+
+// drawRow draws the line at row i.  The margin shows "!" if the line has an error and ">" if
+// it's in the marked block.
+func (e *editor) drawRow(i int) {
+	margin, text := " ", ""
+	if i < len(e.lines) {
+		text = e.prefix(i) + e.lines[i]
+		if e.lineNumbers[i] == e.errorLine {
+			margin = "!"
+		} else if e.marked(i) {
+			margin = ">"
+		}
+	}
+	e.g.SetCurpos(1, 1+i-e.top)
+	e.g.Print(margin + fit(text, e.left, e.width-1))
+}
+
+func (e *editor) drawStatus() {
+	status := e.status
+	if status == "" {
+		status = editorHelp
+	}
+	e.g.SetCurpos(1, e.rows+1)
+	e.g.Print(fit(status, 0, e.width))
+}
+
+// fit returns s from column left onwards, cut or padded with spaces to width columns
+func fit(s string, left, width int) string {
+	if width < 0 {
+		width = 0
+	}
+	r := []rune(s)
+	if left < len(r) {
+		r = r[left:]
+	} else {
+		r = nil
+	}
+	if len(r) > width {
+		r = r[:width]
+	}
+	return string(r) + strings.Repeat(" ", width-len(r))
+}
@@ -9,18 +9,20 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/ast"
+	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/console"
 	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/game"
 	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/lexer"
 	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/object"
 	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/parser"
 	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/syntaxerror"
 	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/token"
-	"github.com/adamstimb/rmbasicx64/pkg/nimgobus"
+	"github.com/adamstimb/rmbasicx64/pkg/nimgobus/options"
 )
 
 // Because null and boolean values never change we can reference them instead of
@@ -40,6 +42,14 @@ func Eval(g *game.Game, node ast.Node, env *object.Environment) object.Object {
 	case *ast.EndStatement:
 		env.EndProgram()
 		return nil
+	case *ast.StopStatement:
+		return evalStopStatement(g, node, env)
+	case *ast.ContinueStatement:
+		return evalContinueStatement(g, node, env)
+	case *ast.LvarStatement:
+		return evalLvarStatement(g, node, env)
+	case *ast.ProcsStatement:
+		return evalProcsStatement(g, node, env)
 	case *ast.RunStatement:
 		return evalRunStatement(g, node, env)
 	case *ast.NewStatement:
@@ -95,6 +105,12 @@ func Eval(g *game.Game, node ast.Node, env *object.Environment) object.Object {
 		return evalSetMouseStatement(g, node, env)
 	case *ast.SetModeStatement:
 		return evalSetModeStatement(g, node, env)
+	case *ast.SetDateStatement:
+		return evalSetDateStatement(g, node, env)
+	case *ast.SetTimeStatement:
+		return evalSetTimeStatement(g, node, env)
+	case *ast.SetTraceStatement:
+		return evalSetTraceStatement(g, node, env)
 	case *ast.SetPaperStatement:
 		return evalSetPaperStatement(g, node, env)
 	case *ast.SetBorderStatement:
@@ -151,6 +167,10 @@ func Eval(g *game.Game, node ast.Node, env *object.Environment) object.Object {
 		return evalNoteStatement(g, node, env)
 	case *ast.GotoStatement:
 		return evalGotoStatement(g, node, env)
+	case *ast.DeleteStatement:
+		return evalDeleteStatement(g, node, env)
+	case *ast.AutoStatement:
+		return evalAutoStatement(g, node, env)
 	case *ast.EditStatement:
 		return evalEditStatement(g, node, env)
 	case *ast.DataStatement:
@@ -161,6 +181,14 @@ func Eval(g *game.Game, node ast.Node, env *object.Environment) object.Object {
 		return evalGosubStatement(g, node, env)
 	case *ast.ReturnStatement:
 		return evalReturnStatement(g, node, env)
+	case *ast.OnErrorStatement:
+		return evalOnErrorStatement(g, node, env)
+	case *ast.OnBreakStatement:
+		return evalOnBreakStatement(g, node, env)
+	case *ast.OnJumpStatement:
+		return evalOnJumpStatement(g, node, env)
+	case *ast.ResumeStatement:
+		return evalResumeStatement(g, node, env)
 	case *ast.FunctionDeclaration:
 		return evalFunctionDeclaration(g, node, env)
 	case *ast.ProcedureDeclaration:
@@ -205,6 +233,10 @@ func Eval(g *game.Game, node ast.Node, env *object.Environment) object.Object {
 		return evalBlockStatement(g, node, env)
 	case *ast.IfStatement:
 		return evalIfStatement(g, node, env)
+	case *ast.ElseStatement:
+		return evalElseStatement(g, node, env)
+	case *ast.EndifStatement:
+		return nil
 	case *ast.LetStatement:
 		val := Eval(g, node.Value, env)
 		if isError(val) {
@@ -218,14 +250,19 @@ func Eval(g *game.Game, node ast.Node, env *object.Environment) object.Object {
 				if val, ok := obj.(*object.Numeric); ok {
 					subscripts[i] = int(val.Value)
 				} else {
-					return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: node.Token.Index}
+					return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: node.Token.Index}
 				}
 			}
-			ret, _ := env.SetArray(node.Name.Value, subscripts, val)
+			ret, ok := env.SetArray(node.Name.Value, subscripts, val)
+			if ok {
+				traceArrayElement(g, env, node.Name.Value, subscripts)
+			}
 			return ret
 		} else {
 			// is variable
-			return env.Set(node.Name.Value, val)
+			ret := env.Set(node.Name.Value, val)
+			traceVariable(g, env, node.Name.Value, ret)
+			return ret
 		}
 	case *ast.BindStatement:
 		val := Eval(g, node.Value, env)
@@ -240,14 +277,19 @@ func Eval(g *game.Game, node ast.Node, env *object.Environment) object.Object {
 				if val, ok := obj.(*object.Numeric); ok {
 					subscripts[i] = int(val.Value)
 				} else {
-					return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: node.Token.Index}
+					return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: node.Token.Index}
 				}
 			}
-			ret, _ := env.SetArray(node.Name.Value, subscripts, val)
+			ret, ok := env.SetArray(node.Name.Value, subscripts, val)
+			if ok {
+				traceArrayElement(g, env, node.Name.Value, subscripts)
+			}
 			return ret
 		} else {
 			// is variable
-			return env.Set(node.Name.Value, val)
+			ret := env.Set(node.Name.Value, val)
+			traceVariable(g, env, node.Name.Value, ret)
+			return ret
 		}
 
 	// Expressions
@@ -280,6 +322,10 @@ func Eval(g *game.Game, node ast.Node, env *object.Environment) object.Object {
 		return result
 	case *ast.Identifier:
 		obj := evalIdentifier(g, node, env)
+		// A function that was stopped has no result
+		if obj == nil {
+			return nil
+		}
 		// Catch builtin
 		if obj.Type() == object.BUILTIN_OBJ {
 			args := evalExpressions(g, node.Subscripts, env)
@@ -358,12 +404,12 @@ func evalPrintStatement(g *game.Game, stmt *ast.PrintStatement, env *object.Envi
 	if stmt.Channel != nil {
 		if val, ok := Eval(g, stmt.Channel, env).(*object.Numeric); ok {
 			if val.Value < 11 || val.Value > 127 {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.WrongChannelNumberUsed), ErrorTokenIndex: stmt.Token.Index}
+				return &object.Error{Code: syntaxerror.WrongChannelNumberUsed, Message: syntaxerror.ErrorMessage(syntaxerror.WrongChannelNumberUsed), ErrorTokenIndex: stmt.Token.Index}
 			} else {
 				channel = int(val.Value)
 			}
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index}
 		}
 	}
 	// Evaluate and handle TextBoxSlot if set
@@ -375,7 +421,7 @@ func evalPrintStatement(g *game.Game, stmt *ast.PrintStatement, env *object.Envi
 		if val, ok := obj.(*object.Numeric); ok {
 			tempTextBoxSlot = int(val.Value)
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	}
 	if channel == 0 {
@@ -409,7 +455,8 @@ func evalPrintStatement(g *game.Game, stmt *ast.PrintStatement, env *object.Envi
 			continue
 		}
 		obj := Eval(g, val.(ast.Node), env)
-		if isError(obj) {
+		// Nothing comes back from a function that was stopped
+		if isError(obj) || obj == nil {
 			if oldTextBoxSlot != tempTextBoxSlot && channel == 0 {
 				g.SetWriting(oldTextBoxSlot)
 				g.SetCurpos(1, curY)
@@ -460,12 +507,12 @@ func evalInputStatement(g *game.Game, stmt *ast.InputStatement, env *object.Envi
 	if stmt.Channel != nil {
 		if val, ok := Eval(g, stmt.Channel, env).(*object.Numeric); ok {
 			if val.Value < 11 || val.Value > 127 {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.WrongChannelNumberUsed), ErrorTokenIndex: stmt.Token.Index}
+				return &object.Error{Code: syntaxerror.WrongChannelNumberUsed, Message: syntaxerror.ErrorMessage(syntaxerror.WrongChannelNumberUsed), ErrorTokenIndex: stmt.Token.Index}
 			} else {
 				channel = int(val.Value)
 			}
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index}
 		}
 	}
 	// Evaluate and handle TextBoxSlot if set
@@ -477,7 +524,7 @@ func evalInputStatement(g *game.Game, stmt *ast.InputStatement, env *object.Envi
 		if val, ok := obj.(*object.Numeric); ok {
 			tempTextBoxSlot = int(val.Value)
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	}
 	// Get raw input
@@ -555,7 +602,7 @@ func evalInputStatement(g *game.Game, stmt *ast.InputStatement, env *object.Envi
 			g.Print("??")
 			g.Put(13)
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.ReadingPastEndOfFile), ErrorTokenIndex: stmt.Token.Index}
+			return &object.Error{Code: syntaxerror.ReadingPastEndOfFile, Message: syntaxerror.ErrorMessage(syntaxerror.ReadingPastEndOfFile), ErrorTokenIndex: stmt.Token.Index}
 		}
 	}
 
@@ -586,7 +633,7 @@ func evalInputStatement(g *game.Game, stmt *ast.InputStatement, env *object.Envi
 				raw = "0"
 			}
 			if num, err := strconv.ParseFloat(raw, 64); err == nil {
-				obj = &object.Numeric{Value: float64(int(num))}
+				obj = &object.Numeric{Value: num}
 			} else {
 				obj = &object.Numeric{Value: 0.0}
 			}
@@ -603,6 +650,10 @@ func evalInputStatement(g *game.Game, stmt *ast.InputStatement, env *object.Envi
 		}
 		// Set the return variable
 		var ok bool
+		if obj, ok = canObjectCastToIdentifierType(obj, receiveVar.Token.Literal); !ok {
+			obj.(*object.Error).ErrorTokenIndex = stmt.Token.Index
+			return obj
+		}
 		// evaluate array subscripts, if any
 		var subscripts []int
 		if subs, obj, ok := evalArraySubscripts(g, env, receiveVar.Subscripts); ok {
@@ -642,12 +693,12 @@ func evalPutStatement(g *game.Game, stmt *ast.PutStatement, env *object.Environm
 	if stmt.Channel != nil {
 		if val, ok := Eval(g, stmt.Channel, env).(*object.Numeric); ok {
 			if val.Value < 11 || val.Value > 127 {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.WrongChannelNumberUsed), ErrorTokenIndex: stmt.Token.Index}
+				return &object.Error{Code: syntaxerror.WrongChannelNumberUsed, Message: syntaxerror.ErrorMessage(syntaxerror.WrongChannelNumberUsed), ErrorTokenIndex: stmt.Token.Index}
 			} else {
 				channel = int(val.Value)
 			}
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index}
 		}
 	}
 	// Evaluate and handle TextBoxSlot if set
@@ -659,7 +710,7 @@ func evalPutStatement(g *game.Game, stmt *ast.PutStatement, env *object.Environm
 		if val, ok := obj.(*object.Numeric); ok {
 			tempTextBoxSlot = int(val.Value)
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	}
 	if channel == 0 {
@@ -678,7 +729,7 @@ func evalPutStatement(g *game.Game, stmt *ast.PutStatement, env *object.Environm
 		if numericVal, ok := obj.(*object.Numeric); ok {
 			printList = append(printList, int(numericVal.Value))
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	}
 	for _, val := range printList {
@@ -713,10 +764,10 @@ func evalPlotStatement(g *game.Game, stmt *ast.PlotStatement, env *object.Enviro
 			if g.ValidateColour(int(val.Value)) {
 				Brush = int(val.Value)
 			} else {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+				return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 			}
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	}
 	if stmt.Direction == nil {
@@ -729,7 +780,7 @@ func evalPlotStatement(g *game.Game, stmt *ast.PlotStatement, env *object.Enviro
 		if val, ok := obj.(*object.Numeric); ok {
 			Direction = int(val.Value)
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	}
 	if stmt.Font == nil {
@@ -742,7 +793,7 @@ func evalPlotStatement(g *game.Game, stmt *ast.PlotStatement, env *object.Enviro
 		if val, ok := obj.(*object.Numeric); ok {
 			Font = int(val.Value)
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	}
 	if stmt.Over == nil {
@@ -755,7 +806,7 @@ func evalPlotStatement(g *game.Game, stmt *ast.PlotStatement, env *object.Enviro
 		if val, ok := obj.(*object.Numeric); ok {
 			Over = int(val.Value)
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	}
 	if stmt.SizeX == nil {
@@ -772,7 +823,7 @@ func evalPlotStatement(g *game.Game, stmt *ast.PlotStatement, env *object.Enviro
 				SizeX = 1
 			}
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	}
 	if stmt.SizeY == nil {
@@ -789,7 +840,7 @@ func evalPlotStatement(g *game.Game, stmt *ast.PlotStatement, env *object.Enviro
 				SizeY = 1
 			}
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	}
 	// Handle text string
@@ -811,7 +862,7 @@ func evalPlotStatement(g *game.Game, stmt *ast.PlotStatement, env *object.Enviro
 		Text = val.Value
 	}
 	// Handle coord list
-	var coordList []nimgobus.XyCoord
+	var coordList []options.XyCoord
 	var X, Y int
 	for i := 0; i < len(stmt.CoordList)-1; i += 2 {
 		obj := Eval(g, stmt.CoordList[i], env)
@@ -821,19 +872,19 @@ func evalPlotStatement(g *game.Game, stmt *ast.PlotStatement, env *object.Enviro
 		if val, ok := obj.(*object.Numeric); ok {
 			X = int(val.Value)
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 		obj = Eval(g, stmt.CoordList[i+1], env)
 		if val, ok := obj.(*object.Numeric); ok {
 			Y = int(val.Value)
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
-		coordList = append(coordList, nimgobus.XyCoord{X, Y})
+		coordList = append(coordList, options.XyCoord{X: X, Y: Y})
 	}
 	// Execute
 	for _, coord := range coordList {
-		opt := nimgobus.PlotOptions{Brush: Brush, Direction: Direction, Font: Font, SizeX: SizeX, SizeY: SizeY, Over: Over}
+		opt := options.PlotOptions{Brush: Brush, Direction: Direction, Font: Font, SizeX: SizeX, SizeY: SizeY, Over: Over}
 		g.Plot(opt, Text, coord.X, coord.Y)
 	}
 	return nil
@@ -853,10 +904,10 @@ func evalLineStatement(g *game.Game, stmt *ast.LineStatement, env *object.Enviro
 			if g.ValidateColour(int(val.Value)) {
 				Brush = int(val.Value)
 			} else {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+				return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 			}
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	}
 	if stmt.Over == nil {
@@ -869,11 +920,11 @@ func evalLineStatement(g *game.Game, stmt *ast.LineStatement, env *object.Enviro
 		if val, ok := obj.(*object.Numeric); ok {
 			Over = int(val.Value)
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	}
 	// Handle coord list
-	var coordList []nimgobus.XyCoord
+	var coordList []options.XyCoord
 	var X, Y int
 	for i := 0; i < len(stmt.CoordList)-1; i += 2 {
 		obj := Eval(g, stmt.CoordList[i], env)
@@ -883,18 +934,18 @@ func evalLineStatement(g *game.Game, stmt *ast.LineStatement, env *object.Enviro
 		if val, ok := obj.(*object.Numeric); ok {
 			X = int(val.Value)
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 		obj = Eval(g, stmt.CoordList[i+1], env)
 		if val, ok := obj.(*object.Numeric); ok {
 			Y = int(val.Value)
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
-		coordList = append(coordList, nimgobus.XyCoord{X, Y})
+		coordList = append(coordList, options.XyCoord{X: X, Y: Y})
 	}
 	// Execute
-	opt := nimgobus.LineOptions{Brush: Brush, Over: Over}
+	opt := options.LineOptions{Brush: Brush, Over: Over}
 	g.Line(opt, coordList)
 	return nil
 }
@@ -913,10 +964,10 @@ func evalCircleStatement(g *game.Game, stmt *ast.CircleStatement, env *object.En
 			if g.ValidateBrush(int(val.Value)) {
 				Brush = int(val.Value)
 			} else {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+				return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 			}
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	}
 	if stmt.Over == nil {
@@ -929,11 +980,11 @@ func evalCircleStatement(g *game.Game, stmt *ast.CircleStatement, env *object.En
 		if val, ok := obj.(*object.Numeric); ok {
 			Over = int(val.Value)
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	}
 	// Handle fill style
-	var fillStyle nimgobus.FillStyle
+	var fillStyle options.FillStyle
 	if stmt.FillStyle == nil {
 		fillStyle.Style = -1
 	} else {
@@ -945,10 +996,10 @@ func evalCircleStatement(g *game.Game, stmt *ast.CircleStatement, env *object.En
 			if val.Value >= 0 && val.Value <= 2 {
 				fillStyle.Style = int(val.Value)
 			} else {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+				return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 			}
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 		if fillStyle.Style == 2 {
 			obj := Eval(g, stmt.FillHatching, env)
@@ -959,10 +1010,10 @@ func evalCircleStatement(g *game.Game, stmt *ast.CircleStatement, env *object.En
 				if val.Value >= 0 && val.Value <= 5 {
 					fillStyle.Hatching = int(val.Value)
 				} else {
-					return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+					return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 				}
 			} else {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+				return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 			}
 			if stmt.FillColour2 == nil {
 				fillStyle.Colour2 = -1
@@ -975,10 +1026,10 @@ func evalCircleStatement(g *game.Game, stmt *ast.CircleStatement, env *object.En
 					if val.Value >= 0 && val.Value <= 5 {
 						fillStyle.Colour2 = int(val.Value)
 					} else {
-						return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+						return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 					}
 				} else {
-					return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+					return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 				}
 			}
 		}
@@ -992,10 +1043,10 @@ func evalCircleStatement(g *game.Game, stmt *ast.CircleStatement, env *object.En
 	if val, ok := obj.(*object.Numeric); ok {
 		radius = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Handle coord list
-	var coordList []nimgobus.XyCoord
+	var coordList []options.XyCoord
 	var X, Y int
 	for i := 0; i < len(stmt.CoordList)-1; i += 2 {
 		obj := Eval(g, stmt.CoordList[i], env)
@@ -1005,18 +1056,18 @@ func evalCircleStatement(g *game.Game, stmt *ast.CircleStatement, env *object.En
 		if val, ok := obj.(*object.Numeric); ok {
 			X = int(val.Value)
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 		obj = Eval(g, stmt.CoordList[i+1], env)
 		if val, ok := obj.(*object.Numeric); ok {
 			Y = int(val.Value)
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
-		coordList = append(coordList, nimgobus.XyCoord{X, Y})
+		coordList = append(coordList, options.XyCoord{X: X, Y: Y})
 	}
 	// Execute
-	opt := nimgobus.CircleOptions{Brush: Brush, Over: Over, FillStyle: fillStyle}
+	opt := options.CircleOptions{Brush: Brush, Over: Over, FillStyle: fillStyle}
 	for _, coord := range coordList {
 		g.Circle(opt, radius, coord.X, coord.Y)
 	}
@@ -1037,10 +1088,10 @@ func evalPointsStatement(g *game.Game, stmt *ast.PointsStatement, env *object.En
 			if g.ValidateColour(int(val.Value)) {
 				Brush = int(val.Value)
 			} else {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+				return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 			}
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	}
 	if stmt.Style == nil {
@@ -1054,10 +1105,10 @@ func evalPointsStatement(g *game.Game, stmt *ast.PointsStatement, env *object.En
 			if g.ValidateStyle(int(val.Value)) {
 				Style = int(val.Value)
 			} else {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+				return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 			}
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	}
 	if stmt.Over == nil {
@@ -1070,11 +1121,11 @@ func evalPointsStatement(g *game.Game, stmt *ast.PointsStatement, env *object.En
 		if val, ok := obj.(*object.Numeric); ok {
 			Over = int(val.Value)
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	}
 	// Handle coord list
-	var coordList []nimgobus.XyCoord
+	var coordList []options.XyCoord
 	var X, Y int
 	for i := 0; i < len(stmt.CoordList)-1; i += 2 {
 		obj := Eval(g, stmt.CoordList[i], env)
@@ -1084,18 +1135,18 @@ func evalPointsStatement(g *game.Game, stmt *ast.PointsStatement, env *object.En
 		if val, ok := obj.(*object.Numeric); ok {
 			X = int(val.Value)
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 		obj = Eval(g, stmt.CoordList[i+1], env)
 		if val, ok := obj.(*object.Numeric); ok {
 			Y = int(val.Value)
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
-		coordList = append(coordList, nimgobus.XyCoord{X, Y})
+		coordList = append(coordList, options.XyCoord{X: X, Y: Y})
 	}
 	// Execute
-	opt := nimgobus.PointsOptions{Brush: Brush, Over: Over, Style: Style}
+	opt := options.PointsOptions{Brush: Brush, Over: Over, Style: Style}
 	g.Points(opt, coordList)
 	return nil
 }
@@ -1115,10 +1166,10 @@ func evalFloodStatement(g *game.Game, stmt *ast.FloodStatement, env *object.Envi
 			if g.ValidateBrush(int(val.Value)) {
 				Brush = int(val.Value)
 			} else {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+				return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 			}
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	}
 	if stmt.UseEdgeColour == nil {
@@ -1131,7 +1182,7 @@ func evalFloodStatement(g *game.Game, stmt *ast.FloodStatement, env *object.Envi
 		if val, ok := obj.(*object.Numeric); ok {
 			UseEdgeColour = isTruthy(val)
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	}
 	if stmt.EdgeColour == nil {
@@ -1145,14 +1196,14 @@ func evalFloodStatement(g *game.Game, stmt *ast.FloodStatement, env *object.Envi
 			if g.ValidateColour(int(val.Value)) {
 				EdgeColour = int(val.Value)
 			} else {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+				return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 			}
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	}
 	// Handle fill style
-	var fillStyle nimgobus.FillStyle
+	var fillStyle options.FillStyle
 	if stmt.FillStyle == nil {
 		fillStyle.Style = -1
 	} else {
@@ -1164,10 +1215,10 @@ func evalFloodStatement(g *game.Game, stmt *ast.FloodStatement, env *object.Envi
 			if val.Value >= 0 && val.Value <= 2 {
 				fillStyle.Style = int(val.Value)
 			} else {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+				return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 			}
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 		if fillStyle.Style == 2 {
 			obj := Eval(g, stmt.FillHatching, env)
@@ -1178,10 +1229,10 @@ func evalFloodStatement(g *game.Game, stmt *ast.FloodStatement, env *object.Envi
 				if val.Value >= 0 && val.Value <= 5 {
 					fillStyle.Hatching = int(val.Value)
 				} else {
-					return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+					return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 				}
 			} else {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+				return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 			}
 			if stmt.FillColour2 == nil {
 				fillStyle.Colour2 = -1
@@ -1194,16 +1245,16 @@ func evalFloodStatement(g *game.Game, stmt *ast.FloodStatement, env *object.Envi
 					if val.Value >= 0 && val.Value <= 5 {
 						fillStyle.Colour2 = int(val.Value)
 					} else {
-						return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+						return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 					}
 				} else {
-					return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+					return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 				}
 			}
 		}
 	}
 	// Handle coord list
-	var coordList []nimgobus.XyCoord
+	var coordList []options.XyCoord
 	var X, Y int
 	for i := 0; i < len(stmt.CoordList)-1; i += 2 {
 		obj := Eval(g, stmt.CoordList[i], env)
@@ -1213,18 +1264,18 @@ func evalFloodStatement(g *game.Game, stmt *ast.FloodStatement, env *object.Envi
 		if val, ok := obj.(*object.Numeric); ok {
 			X = int(val.Value)
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 		obj = Eval(g, stmt.CoordList[i+1], env)
 		if val, ok := obj.(*object.Numeric); ok {
 			Y = int(val.Value)
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
-		coordList = append(coordList, nimgobus.XyCoord{X, Y})
+		coordList = append(coordList, options.XyCoord{X: X, Y: Y})
 	}
 	// Execute
-	opt := nimgobus.FloodOptions{Brush: Brush, UseEdgeColour: UseEdgeColour, EdgeColour: EdgeColour, FillStyle: fillStyle}
+	opt := options.FloodOptions{Brush: Brush, UseEdgeColour: UseEdgeColour, EdgeColour: EdgeColour, FillStyle: fillStyle}
 	for _, coord := range coordList {
 		g.Flood(opt, coord)
 	}
@@ -1245,10 +1296,10 @@ func evalAreaStatement(g *game.Game, stmt *ast.AreaStatement, env *object.Enviro
 			if g.ValidateBrush(int(val.Value)) {
 				Brush = int(val.Value)
 			} else {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+				return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 			}
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	}
 	if stmt.Over == nil {
@@ -1261,11 +1312,11 @@ func evalAreaStatement(g *game.Game, stmt *ast.AreaStatement, env *object.Enviro
 		if val, ok := obj.(*object.Numeric); ok {
 			Over = int(val.Value)
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	}
 	// Handle fill style
-	var fillStyle nimgobus.FillStyle
+	var fillStyle options.FillStyle
 	if stmt.FillStyle == nil {
 		fillStyle.Style = -1
 	} else {
@@ -1277,10 +1328,10 @@ func evalAreaStatement(g *game.Game, stmt *ast.AreaStatement, env *object.Enviro
 			if val.Value >= 0 && val.Value <= 2 {
 				fillStyle.Style = int(val.Value)
 			} else {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+				return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 			}
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 		if fillStyle.Style == 2 {
 			obj := Eval(g, stmt.FillHatching, env)
@@ -1291,10 +1342,10 @@ func evalAreaStatement(g *game.Game, stmt *ast.AreaStatement, env *object.Enviro
 				if val.Value >= 0 && val.Value <= 5 {
 					fillStyle.Hatching = int(val.Value)
 				} else {
-					return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+					return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 				}
 			} else {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+				return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 			}
 			if stmt.FillColour2 == nil {
 				fillStyle.Colour2 = -1
@@ -1307,16 +1358,16 @@ func evalAreaStatement(g *game.Game, stmt *ast.AreaStatement, env *object.Enviro
 					if val.Value >= 0 && val.Value <= 5 {
 						fillStyle.Colour2 = int(val.Value)
 					} else {
-						return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+						return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 					}
 				} else {
-					return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+					return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 				}
 			}
 		}
 	}
 	// Handle coord list
-	var coordList []nimgobus.XyCoord
+	var coordList []options.XyCoord
 	var X, Y int
 	for i := 0; i < len(stmt.CoordList)-1; i += 2 {
 		obj := Eval(g, stmt.CoordList[i], env)
@@ -1326,25 +1377,25 @@ func evalAreaStatement(g *game.Game, stmt *ast.AreaStatement, env *object.Enviro
 		if val, ok := obj.(*object.Numeric); ok {
 			X = int(val.Value)
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 		obj = Eval(g, stmt.CoordList[i+1], env)
 		if val, ok := obj.(*object.Numeric); ok {
 			Y = int(val.Value)
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
-		coordList = append(coordList, nimgobus.XyCoord{X, Y})
+		coordList = append(coordList, options.XyCoord{X: X, Y: Y})
 	}
 	// Execute
-	opt := nimgobus.AreaOptions{Brush: Brush, Over: Over, FillStyle: fillStyle}
+	opt := options.AreaOptions{Brush: Brush, Over: Over, FillStyle: fillStyle}
 	g.Area(opt, coordList)
 	return nil
 }
 
 func evalSetFillStyleStatement(g *game.Game, stmt *ast.SetFillStyleStatement, env *object.Environment) object.Object {
 	// Handle fill style
-	var fillStyle nimgobus.FillStyle
+	var fillStyle options.FillStyle
 	obj := Eval(g, stmt.FillStyle, env)
 	if isError(obj) {
 		return obj
@@ -1353,10 +1404,10 @@ func evalSetFillStyleStatement(g *game.Game, stmt *ast.SetFillStyleStatement, en
 		if val.Value >= 0 && val.Value <= 2 {
 			fillStyle.Style = int(val.Value)
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	if fillStyle.Style == 2 {
 		obj := Eval(g, stmt.FillHatching, env)
@@ -1367,10 +1418,10 @@ func evalSetFillStyleStatement(g *game.Game, stmt *ast.SetFillStyleStatement, en
 			if val.Value >= 0 && val.Value <= 5 {
 				fillStyle.Hatching = int(val.Value)
 			} else {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+				return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 			}
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 		if stmt.FillColour2 == nil {
 			fillStyle.Colour2 = -1
@@ -1383,10 +1434,10 @@ func evalSetFillStyleStatement(g *game.Game, stmt *ast.SetFillStyleStatement, en
 				if val.Value >= 0 && val.Value <= 5 {
 					fillStyle.Colour2 = int(val.Value)
 				} else {
-					return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+					return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 				}
 			} else {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+				return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 			}
 		}
 	}
@@ -1408,11 +1459,11 @@ func evalNoteStatement(g *game.Game, stmt *ast.NoteStatement, env *object.Enviro
 	}
 	if val, ok := obj.(*object.Numeric); ok {
 		if val.Value < 1.0 || val.Value > 388.0 {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 		pitch1 = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Get pitch2
 	if stmt.Pitch2 != nil {
@@ -1423,11 +1474,11 @@ func evalNoteStatement(g *game.Game, stmt *ast.NoteStatement, env *object.Enviro
 		if val, ok := obj.(*object.Numeric); ok {
 			if val.Value < 1.0 || val.Value > 388.0 {
 
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+				return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 			}
 			pitch2 = int(val.Value)
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	}
 	// Get duration
@@ -1438,11 +1489,11 @@ func evalNoteStatement(g *game.Game, stmt *ast.NoteStatement, env *object.Enviro
 		}
 		if val, ok := obj.(*object.Numeric); ok {
 			if val.Value < 0.0 {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+				return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 			}
 			duration = int(val.Value)
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	} else {
 		duration = -1
@@ -1455,11 +1506,11 @@ func evalNoteStatement(g *game.Game, stmt *ast.NoteStatement, env *object.Enviro
 		}
 		if val, ok := obj.(*object.Numeric); ok {
 			if val.Value < 0.0 || val.Value > 15.0 {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+				return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 			}
 			volume = int(val.Value)
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	} else {
 		volume = -1
@@ -1472,11 +1523,11 @@ func evalNoteStatement(g *game.Game, stmt *ast.NoteStatement, env *object.Enviro
 		}
 		if val, ok := obj.(*object.Numeric); ok {
 			if val.Value < 1.0 || val.Value > 10.0 {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+				return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 			}
 			volume = int(val.Value)
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	} else {
 		envelope = -1
@@ -1490,6 +1541,28 @@ func evalNoteStatement(g *game.Game, stmt *ast.NoteStatement, env *object.Enviro
 	return nil
 }
 
+// askYesNo prints a question and waits for Y or N to be pressed.  It returns true for Y.
+func askYesNo(g *game.Game, question string) bool {
+	for {
+		g.Put(13)
+		g.Print(question + " (Y/N): ")
+		key := g.Get()
+		for key < 0 {
+			time.Sleep(100 * time.Millisecond)
+			key = g.Get()
+		}
+		g.Put(key)
+		switch key {
+		case 'Y', 'y':
+			g.Put(13)
+			return true
+		case 'N', 'n':
+			g.Put(13)
+			return false
+		}
+	}
+}
+
 func evalSaveStatement(g *game.Game, stmt *ast.SaveStatement, env *object.Environment) object.Object {
 	obj := Eval(g, stmt.Value, env)
 	if isError(obj) {
@@ -1499,11 +1572,11 @@ func evalSaveStatement(g *game.Game, stmt *ast.SaveStatement, env *object.Enviro
 	if stringVal, ok := obj.(*object.String); ok {
 		filename = stringVal.Value
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.StringExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.StringExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.StringExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Don't allow * or ?
 	if strings.Contains(filename, "*") || strings.Contains(filename, "?") {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.ExactFilenameIsNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.ExactFilenameIsNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.ExactFilenameIsNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Add .BAS if necessary
 	if !strings.HasSuffix(strings.ToUpper(filename), ".BAS") {
@@ -1513,57 +1586,21 @@ func evalSaveStatement(g *game.Game, stmt *ast.SaveStatement, env *object.Enviro
 	fullpath := filepath.Join(g.WorkspacePath, filename)
 	// Don't allow directories
 	if isDirectory(fullpath) {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.FilenameIsADirectory), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.FilenameIsADirectory, Message: syntaxerror.ErrorMessage(syntaxerror.FilenameIsADirectory), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Warn if file already exists
 	_, err := ioutil.ReadFile(fullpath)
 	if !errors.Is(err, os.ErrNotExist) {
 		// Warn user and ask to abort
 		g.Print("Named file already exists")
-		var abort bool
-		var gotAnswer bool
-		for !gotAnswer {
-			g.Put(13)
-			g.Print("Abort command? (Y/N): ")
-			waiting := true
-			for waiting {
-				key := g.Get()
-				if key < 0 {
-					time.Sleep(100 * time.Millisecond)
-					continue
-				}
-				g.Put(key)
-				switch key {
-				case 89: // Y
-					abort = true
-					gotAnswer = true
-					waiting = false
-				case 121: // y
-					abort = true
-					gotAnswer = true
-					waiting = false
-				case 78: // N
-					abort = false
-					gotAnswer = true
-					waiting = false
-				case 110: // n
-					abort = false
-					gotAnswer = true
-					waiting = false
-				default:
-					waiting = false
-				}
-			}
-		}
-		g.Put(13)
-		if abort {
+		if askYesNo(g, "Abort command?") {
 			return nil
 		}
 	}
 	// Save the program
 	file, err := os.Create(fullpath)
 	if err != nil {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.FileOperationFailure)}
+		return &object.Error{Code: syntaxerror.FileOperationFailure, Message: syntaxerror.ErrorMessage(syntaxerror.FileOperationFailure)}
 	}
 	defer file.Close()
 	for _, lineString := range env.Program.List(0, 0, false) {
@@ -1591,11 +1628,11 @@ func evalLoadStatement(g *game.Game, stmt *ast.LoadStatement, env *object.Enviro
 	if stringVal, ok := obj.(*object.String); ok {
 		filename = stringVal.Value
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.StringExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.StringExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.StringExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Don't allow * or ?
 	if strings.Contains(filename, "*") || strings.Contains(filename, "?") {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.ExactFilenameIsNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.ExactFilenameIsNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.ExactFilenameIsNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Add .BAS if necessary
 	if !strings.HasSuffix(strings.ToUpper(filename), ".BAS") {
@@ -1605,25 +1642,28 @@ func evalLoadStatement(g *game.Game, stmt *ast.LoadStatement, env *object.Enviro
 	fullpath := filepath.Join(g.WorkspacePath, filename)
 	// Don't allow directories
 	if isDirectory(fullpath) {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.FilenameIsADirectory), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.FilenameIsADirectory, Message: syntaxerror.ErrorMessage(syntaxerror.FilenameIsADirectory), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Load the program
 	fileBytes, err := ioutil.ReadFile(fullpath)
 	// Handle file doesn't exist
 	if errors.Is(err, os.ErrNotExist) {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.UnableToOpenNamedFile), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.UnableToOpenNamedFile, Message: syntaxerror.ErrorMessage(syntaxerror.UnableToOpenNamedFile), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Handle any other errors
 	if err != nil {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.FileOperationFailure), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.FileOperationFailure, Message: syntaxerror.ErrorMessage(syntaxerror.FileOperationFailure), ErrorTokenIndex: stmt.Token.Index + 1}
+	}
+	// Committed to load the program so erase any existing program in memory, unless merging
+	merge := stmt.Token.TokenType == token.MERGE || stmt.Token.TokenType == token.MERGEGO
+	if !merge {
+		env.Program.New()
 	}
-	// Committed to load the program so erase any existing program in memory
-	env.Program.New()
 	// To read into the program space we just pretend the code is being manually keyed it (I think that's how it worked originally)
 	sliceData := strings.Split(string(fileBytes), "\n")
 	l := &lexer.Lexer{}
 	for _, rawLine := range sliceData {
-		if g.BreakInterruptDetected {
+		if g.AskBreak() {
 			break
 		}
 		l.Scan(rawLine)
@@ -1671,6 +1711,29 @@ func evalLoadStatement(g *game.Game, stmt *ast.LoadStatement, env *object.Enviro
 			}
 		}
 	}
+	if stmt.Token.TokenType == token.LOADGO || stmt.Token.TokenType == token.MERGEGO {
+		return chainProgram(g, env)
+	}
+	return obj
+}
+
+// chainProgram runs a program that has just been loaded by LOADGO or MERGEGO.  GLOBAL variables
+// and open file channels are kept so that one program can chain to another and pass them on.
+func chainProgram(g *game.Game, env *object.Environment) object.Object {
+	env.ErrorSignal = false
+	if !prerun(g, env) {
+		env.ErrorSignal = true
+		return nil
+	}
+	env.Prerun = false
+	env.Program.Start()
+	env.DeleteLocals()
+	env.JumpStack.New()
+	env.ClearErrorTrap()
+	env.ClearBreakTrap()
+	obj := runProgram(g, env)
+	// If LOADGO or MERGEGO was in a program it has been replaced so it mustn't carry on
+	env.EndProgram()
 	return obj
 }
 
@@ -1680,7 +1743,7 @@ func evalCloseStatement(g *game.Game, stmt *ast.CloseStatement, env *object.Envi
 		for _, fileObj := range g.FileChannels {
 			fileObj.File.Close()
 		}
-		g.FileChannels = make(map[int]*nimgobus.FileObj)
+		g.FileChannels = make(map[int]*game.FileObj)
 		return nil
 	}
 	// Otherwise close the specified file
@@ -1695,7 +1758,7 @@ func evalCloseStatement(g *game.Game, stmt *ast.CloseStatement, env *object.Envi
 			delete(g.FileChannels, channel)
 		}
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	return nil
 }
@@ -1710,11 +1773,11 @@ func evalCreateStatement(g *game.Game, stmt *ast.CreateStatement, env *object.En
 	if stringVal, ok := obj.(*object.String); ok {
 		filename = stringVal.Value
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.StringExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.StringExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.StringExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Don't allow * or ?
 	if strings.Contains(filename, "*") || strings.Contains(filename, "?") {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.ExactFilenameIsNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.ExactFilenameIsNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.ExactFilenameIsNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Add .BAS if necessary
 	if !strings.HasSuffix(strings.ToUpper(filename), ".BAS") {
@@ -1724,7 +1787,7 @@ func evalCreateStatement(g *game.Game, stmt *ast.CreateStatement, env *object.En
 	fullpath := filepath.Join(g.WorkspacePath, filename)
 	// Don't allow directories
 	if isDirectory(fullpath) {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.FilenameIsADirectory), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.FilenameIsADirectory, Message: syntaxerror.ErrorMessage(syntaxerror.FilenameIsADirectory), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Evaluate Channel number (must be numeric)
 	obj = Eval(g, stmt.Channel, env)
@@ -1735,24 +1798,24 @@ func evalCreateStatement(g *game.Game, stmt *ast.CreateStatement, env *object.En
 	if intVal, ok := obj.(*object.Numeric); ok {
 		channel = int(intVal.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// To keep things simple(r) we will allow any file channel between 0 and 127.  This way if someone
 	// was using a printer in 1987 the program will still run and the printer output will go to a file
 	// instead.
 	if channel < 0 || channel > 127 {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.WrongChannelNumberUsed), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.WrongChannelNumberUsed, Message: syntaxerror.ErrorMessage(syntaxerror.WrongChannelNumberUsed), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Is channel already in use?
 	if _, ok := g.FileChannels[channel]; ok {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NamedChannelAlreadyInUse), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NamedChannelAlreadyInUse, Message: syntaxerror.ErrorMessage(syntaxerror.NamedChannelAlreadyInUse), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Create a file object and add it to the file channels
 	file, err := os.Create(fullpath)
 	if err != nil {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.FileOperationFailure)}
+		return &object.Error{Code: syntaxerror.FileOperationFailure, Message: syntaxerror.ErrorMessage(syntaxerror.FileOperationFailure)}
 	}
-	g.FileChannels[channel] = &nimgobus.FileObj{File: file, Writing: true}
+	g.FileChannels[channel] = &game.FileObj{File: file, Writing: true}
 	return nil
 }
 
@@ -1766,11 +1829,11 @@ func evalOpenStatement(g *game.Game, stmt *ast.OpenStatement, env *object.Enviro
 	if stringVal, ok := obj.(*object.String); ok {
 		filename = stringVal.Value
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.StringExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.StringExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.StringExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Don't allow * or ?
 	if strings.Contains(filename, "*") || strings.Contains(filename, "?") {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.ExactFilenameIsNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.ExactFilenameIsNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.ExactFilenameIsNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Add .BAS if necessary
 	if !strings.HasSuffix(strings.ToUpper(filename), ".BAS") {
@@ -1780,12 +1843,12 @@ func evalOpenStatement(g *game.Game, stmt *ast.OpenStatement, env *object.Enviro
 	fullpath := filepath.Join(g.WorkspacePath, filename)
 	// Don't allow directories
 	if isDirectory(fullpath) {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.FilenameIsADirectory), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.FilenameIsADirectory, Message: syntaxerror.ErrorMessage(syntaxerror.FilenameIsADirectory), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Check if file exists
 	_, err := os.Stat(fullpath)
 	if err != nil {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.UnableToOpenNamedFile), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.UnableToOpenNamedFile, Message: syntaxerror.ErrorMessage(syntaxerror.UnableToOpenNamedFile), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Evaluate Channel number (must be numeric)
 	obj = Eval(g, stmt.Channel, env)
@@ -1796,35 +1859,35 @@ func evalOpenStatement(g *game.Game, stmt *ast.OpenStatement, env *object.Enviro
 	if intVal, ok := obj.(*object.Numeric); ok {
 		channel = int(intVal.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	if channel < 11 || channel > 127 {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.WrongChannelNumberUsed), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.WrongChannelNumberUsed, Message: syntaxerror.ErrorMessage(syntaxerror.WrongChannelNumberUsed), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Is channel already in use?
 	if _, ok := g.FileChannels[channel]; ok {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NamedChannelAlreadyInUse), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NamedChannelAlreadyInUse, Message: syntaxerror.ErrorMessage(syntaxerror.NamedChannelAlreadyInUse), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Open a file object and add it to the file channels
 	file, err := os.Open(fullpath)
 	if err != nil {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.FileOperationFailure)}
+		return &object.Error{Code: syntaxerror.FileOperationFailure, Message: syntaxerror.ErrorMessage(syntaxerror.FileOperationFailure)}
 	}
-	g.FileChannels[channel] = &nimgobus.FileObj{File: file, Writing: false}
+	g.FileChannels[channel] = &game.FileObj{File: file, Writing: false}
 	return nil
 }
 
 func writeStringToFile(g *game.Game, channel int, writeString string) object.Object {
 	if fileObj, ok := g.FileChannels[channel]; ok {
 		if !fileObj.Writing {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.ChannelNotOpenForOutput)}
+			return &object.Error{Code: syntaxerror.ChannelNotOpenForOutput, Message: syntaxerror.ErrorMessage(syntaxerror.ChannelNotOpenForOutput)}
 		}
 		_, err := g.FileChannels[channel].File.WriteString(writeString)
 		if err != nil {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.FileOperationFailure)}
+			return &object.Error{Code: syntaxerror.FileOperationFailure, Message: syntaxerror.ErrorMessage(syntaxerror.FileOperationFailure)}
 		}
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.ChannelNotOpenForOutput)}
+		return &object.Error{Code: syntaxerror.ChannelNotOpenForOutput, Message: syntaxerror.ErrorMessage(syntaxerror.ChannelNotOpenForOutput)}
 	}
 	return nil
 }
@@ -1832,17 +1895,17 @@ func writeStringToFile(g *game.Game, channel int, writeString string) object.Obj
 func readLineFromFile(g *game.Game, channel int) (lineString string, obj object.Object) {
 	if fileObj, ok := g.FileChannels[channel]; ok {
 		if fileObj.Writing {
-			return "", &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.ChannelNotOpenForInput)}
+			return "", &object.Error{Code: syntaxerror.ChannelNotOpenForInput, Message: syntaxerror.ErrorMessage(syntaxerror.ChannelNotOpenForInput)}
 		}
 		for {
 			b := make([]byte, 1)
 			_, err := g.FileChannels[channel].File.Read(b)
 			// break if EOF or error
 			if err == io.EOF {
-				return "", &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.ReadingPastEndOfFile)}
+				return "", &object.Error{Code: syntaxerror.ReadingPastEndOfFile, Message: syntaxerror.ErrorMessage(syntaxerror.ReadingPastEndOfFile)}
 			}
 			if err != nil {
-				return "", &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.FileOperationFailure)}
+				return "", &object.Error{Code: syntaxerror.FileOperationFailure, Message: syntaxerror.ErrorMessage(syntaxerror.FileOperationFailure)}
 			}
 			// break if we have a newline
 			if b[0] == '\n' {
@@ -1851,7 +1914,7 @@ func readLineFromFile(g *game.Game, channel int) (lineString string, obj object.
 			lineString = lineString + string(b)
 		}
 	} else {
-		return "", &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.ChannelNotOpenForOutput)}
+		return "", &object.Error{Code: syntaxerror.ChannelNotOpenForOutput, Message: syntaxerror.ErrorMessage(syntaxerror.ChannelNotOpenForOutput)}
 	}
 	return lineString, nil
 }
@@ -1866,10 +1929,10 @@ func evalFetchStatement(g *game.Game, stmt *ast.FetchStatement, env *object.Envi
 	if val, ok := obj.(*object.Numeric); ok {
 		block = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	if block < 0 || block > 99 {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Path
 	var path string
@@ -1877,32 +1940,32 @@ func evalFetchStatement(g *game.Game, stmt *ast.FetchStatement, env *object.Envi
 	if val, ok := obj.(*object.String); ok {
 		path = val.Value
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.StringExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.StringExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.StringExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Don't allow * or ?
 	if strings.Contains(path, "*") || strings.Contains(path, "?") {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.ExactFilenameIsNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.ExactFilenameIsNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.ExactFilenameIsNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Preprend workspace folder
 	fullpath := filepath.Join(g.WorkspacePath, path)
 	// Don't allow directories
 	if isDirectory(fullpath) {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.FilenameIsADirectory), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.FilenameIsADirectory, Message: syntaxerror.ErrorMessage(syntaxerror.FilenameIsADirectory), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Infer file format from extention and fail if not recognised
 	if !(strings.HasSuffix(strings.ToUpper(path), ".PNG") || strings.HasSuffix(strings.ToUpper(path), ".JPG") || strings.HasSuffix(strings.ToUpper(path), ".JPEG")) {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.UnsupportedImageFileFormat), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.UnsupportedImageFileFormat, Message: syntaxerror.ErrorMessage(syntaxerror.UnsupportedImageFileFormat), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Handle file doesn't exist
 	_, err := ioutil.ReadFile(fullpath)
 	if errors.Is(err, os.ErrNotExist) {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.UnableToOpenNamedFile), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.UnableToOpenNamedFile, Message: syntaxerror.ErrorMessage(syntaxerror.UnableToOpenNamedFile), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Execute
 	ok := g.Fetch(block, fullpath)
 	// Return any other errors
 	if !ok {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.CouldNotDecodeImageFile), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.CouldNotDecodeImageFile, Message: syntaxerror.ErrorMessage(syntaxerror.CouldNotDecodeImageFile), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	return obj
 }
@@ -1917,10 +1980,10 @@ func evalWriteblockStatement(g *game.Game, stmt *ast.WriteblockStatement, env *o
 	if val, ok := obj.(*object.Numeric); ok {
 		block = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	if block < 0 || block > 99 {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// X
 	var x int
@@ -1928,7 +1991,7 @@ func evalWriteblockStatement(g *game.Game, stmt *ast.WriteblockStatement, env *o
 	if val, ok := obj.(*object.Numeric); ok {
 		x = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Y
 	var y int
@@ -1936,7 +1999,7 @@ func evalWriteblockStatement(g *game.Game, stmt *ast.WriteblockStatement, env *o
 	if val, ok := obj.(*object.Numeric); ok {
 		y = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Over
 	var over bool
@@ -1944,7 +2007,7 @@ func evalWriteblockStatement(g *game.Game, stmt *ast.WriteblockStatement, env *o
 	if val, ok := obj.(*object.Numeric); ok {
 		over = isTruthy(val)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Execute
 	g.Writeblock(block, x, y, over)
@@ -1961,10 +2024,10 @@ func evalReadblockStatement(g *game.Game, stmt *ast.ReadblockStatement, env *obj
 	if val, ok := obj.(*object.Numeric); ok {
 		block = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	if block < 0 || block > 99 {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// X1
 	var x1 int
@@ -1972,7 +2035,7 @@ func evalReadblockStatement(g *game.Game, stmt *ast.ReadblockStatement, env *obj
 	if val, ok := obj.(*object.Numeric); ok {
 		x1 = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Y1
 	var y1 int
@@ -1980,7 +2043,7 @@ func evalReadblockStatement(g *game.Game, stmt *ast.ReadblockStatement, env *obj
 	if val, ok := obj.(*object.Numeric); ok {
 		y1 = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// X2
 	var x2 int
@@ -1988,7 +2051,7 @@ func evalReadblockStatement(g *game.Game, stmt *ast.ReadblockStatement, env *obj
 	if val, ok := obj.(*object.Numeric); ok {
 		x2 = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Y2
 	var y2 int
@@ -1996,7 +2059,7 @@ func evalReadblockStatement(g *game.Game, stmt *ast.ReadblockStatement, env *obj
 	if val, ok := obj.(*object.Numeric); ok {
 		y2 = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Execute
 	g.Readblock(block, x1, y1, x2, y2)
@@ -2010,7 +2073,7 @@ func evalCopyblockStatement(g *game.Game, stmt *ast.CopyblockStatement, env *obj
 	if val, ok := obj.(*object.Numeric); ok {
 		x1 = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Y1
 	var y1 int
@@ -2018,7 +2081,7 @@ func evalCopyblockStatement(g *game.Game, stmt *ast.CopyblockStatement, env *obj
 	if val, ok := obj.(*object.Numeric); ok {
 		y1 = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// X2
 	var x2 int
@@ -2026,7 +2089,7 @@ func evalCopyblockStatement(g *game.Game, stmt *ast.CopyblockStatement, env *obj
 	if val, ok := obj.(*object.Numeric); ok {
 		x2 = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Y2
 	var y2 int
@@ -2034,7 +2097,7 @@ func evalCopyblockStatement(g *game.Game, stmt *ast.CopyblockStatement, env *obj
 	if val, ok := obj.(*object.Numeric); ok {
 		y2 = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Dx
 	var dx int
@@ -2042,7 +2105,7 @@ func evalCopyblockStatement(g *game.Game, stmt *ast.CopyblockStatement, env *obj
 	if val, ok := obj.(*object.Numeric); ok {
 		dx = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Dy
 	var dy int
@@ -2050,7 +2113,7 @@ func evalCopyblockStatement(g *game.Game, stmt *ast.CopyblockStatement, env *obj
 	if val, ok := obj.(*object.Numeric); ok {
 		dy = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Over
 	var over bool
@@ -2058,7 +2121,7 @@ func evalCopyblockStatement(g *game.Game, stmt *ast.CopyblockStatement, env *obj
 	if val, ok := obj.(*object.Numeric); ok {
 		over = isTruthy(val)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Execute
 	g.Readblock(0, x1, y1, x2, y2)
@@ -2077,10 +2140,10 @@ func evalSquashStatement(g *game.Game, stmt *ast.SquashStatement, env *object.En
 	if val, ok := obj.(*object.Numeric); ok {
 		block = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	if block < 0 || block > 99 {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// X
 	var x int
@@ -2088,7 +2151,7 @@ func evalSquashStatement(g *game.Game, stmt *ast.SquashStatement, env *object.En
 	if val, ok := obj.(*object.Numeric); ok {
 		x = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Y
 	var y int
@@ -2096,7 +2159,7 @@ func evalSquashStatement(g *game.Game, stmt *ast.SquashStatement, env *object.En
 	if val, ok := obj.(*object.Numeric); ok {
 		y = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Over
 	var over bool
@@ -2104,7 +2167,7 @@ func evalSquashStatement(g *game.Game, stmt *ast.SquashStatement, env *object.En
 	if val, ok := obj.(*object.Numeric); ok {
 		over = isTruthy(val)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Execute
 	g.Squash(block, x, y, over)
@@ -2121,10 +2184,10 @@ func evalDelblockStatement(g *game.Game, stmt *ast.DelblockStatement, env *objec
 	if val, ok := obj.(*object.Numeric); ok {
 		block = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	if block < 0 || block > 99 {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Execute
 	g.Delblock(block)
@@ -2141,10 +2204,10 @@ func evalKeepStatement(g *game.Game, stmt *ast.KeepStatement, env *object.Enviro
 	if val, ok := obj.(*object.Numeric); ok {
 		block = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	if block < 0 || block > 99 {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Path
 	var path string
@@ -2155,21 +2218,21 @@ func evalKeepStatement(g *game.Game, stmt *ast.KeepStatement, env *object.Enviro
 	if val, ok := obj.(*object.String); ok {
 		path = val.Value
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.StringExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.StringExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.StringExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Don't allow * or ?
 	if strings.Contains(path, "*") || strings.Contains(path, "?") {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.ExactFilenameIsNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.ExactFilenameIsNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.ExactFilenameIsNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Preprend workspace folder
 	fullpath := filepath.Join(g.WorkspacePath, path)
 	// Don't allow directories
 	if isDirectory(fullpath) {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.FilenameIsADirectory), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.FilenameIsADirectory, Message: syntaxerror.ErrorMessage(syntaxerror.FilenameIsADirectory), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Infer file format from extention and fail if not recognised
 	if !(strings.HasSuffix(strings.ToUpper(path), ".PNG") || strings.HasSuffix(strings.ToUpper(path), ".JPG") || strings.HasSuffix(strings.ToUpper(path), ".JPEG")) {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.UnsupportedImageFileFormat), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.UnsupportedImageFileFormat, Message: syntaxerror.ErrorMessage(syntaxerror.UnsupportedImageFileFormat), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	var format string
 	if strings.HasSuffix(strings.ToUpper(path), ".PNG") {
@@ -2181,7 +2244,7 @@ func evalKeepStatement(g *game.Game, stmt *ast.KeepStatement, env *object.Enviro
 	// Execute
 	err := g.Keep(block, format, fullpath)
 	if err != nil {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.FileOperationFailure), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.FileOperationFailure, Message: syntaxerror.ErrorMessage(syntaxerror.FileOperationFailure), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	return nil
 }
@@ -2195,7 +2258,130 @@ func evalSetModeStatement(g *game.Game, stmt *ast.SetModeStatement, env *object.
 		g.SetMode(int(val.Value))
 		return obj
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+	}
+}
+
+// The formats used by DATE$ and TIME$, and accepted by SET DATE and SET TIME
+const (
+	dateFormat      = "02/01/06"
+	longDateFormat  = "02/01/2006"
+	timeFormat      = "15:04:05"
+	shortTimeFormat = "15:04"
+)
+
+func evalSetDateStatement(g *game.Game, stmt *ast.SetDateStatement, env *object.Environment) object.Object {
+	obj := Eval(g, stmt.Value, env)
+	if isError(obj) {
+		return obj
+	}
+	val, ok := obj.(*object.String)
+	if !ok {
+		return &object.Error{Code: syntaxerror.StringExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.StringExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+	}
+	date, err := time.Parse(dateFormat, val.Value)
+	if err != nil {
+		if date, err = time.Parse(longDateFormat, val.Value); err != nil {
+			return &object.Error{Code: syntaxerror.InvalidDateOrTime, Message: syntaxerror.ErrorMessage(syntaxerror.InvalidDateOrTime), ErrorTokenIndex: stmt.Token.Index + 1}
+		}
+	}
+	// Keep the time of day
+	now := env.Now()
+	env.SetNow(time.Date(date.Year(), date.Month(), date.Day(), now.Hour(), now.Minute(), now.Second(), now.Nanosecond(), now.Location()))
+	return nil
+}
+
+func evalSetTimeStatement(g *game.Game, stmt *ast.SetTimeStatement, env *object.Environment) object.Object {
+	obj := Eval(g, stmt.Value, env)
+	if isError(obj) {
+		return obj
+	}
+	val, ok := obj.(*object.String)
+	if !ok {
+		return &object.Error{Code: syntaxerror.StringExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.StringExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+	}
+	t, err := time.Parse(timeFormat, val.Value)
+	if err != nil {
+		if t, err = time.Parse(shortTimeFormat, val.Value); err != nil {
+			return &object.Error{Code: syntaxerror.InvalidDateOrTime, Message: syntaxerror.ErrorMessage(syntaxerror.InvalidDateOrTime), ErrorTokenIndex: stmt.Token.Index + 1}
+		}
+	}
+	// Keep the date
+	now := env.Now()
+	env.SetNow(time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), t.Second(), 0, now.Location()))
+	return nil
+}
+
+func evalSetTraceStatement(g *game.Game, stmt *ast.SetTraceStatement, env *object.Environment) object.Object {
+	channel := 0
+	// Evaluate and handle Channel if set
+	if stmt.Channel != nil {
+		obj := Eval(g, stmt.Channel, env)
+		if isError(obj) {
+			return obj
+		}
+		if val, ok := obj.(*object.Numeric); ok {
+			if val.Value < 11 || val.Value > 127 {
+				return &object.Error{Code: syntaxerror.WrongChannelNumberUsed, Message: syntaxerror.ErrorMessage(syntaxerror.WrongChannelNumberUsed), ErrorTokenIndex: stmt.Token.Index + 2}
+			}
+			channel = int(val.Value)
+		} else {
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 2}
+		}
+		if fileObj, ok := g.FileChannels[channel]; !ok || !fileObj.Writing {
+			return &object.Error{Code: syntaxerror.ChannelNotOpenForOutput, Message: syntaxerror.ErrorMessage(syntaxerror.ChannelNotOpenForOutput), ErrorTokenIndex: stmt.Token.Index + 2}
+		}
+	}
+	env.SetTrace(stmt.On, stmt.Variables, channel)
+	return nil
+}
+
+// traceLine adds the number of the line about to be executed to the trace, if tracing is on
+func traceLine(g *game.Game, env *object.Environment) {
+	if on, _, _ := env.Trace(); on {
+		writeTrace(g, env, fmt.Sprintf("[%d]", env.Program.GetLineNumber()))
+	}
+}
+
+// traceVariable adds an assignment to the trace, if variables are being traced
+func traceVariable(g *game.Game, env *object.Environment, name string, val object.Object) {
+	if _, variables, _ := env.Trace(); !variables || isError(val) {
+		return
+	}
+	switch val := val.(type) {
+	case *object.Numeric:
+		writeTrace(g, env, fmt.Sprintf("[%s := %g]", name, val.Value))
+	case *object.Integer:
+		writeTrace(g, env, fmt.Sprintf("[%s := %d]", name, val.Value))
+	case *object.String:
+		writeTrace(g, env, fmt.Sprintf("[%s := \"%s\"]", name, val.Value))
+	}
+}
+
+// traceArrayElement adds an assignment to an array element to the trace
+func traceArrayElement(g *game.Game, env *object.Environment, name string, subscripts []int) {
+	if _, variables, _ := env.Trace(); !variables {
+		return
+	}
+	val, _ := env.GetArray(name, subscripts)
+	s := make([]string, len(subscripts))
+	for i, subscript := range subscripts {
+		s[i] = strconv.Itoa(subscript)
+	}
+	traceVariable(g, env, fmt.Sprintf("%s(%s)", name, strings.Join(s, ", ")), val)
+}
+
+// writeTrace prints an item of trace inline on the screen, or writes it on a line of its own
+// if the trace is going to a file channel
+func writeTrace(g *game.Game, env *object.Environment, item string) {
+	_, _, channel := env.Trace()
+	if channel == 0 {
+		g.Print(item + " ")
+		return
+	}
+	if isError(writeStringToFile(g, channel, item+"\n")) {
+		// The channel has been closed so stop tracing
+		env.SetTrace(false, false, 0)
 	}
 }
 
@@ -2208,10 +2394,10 @@ func evalSetPaperStatement(g *game.Game, stmt *ast.SetPaperStatement, env *objec
 		if g.ValidateColour(int(val.Value)) {
 			g.SetPaper(int(val.Value))
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	return nil
 }
@@ -2225,10 +2411,10 @@ func evalSetBorderStatement(g *game.Game, stmt *ast.SetBorderStatement, env *obj
 		if g.ValidateColour(int(val.Value)) {
 			g.SetBorder(int(val.Value))
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	return nil
 }
@@ -2242,10 +2428,10 @@ func evalSetPenStatement(g *game.Game, stmt *ast.SetPenStatement, env *object.En
 		if g.ValidateColour(int(val.Value)) {
 			g.SetPen(int(val.Value))
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	return nil
 }
@@ -2262,7 +2448,7 @@ func evalSetCurposStatement(g *game.Game, stmt *ast.SetCurposStatement, env *obj
 	if val, ok := col.(*object.Numeric); ok {
 		colVal = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 
 	// evaluate row
@@ -2272,7 +2458,7 @@ func evalSetCurposStatement(g *game.Game, stmt *ast.SetCurposStatement, env *obj
 	if val, ok := row.(*object.Numeric); ok {
 		rowVal = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	g.SetCurpos(colVal, rowVal)
 	return nil
@@ -2287,7 +2473,7 @@ func evalSetWritingStatement(g *game.Game, stmt *ast.SetWritingStatement, env *o
 	if val, ok := obj.(*object.Numeric); ok {
 		slot = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// activating slot 0 is allowed but you can't change it
 	minSlot := 1
@@ -2295,7 +2481,7 @@ func evalSetWritingStatement(g *game.Game, stmt *ast.SetWritingStatement, env *o
 		minSlot = 0
 	}
 	if slot < minSlot || slot > 10 {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// SET WRITING e1
 	if stmt.Col1 == nil {
@@ -2310,10 +2496,10 @@ func evalSetWritingStatement(g *game.Game, stmt *ast.SetWritingStatement, env *o
 	if val, ok := obj.(*object.Numeric); ok {
 		col1 = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	if col1 < 1 || col1 > g.AskMode() {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	obj = Eval(g, stmt.Row1, env)
 	if isError(obj) {
@@ -2322,10 +2508,10 @@ func evalSetWritingStatement(g *game.Game, stmt *ast.SetWritingStatement, env *o
 	if val, ok := obj.(*object.Numeric); ok {
 		row1 = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	if row1 < 1 || row2 > 25 {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	obj = Eval(g, stmt.Col2, env)
 	if isError(obj) {
@@ -2334,10 +2520,10 @@ func evalSetWritingStatement(g *game.Game, stmt *ast.SetWritingStatement, env *o
 	if val, ok := obj.(*object.Numeric); ok {
 		col2 = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	if col2 < 1 || col2 > g.AskMode() {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	obj = Eval(g, stmt.Row2, env)
 	if isError(obj) {
@@ -2346,10 +2532,10 @@ func evalSetWritingStatement(g *game.Game, stmt *ast.SetWritingStatement, env *o
 	if val, ok := obj.(*object.Numeric); ok {
 		row2 = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	if row2 < 1 || row2 > 25 {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	g.SetWriting(slot, col1, row1, col2, row2)
 	return nil
@@ -2364,7 +2550,7 @@ func evalSetDrawingStatement(g *game.Game, stmt *ast.SetDrawingStatement, env *o
 	if val, ok := obj.(*object.Numeric); ok {
 		slot = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// activating slot 0 is allowed but you can't change it
 	minSlot := 1
@@ -2372,7 +2558,7 @@ func evalSetDrawingStatement(g *game.Game, stmt *ast.SetDrawingStatement, env *o
 		minSlot = 0
 	}
 	if slot < minSlot || slot > 10 {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// SET DRAWING e1
 	if stmt.X1 == nil {
@@ -2387,7 +2573,7 @@ func evalSetDrawingStatement(g *game.Game, stmt *ast.SetDrawingStatement, env *o
 	if val, ok := obj.(*object.Numeric); ok {
 		x1 = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	obj = Eval(g, stmt.Y1, env)
 	if isError(obj) {
@@ -2396,7 +2582,7 @@ func evalSetDrawingStatement(g *game.Game, stmt *ast.SetDrawingStatement, env *o
 	if val, ok := obj.(*object.Numeric); ok {
 		y1 = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	obj = Eval(g, stmt.X2, env)
 	if isError(obj) {
@@ -2405,7 +2591,7 @@ func evalSetDrawingStatement(g *game.Game, stmt *ast.SetDrawingStatement, env *o
 	if val, ok := obj.(*object.Numeric); ok {
 		x2 = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	obj = Eval(g, stmt.Y2, env)
 	if isError(obj) {
@@ -2414,7 +2600,7 @@ func evalSetDrawingStatement(g *game.Game, stmt *ast.SetDrawingStatement, env *o
 	if val, ok := obj.(*object.Numeric); ok {
 		y2 = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	g.SetDrawing(slot, x1, y1, x2, y2)
 	return nil
@@ -2429,7 +2615,7 @@ func evalSetPatternStatement(g *game.Game, stmt *ast.SetPatternStatement, env *o
 	if val, ok := obj.(*object.Numeric); ok {
 		slot = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	obj = Eval(g, stmt.Row, env)
 	if isError(obj) {
@@ -2438,7 +2624,7 @@ func evalSetPatternStatement(g *game.Game, stmt *ast.SetPatternStatement, env *o
 	if val, ok := obj.(*object.Numeric); ok {
 		row = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	obj = Eval(g, stmt.C1, env)
 	if isError(obj) {
@@ -2447,7 +2633,7 @@ func evalSetPatternStatement(g *game.Game, stmt *ast.SetPatternStatement, env *o
 	if val, ok := obj.(*object.Numeric); ok {
 		c1 = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	obj = Eval(g, stmt.C2, env)
 	if isError(obj) {
@@ -2456,7 +2642,7 @@ func evalSetPatternStatement(g *game.Game, stmt *ast.SetPatternStatement, env *o
 	if val, ok := obj.(*object.Numeric); ok {
 		c2 = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	obj = Eval(g, stmt.C3, env)
 	if isError(obj) {
@@ -2465,7 +2651,7 @@ func evalSetPatternStatement(g *game.Game, stmt *ast.SetPatternStatement, env *o
 	if val, ok := obj.(*object.Numeric); ok {
 		c3 = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	obj = Eval(g, stmt.C4, env)
 	if isError(obj) {
@@ -2474,7 +2660,7 @@ func evalSetPatternStatement(g *game.Game, stmt *ast.SetPatternStatement, env *o
 	if val, ok := obj.(*object.Numeric); ok {
 		c4 = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	g.SetPattern(slot, row, c1, c2, c3, c4)
 	return nil
@@ -2492,7 +2678,7 @@ func evalMoveStatement(g *game.Game, stmt *ast.MoveStatement, env *object.Enviro
 	if val, ok := cols.(*object.Numeric); ok {
 		colsIncr = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// evaluate row
 	if _, ok := rows.(*object.Error); ok {
@@ -2501,7 +2687,7 @@ func evalMoveStatement(g *game.Game, stmt *ast.MoveStatement, env *object.Enviro
 	if val, ok := rows.(*object.Numeric); ok {
 		rowsIncr = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	col, row := g.AskCurpos()
 	g.SetCurpos(col+colsIncr, row+rowsIncr)
@@ -2517,7 +2703,7 @@ func evalSetDegStatement(g *game.Game, stmt *ast.SetDegStatement, env *object.En
 		env.Degrees = isTruthy(obj)
 		return obj
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 }
 
@@ -2535,10 +2721,10 @@ func evalSetColourStatement(g *game.Game, stmt *ast.SetColourStatement, env *obj
 		if g.ValidateColour(int(val.Value)) {
 			paletteSlot = int(val.Value)
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// basicColour
 	obj = Eval(g, stmt.BasicColour, env)
@@ -2548,10 +2734,10 @@ func evalSetColourStatement(g *game.Game, stmt *ast.SetColourStatement, env *obj
 	if val, ok := obj.(*object.Numeric); ok {
 		basicColour = int(val.Value)
 		if basicColour < 0 || basicColour > 15 {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 3}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 3}
 	}
 	// optionals
 	if stmt.FlashSpeed == nil || stmt.FlashColour == nil {
@@ -2566,10 +2752,10 @@ func evalSetColourStatement(g *game.Game, stmt *ast.SetColourStatement, env *obj
 		if val, ok := obj.(*object.Numeric); ok {
 			flashSpeed = int(val.Value)
 			if flashSpeed < 0 || flashSpeed > 2 {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+				return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 			}
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 5} // <-- this is wrong...
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 5} // <-- this is wrong...
 		}
 		// flashColour
 		obj = Eval(g, stmt.FlashColour, env)
@@ -2578,11 +2764,11 @@ func evalSetColourStatement(g *game.Game, stmt *ast.SetColourStatement, env *obj
 		}
 		if val, ok := obj.(*object.Numeric); ok {
 			if flashColour < 0 || flashColour > 15 {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+				return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 			}
 			flashColour = int(val.Value)
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 7} // <-- so's this!
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 7} // <-- so's this!
 		}
 	}
 	// execute
@@ -2602,15 +2788,15 @@ func evalSetConfigBootStatement(g *game.Game, stmt *ast.SetConfigBootStatement,
 	if _, ok := obj.(*object.Numeric); ok {
 		c, err := g.ReadConf()
 		if err != nil {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.FileOperationFailure), ErrorTokenIndex: 0}
+			return &object.Error{Code: syntaxerror.FileOperationFailure, Message: syntaxerror.ErrorMessage(syntaxerror.FileOperationFailure), ErrorTokenIndex: 0}
 		}
 		c.Boot = isTruthy(obj)
 		if !g.WriteConf(c) {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.FileOperationFailure), ErrorTokenIndex: 0}
+			return &object.Error{Code: syntaxerror.FileOperationFailure, Message: syntaxerror.ErrorMessage(syntaxerror.FileOperationFailure), ErrorTokenIndex: 0}
 		}
 		return obj
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 }
 
@@ -2623,7 +2809,7 @@ func evalSetSoundStatement(g *game.Game, stmt *ast.SetSoundStatement, env *objec
 		g.SetSound(isTruthy(val))
 		return val
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 }
 
@@ -2638,7 +2824,7 @@ func evalSetToneStatement(g *game.Game, stmt *ast.SetToneStatement, env *object.
 		}
 		return val
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 }
 
@@ -2649,14 +2835,14 @@ func evalSetVoiceStatement(g *game.Game, stmt *ast.SetVoiceStatement, env *objec
 	}
 	if val, ok := obj.(*object.Numeric); ok {
 		if val.Value < 1.0 || val.Value > 3.0 {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 		if g.AskSound() {
 			g.SetVoice(int(val.Value) - 1)
 		}
 		return val
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 }
 
@@ -2669,10 +2855,10 @@ func evalSetEnvelopeStatement(g *game.Game, stmt *ast.SetEnvelopeStatement, env
 	if val, ok := obj.(*object.Numeric); ok {
 		slot = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	if slot < 1 || slot > 10 {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	if stmt.AttackTime == nil {
 		if g.AskSound() {
@@ -2690,10 +2876,10 @@ func evalSetEnvelopeStatement(g *game.Game, stmt *ast.SetEnvelopeStatement, env
 	if val, ok := obj.(*object.Numeric); ok {
 		attackTime = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	if attackTime < 0 {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	obj = Eval(g, stmt.DecayTime, env)
 	if isError(obj) {
@@ -2702,10 +2888,10 @@ func evalSetEnvelopeStatement(g *game.Game, stmt *ast.SetEnvelopeStatement, env
 	if val, ok := obj.(*object.Numeric); ok {
 		decayTime = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	if decayTime < 0 {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	obj = Eval(g, stmt.SustainTime, env)
 	if isError(obj) {
@@ -2714,10 +2900,10 @@ func evalSetEnvelopeStatement(g *game.Game, stmt *ast.SetEnvelopeStatement, env
 	if val, ok := obj.(*object.Numeric); ok {
 		sustainTime = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	if sustainTime < 0 {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	obj = Eval(g, stmt.ReleaseTime, env)
 	if isError(obj) {
@@ -2726,10 +2912,10 @@ func evalSetEnvelopeStatement(g *game.Game, stmt *ast.SetEnvelopeStatement, env
 	if val, ok := obj.(*object.Numeric); ok {
 		releaseTime = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	if releaseTime < 0 {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Evaluate levels
 	obj = Eval(g, stmt.AttackLevel, env)
@@ -2739,10 +2925,10 @@ func evalSetEnvelopeStatement(g *game.Game, stmt *ast.SetEnvelopeStatement, env
 	if val, ok := obj.(*object.Numeric); ok {
 		attackLevel = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	if attackLevel < 0 || attackLevel > 15 {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	obj = Eval(g, stmt.DecayLevel, env)
 	if isError(obj) {
@@ -2751,10 +2937,10 @@ func evalSetEnvelopeStatement(g *game.Game, stmt *ast.SetEnvelopeStatement, env
 	if val, ok := obj.(*object.Numeric); ok {
 		decayLevel = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	if decayLevel < 0 || decayLevel > 15 {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	obj = Eval(g, stmt.SustainLevel, env)
 	if isError(obj) {
@@ -2763,10 +2949,10 @@ func evalSetEnvelopeStatement(g *game.Game, stmt *ast.SetEnvelopeStatement, env
 	if val, ok := obj.(*object.Numeric); ok {
 		sustainLevel = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	if sustainLevel < 0 || sustainLevel > 15 {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Execute
 	if g.AskSound() {
@@ -2780,25 +2966,114 @@ func evalGotoStatement(g *game.Game, stmt *ast.GotoStatement, env *object.Enviro
 	val, _ := strconv.ParseFloat(stmt.Linenumber.Literal, 64)
 	lineNumber := int(val)
 	if lineNumber < 0 {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.PositiveValueRequired), ErrorTokenIndex: stmt.Linenumber.Index}
+		return &object.Error{Code: syntaxerror.PositiveValueRequired, Message: syntaxerror.ErrorMessage(syntaxerror.PositiveValueRequired), ErrorTokenIndex: stmt.Linenumber.Index}
 	}
 	if env.Program.Jump(lineNumber, 0) {
 		return nil
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.LineNumberDoesNotExist), ErrorTokenIndex: stmt.Linenumber.Index}
+		return &object.Error{Code: syntaxerror.LineNumberDoesNotExist, Message: syntaxerror.ErrorMessage(syntaxerror.LineNumberDoesNotExist), ErrorTokenIndex: stmt.Linenumber.Index}
+	}
+}
+
+// deleteConfirmationLines is the number of lines DELETE removes without asking first
+const deleteConfirmationLines = 10
+
+func evalDeleteStatement(g *game.Game, stmt *ast.DeleteStatement, env *object.Environment) object.Object {
+	fromLinenumber := 0
+	toLinenumber := 0
+	if stmt.FromLinenumber.Literal != "" {
+		val, _ := strconv.ParseFloat(stmt.FromLinenumber.Literal, 64)
+		fromLinenumber = int(val)
+	}
+	if stmt.ToLinenumber.Literal != "" {
+		val, _ := strconv.ParseFloat(stmt.ToLinenumber.Literal, 64)
+		toLinenumber = int(val)
+	}
+	if stmt.FromLineOnly {
+		// DELETE lineNumber deletes exactly that line
+		toLinenumber = fromLinenumber
+		if _, ok := env.Program.GetLineForEditing(fromLinenumber); !ok {
+			return &object.Error{Code: syntaxerror.LineNumberDoesNotExist, Message: syntaxerror.ErrorMessage(syntaxerror.LineNumberDoesNotExist), ErrorTokenIndex: stmt.FromLinenumber.Index}
+		}
+	}
+	count := len(env.Program.List(fromLinenumber, toLinenumber, false))
+	if count > deleteConfirmationLines {
+		g.Print(fmt.Sprintf("%d lines will be deleted", count))
+		if !askYesNo(g, "Continue?") {
+			return nil
+		}
+	}
+	env.Program.Delete(fromLinenumber, toLinenumber)
+	return nil
+}
+
+func evalAutoStatement(g *game.Game, stmt *ast.AutoStatement, env *object.Environment) object.Object {
+	// Start and step both default to 10
+	params := []int{10, 10}
+	for i, param := range []ast.Expression{stmt.Start, stmt.Step} {
+		if param == nil {
+			continue
+		}
+		obj := Eval(g, param, env)
+		if isError(obj) {
+			return obj
+		}
+		val, ok := obj.(*object.Numeric)
+		if !ok {
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		}
+		if val.Value < 1 {
+			return &object.Error{Code: syntaxerror.PositiveValueRequired, Message: syntaxerror.ErrorMessage(syntaxerror.PositiveValueRequired), ErrorTokenIndex: stmt.Token.Index + 1}
+		}
+		params[i] = int(val.Value)
+	}
+	lineNumber, step := params[0], params[1]
+	// Keep offering the next line number until <BREAK> or a line with nothing after the line
+	// number.  Existing lines are offered in full so they can be kept or overwritten.
+	for {
+		prompt := fmt.Sprintf("%d ", lineNumber)
+		if line, ok := env.Program.GetLineForEditing(lineNumber); ok {
+			prompt += strings.TrimSpace(line)
+		}
+		rawLine := g.Input(prompt)
+		if g.AskBreak() {
+			return nil
+		}
+		l := &lexer.Lexer{}
+		l.Scan(rawLine)
+		p := parser.New(l, g)
+		line := p.ParseLine()
+		if line.Statements != nil {
+			// The line number has been deleted
+			if len(line.Statements) == 0 {
+				return nil
+			}
+			return &object.Error{Code: syntaxerror.LineNumberExpected, Message: syntaxerror.ErrorMessage(syntaxerror.LineNumberExpected), ErrorTokenIndex: 0}
+		}
+		if line.LineString == "" {
+			return nil
+		}
+		env.Program.AddLine(line.LineNumber, line.LineString)
+		lineNumber = line.LineNumber + step
 	}
 }
 
 func evalEditStatement(g *game.Game, stmt *ast.EditStatement, env *object.Environment) object.Object {
-	// TODO: Handle no line number so try to get line of last error
+	// With no line number use the full-screen editor, starting from the line where the
+	// program last stopped if it was stopped by an error or <BREAK>
 	if stmt.Linenumber.Literal == "" {
+		e := newEditor(g, env)
+		if env.ErrorSignal {
+			e.gotoLine(env.Program.GetLineNumber())
+		}
+		e.run()
 		return nil
 	}
 	// Get line number direct from literal
 	val, _ := strconv.ParseFloat(stmt.Linenumber.Literal, 64)
 	lineNumber := int(val)
 	if lineNumber < 0 {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.PositiveValueRequired), ErrorTokenIndex: stmt.Linenumber.Index}
+		return &object.Error{Code: syntaxerror.PositiveValueRequired, Message: syntaxerror.ErrorMessage(syntaxerror.PositiveValueRequired), ErrorTokenIndex: stmt.Linenumber.Index}
 	}
 	// Edit the line if it exists
 	if line, ok := env.Program.GetLineForEditing(lineNumber); ok {
@@ -2816,7 +3091,7 @@ func evalEditStatement(g *game.Game, stmt *ast.EditStatement, env *object.Enviro
 			env.Program.AddLine(lineNumber, line.LineString)
 		}
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.LineNumberDoesNotExist), ErrorTokenIndex: stmt.Linenumber.Index}
+		return &object.Error{Code: syntaxerror.LineNumberDoesNotExist, Message: syntaxerror.ErrorMessage(syntaxerror.LineNumberDoesNotExist), ErrorTokenIndex: stmt.Linenumber.Index}
 	}
 	return nil
 }
@@ -2824,7 +3099,6 @@ func evalEditStatement(g *game.Game, stmt *ast.EditStatement, env *object.Enviro
 func evalRestoreStatement(g *game.Game, stmt *ast.RestoreStatement, env *object.Environment) object.Object {
 	resumeLine := env.Program.GetLineNumber()
 	resumeStatement := env.Program.CurrentStatementNumber
-	l := &lexer.Lexer{}
 	env.Program.Start()
 	// Jump to line number if specified and it exists in program
 	if stmt.Linenumber.Literal != "" {
@@ -2832,22 +3106,24 @@ func evalRestoreStatement(g *game.Game, stmt *ast.RestoreStatement, env *object.
 		val, _ := strconv.ParseFloat(stmt.Linenumber.Literal, 64)
 		lineNumber := int(val)
 		if lineNumber < 0 {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.PositiveValueRequired), ErrorTokenIndex: stmt.Linenumber.Index}
+			return &object.Error{Code: syntaxerror.PositiveValueRequired, Message: syntaxerror.ErrorMessage(syntaxerror.PositiveValueRequired), ErrorTokenIndex: stmt.Linenumber.Index}
 		}
 		// Try to jump
 		if !env.Program.Jump(lineNumber, 0) {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.LineNumberDoesNotExist), ErrorTokenIndex: stmt.Linenumber.Index}
+			return &object.Error{Code: syntaxerror.LineNumberDoesNotExist, Message: syntaxerror.ErrorMessage(syntaxerror.LineNumberDoesNotExist), ErrorTokenIndex: stmt.Linenumber.Index}
 		}
 	}
 	// Run through the stored program but only collect data
 	env.DeleteData()
 	for !env.Program.EndOfProgram() {
-		l.Scan(env.Program.GetLine())
-		p := parser.New(l, g)
-		line := p.ParseLine()
-		// Disregard parser errors as these will be handling during execution.
-		if _, hasError := p.GetError(); hasError {
-			continue
+		line, p := parseProgramLine(g, env)
+		// Only a freshly parsed line can have parsing errors
+		if p != nil {
+			// Disregard parser errors as these will be handling during execution.
+			if _, hasError := p.GetError(); hasError {
+				env.Program.Next()
+				continue
+			}
 		}
 		// Only DATA
 		for statementNumber, stmt := range line.Statements {
@@ -2858,6 +3134,7 @@ func evalRestoreStatement(g *game.Game, stmt *ast.RestoreStatement, env *object.
 				obj := Eval(g, stmt, env)
 				env.Prerun = false
 				if errorMsg, ok := obj.(*object.Error); ok {
+					p := programLineParser(g, env)
 					if errorMsg.ErrorTokenIndex != 0 {
 						p.ErrorTokenIndex = errorMsg.ErrorTokenIndex
 					}
@@ -2901,7 +3178,7 @@ func evalDataStatement(g *game.Game, stmt *ast.DataStatement, env *object.Enviro
 func evalSubroutineStatement(g *game.Game, stmt *ast.SubroutineStatement, env *object.Environment) object.Object {
 	// Error if not prerun
 	if !env.Prerun {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.CannotExecuteDefinition), ErrorTokenIndex: stmt.Token.Index}
+		return &object.Error{Code: syntaxerror.CannotExecuteDefinition, Message: syntaxerror.ErrorMessage(syntaxerror.CannotExecuteDefinition), ErrorTokenIndex: stmt.Token.Index}
 	}
 	// Register subroutine
 	stmt.LineNumber = env.Program.GetLineNumber()
@@ -2919,37 +3196,175 @@ func evalGosubStatement(g *game.Game, stmt *ast.GosubStatement, env *object.Envi
 	if stmt.IsLabel {
 		// Jump to subroutine label
 		if sub, ok := env.GetSubroutine(stmt.Name.Value); ok {
-			env.Program.Jump(sub.LineNumber, sub.StatementNumber)
-			env.Program.Next()
+			env.Program.Jump(sub.LineNumber, sub.StatementNumber+1)
+			return nil
 		}
-	} else {
-		// Jump to line number
-		val, _ := strconv.ParseFloat(stmt.Name.Value, 64)
-		env.Program.Jump(int(val), 0)
+		env.JumpStack.Pop()
+		return &object.Error{Code: syntaxerror.SpecifiedLineNotFound, Message: syntaxerror.ErrorMessage(syntaxerror.SpecifiedLineNotFound), ErrorTokenIndex: stmt.Name.Token.Index}
 	}
-	return nil
+	// Jump to line number
+	val, _ := strconv.ParseFloat(stmt.Name.Value, 64)
+	if env.Program.Jump(int(val), 0) {
+		return nil
+	}
+	env.JumpStack.Pop()
+	return &object.Error{Code: syntaxerror.LineNumberDoesNotExist, Message: syntaxerror.ErrorMessage(syntaxerror.LineNumberDoesNotExist), ErrorTokenIndex: stmt.Name.Token.Index}
 }
 
 func evalReturnStatement(g *game.Game, stmt *ast.ReturnStatement, env *object.Environment) object.Object {
 	// Pop return stack until we find a gosub statement.  If we don't find one, return the
 	// RETURN without any GOSUB error.
-	looking := true
-	for looking {
+	for {
 		jumpItem := env.JumpStack.Pop()
+		if jumpItem == nil {
+			// stack is empty
+			break
+		}
 		// test if this is a gosub and jump back to it if so
 		if gosub, ok := jumpItem.(*ast.GosubStatement); ok {
-			env.Program.Jump(gosub.LineNumber, gosub.StatementNumber)
-			env.Program.Next()
+			// Returning from an ON ERROR GOSUB handler ends the error handling
+			if env.HandlingError() && env.ErrorHandler() == ast.Statement(gosub) {
+				env.ResumeFromError()
+			}
+			// Likewise for an ON BREAK GOSUB handler
+			if env.HandlingBreak() && env.BreakHandler() == ast.Statement(gosub) {
+				env.ResumeFromBreak()
+			}
+			env.Program.Jump(gosub.LineNumber, gosub.StatementNumber+1)
 			return nil
 		}
 	}
-	return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.ReturnWithoutAnyGosub), ErrorTokenIndex: stmt.Token.Index}
+	return &object.Error{Code: syntaxerror.ReturnWithoutAnyGosub, Message: syntaxerror.ErrorMessage(syntaxerror.ReturnWithoutAnyGosub), ErrorTokenIndex: stmt.Token.Index}
+}
+
+func evalOnErrorStatement(g *game.Game, stmt *ast.OnErrorStatement, env *object.Environment) object.Object {
+	env.SetErrorHandler(stmt.Branch)
+	return nil
+}
+
+func evalOnBreakStatement(g *game.Game, stmt *ast.OnBreakStatement, env *object.Environment) object.Object {
+	env.SetBreakHandler(stmt.Branch)
+	return nil
+}
+
+func evalOnJumpStatement(g *game.Game, stmt *ast.OnJumpStatement, env *object.Environment) object.Object {
+	obj := Eval(g, stmt.Selector, env)
+	if isError(obj) {
+		return obj
+	}
+	val, ok := promoteInteger(obj).(*object.Numeric)
+	if !ok {
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+	}
+	// The value picks a branch counting from 1
+	selector := int(val.Value)
+	if selector < 1 || selector > len(stmt.Branches) {
+		return &object.Error{Code: syntaxerror.OnValueOutOfRange, Message: syntaxerror.ErrorMessage(syntaxerror.OnValueOutOfRange), ErrorTokenIndex: stmt.Token.Index + 1}
+	}
+	return Eval(g, stmt.Branches[selector-1], env)
+}
+
+func evalResumeStatement(g *game.Game, stmt *ast.ResumeStatement, env *object.Environment) object.Object {
+	if !env.HandlingError() {
+		return &object.Error{Code: syntaxerror.ResumeWithoutAnyError, Message: syntaxerror.ErrorMessage(syntaxerror.ResumeWithoutAnyError), ErrorTokenIndex: stmt.Token.Index}
+	}
+	_, lineNumber, statementNumber, _ := env.LastError()
+	switch {
+	case stmt.Next:
+		if _, ok := parseLineNumber(g, env, lineNumber); !ok {
+			// None of a line that failed to parse was run so carry on from the line after it
+			resumeAfterLine(env, lineNumber)
+			break
+		}
+		// Resume from the statement after the one that caused the error
+		env.Program.Jump(lineNumber, statementNumber+1)
+	case stmt.Linenumber.Literal != "":
+		// Resume from a specific line
+		val, _ := strconv.ParseFloat(stmt.Linenumber.Literal, 64)
+		if !env.Program.Jump(int(val), 0) {
+			return &object.Error{Code: syntaxerror.LineNumberDoesNotExist, Message: syntaxerror.ErrorMessage(syntaxerror.LineNumberDoesNotExist), ErrorTokenIndex: stmt.Linenumber.Index}
+		}
+	default:
+		// Try the statement that caused the error again
+		env.Program.Jump(lineNumber, statementNumber)
+	}
+	// If the handler was a GOSUB it won't be RETURNing so drop it from the jump stack
+	if gosub, ok := env.ErrorHandler().(*ast.GosubStatement); ok {
+		for {
+			jumpItem := env.JumpStack.Pop()
+			if jumpItem == nil || jumpItem == gosub {
+				break
+			}
+		}
+	}
+	env.ResumeFromError()
+	return nil
+}
+
+// resumeAfterLine carries on from the line after lineNumber, or ends the program if there
+// isn't one
+func resumeAfterLine(env *object.Environment, lineNumber int) {
+	sortedIndex, _ := env.Program.Dump()
+	for _, n := range sortedIndex {
+		if n > lineNumber {
+			env.Program.Jump(n, 0)
+			return
+		}
+	}
+	env.EndProgram()
+}
+
+// trapError passes an error to the ON ERROR handler, if there is one and it isn't already
+// handling an error.  It returns false if the error wasn't trapped and must be reported.
+// An error inside a procedure or function called after ON ERROR is passed back out of the
+// calls first, so the handler runs where ON ERROR was set.
+func trapError(g *game.Game, env *object.Environment, errorMsg *object.Error) bool {
+	handler := env.ErrorHandler()
+	if handler == nil || env.HandlingError() {
+		return false
+	}
+	if len(env.CallFrames()) > env.ErrorTrapDepth() {
+		// The trap was set outside this procedure or function so leave the call and handle
+		// the error where the trap was set
+		env.SetUnwindingError(errorMsg)
+		return true
+	}
+	env.SetUnwindingError(nil)
+	env.TrapError(errorMsg.Code, env.Program.GetLineNumber(), env.Program.CurrentStatementNumber, errorMsg.Message)
+	if isError(Eval(g, handler, env)) {
+		// The handler's GOTO or GOSUB failed so report the original error instead
+		env.ResumeFromError()
+		return false
+	}
+	return true
+}
+
+// trapBreak passes a <BREAK> to the ON BREAK handler, if there is one and an ON BREAK GOSUB
+// handler isn't already running, and clears the break so the program can carry on.  A
+// <BREAK> made while the handler is running stops the program as usual.
+func trapBreak(g *game.Game, env *object.Environment) {
+	handler := env.BreakHandler()
+	if handler == nil || env.HandlingBreak() {
+		return
+	}
+	if isError(Eval(g, handler, env)) {
+		// The handler's GOTO or GOSUB failed so let the break stop the program
+		return
+	}
+	g.SetBreak(false)
+	// A GOTO handler never returns so there's nothing to wait for, and an ELSE IF that was
+	// about to be tested never will be
+	if _, ok := handler.(*ast.GosubStatement); ok {
+		env.TrapBreak()
+	} else {
+		env.Program.ClearElseIf()
+	}
 }
 
 func evalFunctionDeclaration(g *game.Game, stmt *ast.FunctionDeclaration, env *object.Environment) object.Object {
 	// Error if not prerun
 	if !env.Prerun {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.CannotExecuteDefinition), ErrorTokenIndex: stmt.Token.Index}
+		return &object.Error{Code: syntaxerror.CannotExecuteDefinition, Message: syntaxerror.ErrorMessage(syntaxerror.CannotExecuteDefinition), ErrorTokenIndex: stmt.Token.Index}
 	}
 	// Register function
 	stmt.LineNumber = env.Program.GetLineNumber()
@@ -2960,22 +3375,25 @@ func evalFunctionDeclaration(g *game.Game, stmt *ast.FunctionDeclaration, env *o
 
 func evalResultStatement(g *game.Game, stmt *ast.ResultStatement, env *object.Environment) object.Object {
 	if env.IsBaseScope() {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.FunctionExitWithoutCall), ErrorTokenIndex: stmt.Token.Index}
+		return &object.Error{Code: syntaxerror.FunctionExitWithoutCall, Message: syntaxerror.ErrorMessage(syntaxerror.FunctionExitWithoutCall), ErrorTokenIndex: stmt.Token.Index}
 	}
 	obj := Eval(g, stmt.ResultValue, env)
+	if isError(obj) {
+		return obj
+	}
 	env.ReturnVals = append(env.ReturnVals, obj)
 	env.LeaveFunction()
 	return nil // Return vals are picked out of the env so this stays as nil
 }
 
 func evalEndfunStatement(g *game.Game, stmt *ast.EndfunStatement, env *object.Environment) object.Object {
-	return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NeedResultToExitFunction), ErrorTokenIndex: stmt.Token.Index}
+	return &object.Error{Code: syntaxerror.NeedResultToExitFunction, Message: syntaxerror.ErrorMessage(syntaxerror.NeedResultToExitFunction), ErrorTokenIndex: stmt.Token.Index}
 }
 
 func evalProcedureDeclaration(g *game.Game, stmt *ast.ProcedureDeclaration, env *object.Environment) object.Object {
 	// Error if not prerun
 	if !env.Prerun {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.CannotExecuteDefinition), ErrorTokenIndex: stmt.Token.Index}
+		return &object.Error{Code: syntaxerror.CannotExecuteDefinition, Message: syntaxerror.ErrorMessage(syntaxerror.CannotExecuteDefinition), ErrorTokenIndex: stmt.Token.Index}
 	}
 	// Register function
 	stmt.LineNumber = env.Program.GetLineNumber()
@@ -2986,7 +3404,7 @@ func evalProcedureDeclaration(g *game.Game, stmt *ast.ProcedureDeclaration, env
 
 func evalEndprocStatement(g *game.Game, stmt *ast.EndprocStatement, env *object.Environment) object.Object {
 	if env.IsBaseScope() {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.ProcedureExitWithoutCall), ErrorTokenIndex: stmt.Token.Index}
+		return &object.Error{Code: syntaxerror.ProcedureExitWithoutCall, Message: syntaxerror.ErrorMessage(syntaxerror.ProcedureExitWithoutCall), ErrorTokenIndex: stmt.Token.Index}
 	}
 	// TODO: Handle return values
 	env.ReturnVals = append(env.ReturnVals, nil)
@@ -2996,7 +3414,7 @@ func evalEndprocStatement(g *game.Game, stmt *ast.EndprocStatement, env *object.
 
 func evalLeaveStatement(g *game.Game, stmt *ast.LeaveStatement, env *object.Environment) object.Object {
 	if env.IsBaseScope() {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.ProcedureExitWithoutCall), ErrorTokenIndex: stmt.Token.Index}
+		return &object.Error{Code: syntaxerror.ProcedureExitWithoutCall, Message: syntaxerror.ErrorMessage(syntaxerror.ProcedureExitWithoutCall), ErrorTokenIndex: stmt.Token.Index}
 	}
 	// TODO: Handle return values
 	env.ReturnVals = append(env.ReturnVals, nil)
@@ -3005,7 +3423,28 @@ func evalLeaveStatement(g *game.Game, stmt *ast.LeaveStatement, env *object.Envi
 }
 
 func evalRenumberStatement(g *game.Game, stmt *ast.RenumberStatement, env *object.Environment) object.Object {
-	env.Program.Renumber()
+	// Start and increment default to 10 and the whole program is renumbered by default
+	params := []int{10, 10, 0, 0}
+	for i, param := range []ast.Expression{stmt.Start, stmt.Increment, stmt.From, stmt.To} {
+		if param == nil {
+			continue
+		}
+		obj := Eval(g, param, env)
+		if isError(obj) {
+			return obj
+		}
+		val, ok := obj.(*object.Numeric)
+		if !ok {
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		}
+		if val.Value < 1 {
+			return &object.Error{Code: syntaxerror.PositiveValueRequired, Message: syntaxerror.ErrorMessage(syntaxerror.PositiveValueRequired), ErrorTokenIndex: stmt.Token.Index + 1}
+		}
+		params[i] = int(val.Value)
+	}
+	if !env.Program.Renumber(params[0], params[1], params[2], params[3]) {
+		return &object.Error{Code: syntaxerror.RenumberWouldOverlapLines, Message: syntaxerror.ErrorMessage(syntaxerror.RenumberWouldOverlapLines), ErrorTokenIndex: stmt.Token.Index}
+	}
 	return nil
 }
 
@@ -3029,7 +3468,7 @@ func evalArraySubscripts(g *game.Game, env *object.Environment, subscripts []ast
 		if val, ok := obj.(*object.Numeric); ok {
 			evaluatedSubscripts[i] = int(val.Value)
 		} else {
-			return evaluatedSubscripts, &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: 1}, false
+			return evaluatedSubscripts, &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: 1}, false
 		}
 	}
 	return evaluatedSubscripts, nil, true
@@ -3051,10 +3490,15 @@ func evalReadStatement(g *game.Game, stmt *ast.ReadStatement, env *object.Enviro
 			// is numeric var
 			obj := env.PopData()
 			if obj == nil {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NoMoreDataToBeRead), ErrorTokenIndex: stmt.Token.Index}
+				return &object.Error{Code: syntaxerror.NoMoreDataToBeRead, Message: syntaxerror.ErrorMessage(syntaxerror.NoMoreDataToBeRead), ErrorTokenIndex: stmt.Token.Index}
 			}
 			// can only accept numeric data
-			if val, ok := obj.(*object.Numeric); ok {
+			if _, ok := obj.(*object.Numeric); ok {
+				val, ok := canObjectCastToIdentifierType(obj, varName)
+				if !ok {
+					val.(*object.Error).ErrorTokenIndex = stmt.Token.Index
+					return val
+				}
 				if len(subscripts) > 0 {
 					// is array
 					if obj, ok := env.SetArray(varName, subscripts, val); ok {
@@ -3065,16 +3509,16 @@ func evalReadStatement(g *game.Game, stmt *ast.ReadStatement, env *object.Enviro
 					}
 				} else {
 					// is var
-					env.Set(varName, &object.Numeric{Value: val.Value})
+					env.Set(varName, val)
 				}
 			} else {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.StringVariableExpected), ErrorTokenIndex: stmt.Token.Index}
+				return &object.Error{Code: syntaxerror.StringVariableExpected, Message: syntaxerror.ErrorMessage(syntaxerror.StringVariableExpected), ErrorTokenIndex: stmt.Token.Index}
 			}
 		} else {
 			// is string var - can accept numeric or string data
 			obj := env.PopData()
 			if obj == nil {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NoMoreDataToBeRead), ErrorTokenIndex: stmt.Token.Index}
+				return &object.Error{Code: syntaxerror.NoMoreDataToBeRead, Message: syntaxerror.ErrorMessage(syntaxerror.NoMoreDataToBeRead), ErrorTokenIndex: stmt.Token.Index}
 			}
 			// string data can be set directly
 			if val, ok := obj.(*object.String); ok {
@@ -3115,13 +3559,7 @@ func evalReadStatement(g *game.Game, stmt *ast.ReadStatement, env *object.Enviro
 func evalForStatement(g *game.Game, stmt *ast.ForStatement, env *object.Environment) object.Object {
 	stmt.LineNumber = env.Program.GetLineNumber()
 	stmt.StatementNumber = env.Program.CurrentStatementNumber
-	// don't repush the same Repeat
-	if forStmt, ok := env.JumpStack.Peek().(*ast.ForStatement); ok {
-		if forStmt.LineNumber == stmt.LineNumber && forStmt.StatementNumber == stmt.StatementNumber {
-			return nil
-		}
-	}
-	// Otherwise evaluate start, stop, step expressions, bind counting variable, and push to stack
+	// Evaluate start, stop, step expressions, bind counting variable, and push to stack
 	var start, stop, step float64
 	// Start
 	obj := Eval(g, stmt.Start, env)
@@ -3131,7 +3569,7 @@ func evalForStatement(g *game.Game, stmt *ast.ForStatement, env *object.Environm
 	if val, ok := obj.(*object.Numeric); ok {
 		start = val.Value
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Stop
 	obj = Eval(g, stmt.Stop, env)
@@ -3141,9 +3579,9 @@ func evalForStatement(g *game.Game, stmt *ast.ForStatement, env *object.Environm
 	if val, ok := obj.(*object.Numeric); ok {
 		stop = val.Value
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
-	// Step (Default=1)
+	// Step (Default=1).  A negative step counts down.
 	if stmt.Step == nil {
 		step = 1.0
 	} else {
@@ -3153,101 +3591,186 @@ func evalForStatement(g *game.Game, stmt *ast.ForStatement, env *object.Environm
 		}
 		if val, ok := obj.(*object.Numeric); ok {
 			step = val.Value
-			if step < 0 {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.PositiveValueRequired), ErrorTokenIndex: stmt.Token.Index + 1}
-			}
 			if step == 0 {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.StepValueNotLargeEnough), ErrorTokenIndex: stmt.Token.Index + 1}
+				return &object.Error{Code: syntaxerror.StepValueNotLargeEnough, Message: syntaxerror.ErrorMessage(syntaxerror.StepValueNotLargeEnough), ErrorTokenIndex: stmt.Token.Index + 1}
 			}
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	}
-	// Flip step sign if counting down
-	if stop < start {
-		step *= -1.0
-	}
 	// Bind counting variable (must be numeric) --- TODO this should be handled by parser!
 	if stmt.Name.Value[len(stmt.Name.Value)-1:] == "$" {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericVariableNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericVariableNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericVariableNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
-	env.Set(stmt.Name.Value, &object.Numeric{Value: start})
-	// Update ast with evaluated stop and step values, then push to stack
-	stmt.StartValue = start
-	stmt.StopValue = stop
-	stmt.StepValue = step
-	env.JumpStack.Push(stmt)
+	counter, ok := canObjectCastToIdentifierType(&object.Numeric{Value: start}, stmt.Name.Value)
+	if !ok {
+		counter.(*object.Error).ErrorTokenIndex = stmt.Token.Index + 1
+		return counter
+	}
+	env.Set(stmt.Name.Value, counter)
+	// A loop that is already counting with the same variable is replaced by this one, along with
+	// any loops inside it, e.g. when an inner loop is started again after a GOTO out of it
+	if depth, ok := findForLoop(env, stmt.Name.Value); ok {
+		for i := 0; i <= depth; i++ {
+			env.JumpStack.Pop()
+		}
+	}
+	// A loop that has nothing to count isn't run at all
+	if (step > 0 && start > stop) || (step < 0 && start < stop) {
+		return skipForLoop(g, env, stmt.Token)
+	}
+	// Push a copy of the ast with the evaluated stop and step values to the stack.  The ast
+	// itself is cached and shared by every execution of the line so it mustn't hold loop state.
+	forStmt := *stmt
+	forStmt.StartValue = start
+	forStmt.StopValue = stop
+	forStmt.StepValue = step
+	env.JumpStack.Push(&forStmt)
 	return nil
 }
 
-func evalNextStatement(g *game.Game, stmt *ast.NextStatement, env *object.Environment) object.Object {
-
-	// Ensure we're inside the FOR loop before evaluating condition
-	if forStmt, ok := env.JumpStack.Peek().(*ast.ForStatement); ok {
-		// Get optional var name
-		var controlVar string
-		if stmt.Name != nil {
-			controlVar = stmt.Name.Value
-		} else {
-			controlVar = forStmt.Name.Value
+// findForLoop looks down the jump stack for the FOR loop that counts with the variable name, or
+// the innermost loop if name is "".  It returns how far below the top of the stack the loop is.
+// The search stops at anything other than a loop, e.g. a GOSUB, because a NEXT can't end a loop
+// that was started outside the subroutine it's in.
+func findForLoop(env *object.Environment, name string) (depth int, ok bool) {
+	for depth = 0; depth < env.JumpStack.Len(); depth++ {
+		switch item := env.JumpStack.Item(depth).(type) {
+		case *ast.ForStatement:
+			if name == "" || item.Name.Value == name {
+				return depth, true
+			}
+		case *ast.RepeatStatement:
+		default:
+			return 0, false
 		}
-		// Get value of counter variable
-		var counterVal float64
-		if obj, ok := env.Get(controlVar); ok {
-			if val, ok := obj.(*object.Numeric); ok {
-				counterVal = val.Value
-			} else {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index}
+	}
+	return 0, false
+}
+
+// skipForLoop jumps past the NEXT that ends a FOR loop which runs zero times
+func skipForLoop(g *game.Game, env *object.Environment, tok token.Token) object.Object {
+	var next *ast.NextStatement
+	var remaining []*ast.Identifier
+	depth, lineNumber, statementNumber := 0, 0, 0
+	scanProgram(g, env, func(stmt ast.Statement, l, s int) bool {
+		switch stmt := stmt.(type) {
+		case *ast.ForStatement:
+			depth++
+		case *ast.NextStatement:
+			// NEXT on its own ends one loop and NEXT J, I ends one for each variable
+			ends := len(stmt.Names)
+			if ends == 0 {
+				ends = 1
 			}
-		} else {
-			env.Set(controlVar, &object.Numeric{Value: 0})
-			counterVal = 0
-		}
-		// Evaluate condition
-		conditionMet := false
-		if forStmt.StartValue < forStmt.StopValue && counterVal+forStmt.StepValue > forStmt.StopValue {
-			conditionMet = true
-		}
-		if forStmt.StartValue > forStmt.StopValue && counterVal+forStmt.StepValue < forStmt.StopValue {
-			conditionMet = true
-		}
-		if !conditionMet {
-			// increment counter and loop again
-			counterVal += forStmt.StepValue
-			env.Set(controlVar, &object.Numeric{Value: counterVal})
-			env.Program.Jump(forStmt.LineNumber, forStmt.StatementNumber)
-			return nil
-		} else {
-			// drop through loop
-			env.JumpStack.Pop()
-			return nil
+			if depth < ends {
+				next, lineNumber, statementNumber = stmt, l, s
+				if depth+1 < len(stmt.Names) {
+					remaining = stmt.Names[depth+1:]
+				}
+				return true
+			}
+			depth -= ends
 		}
+		return false
+	})
+	if next == nil {
+		return &object.Error{Code: syntaxerror.ForWithoutNext, Message: syntaxerror.ErrorMessage(syntaxerror.ForWithoutNext), ErrorTokenIndex: tok.Index}
 	}
-	return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.UntilWithoutAnyRepeat), ErrorTokenIndex: stmt.Token.Index}
+	env.Program.Jump(lineNumber, statementNumber+1)
+	// The rest of a NEXT J, I still has to be done
+	return nextLoops(g, env, remaining, next.Token)
 }
 
-func evalGlobalStatement(g *game.Game, stmt *ast.GlobalStatement, env *object.Environment) object.Object {
-	for _, name := range stmt.Names {
-		if !env.Global(name.Value) {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.VariableUsedAsLocal), ErrorTokenIndex: stmt.Token.Index}
+func evalNextStatement(g *game.Game, stmt *ast.NextStatement, env *object.Environment) object.Object {
+	// NEXT on its own ends the innermost loop
+	if len(stmt.Names) == 0 {
+		_, obj := nextLoop(g, env, nil, stmt.Token)
+		return obj
+	}
+	return nextLoops(g, env, stmt.Names, stmt.Token)
+}
+
+// nextLoops does NEXT for each of the variables of NEXT J, I in turn until one of the loops
+// goes round again
+func nextLoops(g *game.Game, env *object.Environment, names []*ast.Identifier, tok token.Token) object.Object {
+	for _, name := range names {
+		looped, obj := nextLoop(g, env, name, tok)
+		if obj != nil || looped {
+			return obj
 		}
 	}
 	return nil
 }
 
-func evalDimStatement(g *game.Game, stmt *ast.DimStatement, env *object.Environment) object.Object {
-	for _, item := range stmt.Payload {
+// nextLoop counts the FOR loop that uses the variable name, or the innermost loop if name is nil.
+// It returns true if the loop goes round again.
+func nextLoop(g *game.Game, env *object.Environment, name *ast.Identifier, tok token.Token) (bool, object.Object) {
+	controlVar := ""
+	errorTokenIndex := tok.Index
+	if name != nil {
+		controlVar = name.Value
+		errorTokenIndex = name.Token.Index
+	}
+	// Ensure we're inside the FOR loop before evaluating condition
+	depth, ok := findForLoop(env, controlVar)
+	if !ok {
+		return false, &object.Error{Code: syntaxerror.NextWithoutMatchingFor, Message: syntaxerror.ErrorMessage(syntaxerror.NextWithoutMatchingFor), ErrorTokenIndex: errorTokenIndex}
+	}
+	// Any loops inside this one have been left without reaching their NEXT so drop them
+	for i := 0; i < depth; i++ {
+		env.JumpStack.Pop()
+	}
+	forStmt := env.JumpStack.Peek().(*ast.ForStatement)
+	controlVar = forStmt.Name.Value
+	// Get value of counter variable
+	var counterVal float64
+	if obj, ok := env.Get(controlVar); ok {
+		if val, ok := promoteInteger(obj).(*object.Numeric); ok {
+			counterVal = val.Value
+		} else {
+			return false, &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: errorTokenIndex}
+		}
+	}
+	// Drop through the loop once the counter would pass the stop value
+	counterVal += forStmt.StepValue
+	if (forStmt.StepValue > 0 && counterVal > forStmt.StopValue) || (forStmt.StepValue < 0 && counterVal < forStmt.StopValue) {
+		env.JumpStack.Pop()
+		return false, nil
+	}
+	// Otherwise increment counter and loop again
+	counter, ok := canObjectCastToIdentifierType(&object.Numeric{Value: counterVal}, controlVar)
+	if !ok {
+		counter.(*object.Error).ErrorTokenIndex = errorTokenIndex
+		return false, counter
+	}
+	env.Set(controlVar, counter)
+	env.Program.Jump(forStmt.LineNumber, forStmt.StatementNumber+1)
+	return true, nil
+}
+
+func evalGlobalStatement(g *game.Game, stmt *ast.GlobalStatement, env *object.Environment) object.Object {
+	for _, name := range stmt.Names {
+		if !env.Global(name.Value) {
+			return &object.Error{Code: syntaxerror.VariableUsedAsLocal, Message: syntaxerror.ErrorMessage(syntaxerror.VariableUsedAsLocal), ErrorTokenIndex: stmt.Token.Index}
+		}
+	}
+	return nil
+}
+
+func evalDimStatement(g *game.Game, stmt *ast.DimStatement, env *object.Environment) object.Object {
+	for _, item := range stmt.Payload {
 		subscripts := make([]int, len(item.Subscripts))
 		for i := 0; i < len(item.Subscripts); i++ {
 			obj := Eval(g, item.Subscripts[i], env)
 			if val, ok := obj.(*object.Numeric); ok {
 				subscripts[i] = int(val.Value)
 			} else {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index}
+				return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index}
 			}
 		}
 		if len(subscripts) == 0 {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.WrongNumberOfSubscripts), ErrorTokenIndex: stmt.Token.Index}
+			return &object.Error{Code: syntaxerror.WrongNumberOfSubscripts, Message: syntaxerror.ErrorMessage(syntaxerror.WrongNumberOfSubscripts), ErrorTokenIndex: stmt.Token.Index}
 		}
 		obj, _ := env.NewArray(item.Name.Value, subscripts)
 		// return if object is error
@@ -3264,11 +3787,12 @@ func evalAskMouseStatement(g *game.Game, stmt *ast.AskMouseStatement, env *objec
 		return nil
 	}
 	// Set X, Y
-	env.Set(stmt.XName.Value, &object.Numeric{Value: float64(g.MouseX)})
-	env.Set(stmt.YName.Value, &object.Numeric{Value: float64(g.MouseY)})
+	mouseX, mouseY, mouseButton := g.AskMouse()
+	env.Set(stmt.XName.Value, &object.Numeric{Value: float64(mouseX)})
+	env.Set(stmt.YName.Value, &object.Numeric{Value: float64(mouseY)})
 	// Handle button if required
 	if stmt.BName != nil {
-		env.Set(stmt.BName.Value, &object.Numeric{Value: float64(g.MouseButton)})
+		env.Set(stmt.BName.Value, &object.Numeric{Value: float64(mouseButton)})
 	}
 	return nil
 }
@@ -3287,7 +3811,7 @@ func evalAskBlocksizeStatement(g *game.Game, stmt *ast.AskBlocksizeStatement, en
 	if val, ok := obj.(*object.Numeric); ok {
 		block = int(val.Value)
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Execute
 	width, height, mode := g.AskBlocksize(block)
@@ -3321,7 +3845,7 @@ func evalUntilStatement(g *game.Game, stmt *ast.UntilStatement, env *object.Envi
 			return nil
 		}
 	}
-	return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.UntilWithoutAnyRepeat), ErrorTokenIndex: stmt.Token.Index}
+	return &object.Error{Code: syntaxerror.UntilWithoutAnyRepeat, Message: syntaxerror.ErrorMessage(syntaxerror.UntilWithoutAnyRepeat), ErrorTokenIndex: stmt.Token.Index}
 }
 
 func evalSetRadStatement(g *game.Game, stmt *ast.SetRadStatement, env *object.Environment) object.Object {
@@ -3333,7 +3857,7 @@ func evalSetRadStatement(g *game.Game, stmt *ast.SetRadStatement, env *object.En
 		env.Degrees = !val.Value
 		return obj
 	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 }
 
@@ -3346,12 +3870,12 @@ func evalListStatement(g *game.Game, stmt *ast.ListStatement, env *object.Enviro
 	if stmt.Channel != nil {
 		if val, ok := Eval(g, stmt.Channel, env).(*object.Numeric); ok {
 			if val.Value < 11 || val.Value > 127 {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.WrongChannelNumberUsed), ErrorTokenIndex: stmt.Token.Index}
+				return &object.Error{Code: syntaxerror.WrongChannelNumberUsed, Message: syntaxerror.ErrorMessage(syntaxerror.WrongChannelNumberUsed), ErrorTokenIndex: stmt.Token.Index}
 			} else {
 				channel = int(val.Value)
 			}
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index}
 		}
 	}
 	// Evaluate and handle TextBoxSlot if set
@@ -3363,7 +3887,7 @@ func evalListStatement(g *game.Game, stmt *ast.ListStatement, env *object.Enviro
 		if val, ok := obj.(*object.Numeric); ok {
 			tempTextBoxSlot = int(val.Value)
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	}
 	if channel == 0 {
@@ -3410,121 +3934,444 @@ func evalListStatement(g *game.Game, stmt *ast.ListStatement, env *object.Enviro
 	return nil
 }
 
+// parseProgramLine returns the AST of the current program line.  Lines are only parsed the
+// first time they're needed and after that the cached AST is used.  If the line did have
+// to be parsed then the parser is returned too so that any parsing errors can be reported,
+// otherwise the parser is nil.  Lines with parsing errors are never cached.
+func parseProgramLine(g *game.Game, env *object.Environment) (*ast.Line, *parser.Parser) {
+	if line, ok := env.Program.GetParsedLine(); ok {
+		return line, nil
+	}
+	l := &lexer.Lexer{}
+	l.Scan(env.Program.GetLine())
+	p := parser.New(l, g)
+	line := p.ParseLine()
+	if _, hasError := p.GetError(); !hasError && len(p.Errors()) == 0 {
+		env.Program.SetParsedLine(line)
+	}
+	return line, p
+}
+
+// lineParseError returns the error for a program line that failed to parse, or nil if it
+// parsed cleanly
+func lineParseError(p *parser.Parser) *object.Error {
+	if errorMsg, hasError := p.GetError(); hasError {
+		return &object.Error{Code: syntaxerror.ErrorCode(errorMsg), Message: errorMsg}
+	}
+	if len(p.Errors()) > 0 {
+		return &object.Error{Code: syntaxerror.InvalidExpressionFound, Message: p.Errors()[0]}
+	}
+	return nil
+}
+
+// parseLineNumber returns the AST of any program line without moving the program counter
+func parseLineNumber(g *game.Game, env *object.Environment, lineNumber int) (*ast.Line, bool) {
+	if line, ok := env.Program.GetParsedLineNumber(lineNumber); ok {
+		return line, true
+	}
+	_, lines := env.Program.Dump()
+	l := &lexer.Lexer{}
+	l.Scan(lines[lineNumber])
+	p := parser.New(l, g)
+	line := p.ParseLine()
+	if _, hasError := p.GetError(); hasError || len(p.Errors()) > 0 {
+		return nil, false
+	}
+	env.Program.SetParsedLineNumber(lineNumber, line)
+	return line, true
+}
+
+// programLineParser returns a parser loaded with the current program line, which is needed
+// to pretty print the line when an error is reported
+func programLineParser(g *game.Game, env *object.Environment) *parser.Parser {
+	l := &lexer.Lexer{}
+	l.Scan(env.Program.GetLine())
+	return parser.New(l, g)
+}
+
+// blockIf is a block IF that prerun has found the start of but not the ENDIF
+type blockIf struct {
+	lineNumber int
+	tokenIndex int
+	hasElse    bool // True once the plain ELSE of the block has been found
+}
+
+// checkBlockIf checks that stmt fits in with the block IFs that prerun has found so far, and
+// returns the block IFs that are still waiting for their ENDIF
+func checkBlockIf(stmt ast.Statement, blockIfs []blockIf, lineNumber int) ([]blockIf, object.Object) {
+	switch stmt := stmt.(type) {
+	case *ast.IfStatement:
+		if stmt.Block {
+			blockIfs = append(blockIfs, blockIf{lineNumber: lineNumber, tokenIndex: stmt.Token.Index})
+		}
+	case *ast.ElseStatement:
+		// ELSE must be inside a block IF and no branch can follow a plain ELSE
+		if len(blockIfs) == 0 || blockIfs[len(blockIfs)-1].hasElse {
+			return blockIfs, &object.Error{Code: syntaxerror.ElseWithoutAnyIf, Message: syntaxerror.ErrorMessage(syntaxerror.ElseWithoutAnyIf), ErrorTokenIndex: stmt.Token.Index}
+		}
+		if stmt.Condition == nil {
+			blockIfs[len(blockIfs)-1].hasElse = true
+		}
+	case *ast.EndifStatement:
+		if len(blockIfs) == 0 {
+			return blockIfs, &object.Error{Code: syntaxerror.EndifWithoutAnyIf, Message: syntaxerror.ErrorMessage(syntaxerror.EndifWithoutAnyIf), ErrorTokenIndex: stmt.Token.Index}
+		}
+		blockIfs = blockIfs[:len(blockIfs)-1]
+	}
+	return blockIfs, nil
+}
+
+// printPrerunError reports an error that prerun found in the current line
+func printPrerunError(g *game.Game, env *object.Environment, errorMsg *object.Error) {
+	p := programLineParser(g, env)
+	if errorMsg.ErrorTokenIndex != 0 {
+		p.ErrorTokenIndex = errorMsg.ErrorTokenIndex
+	}
+	lineNumber := env.Program.GetLineNumber()
+	g.Print(fmt.Sprintf("%s in line %d", errorMsg.Message, lineNumber))
+	g.Put(13)
+	p.JumpToToken(0)
+	g.Print(fmt.Sprintf("%d %s", lineNumber, p.PrettyPrint()))
+	g.Put(13)
+}
+
+// CheckProgram runs through the stored program the way RUN does before starting it.  If
+// the program can't be run, e.g. because a line can't be understood, the first error is
+// reported and false is returned.
+func CheckProgram(g *game.Game, env *object.Environment) bool {
+	return prerun(g, env)
+}
+
 func prerun(g *game.Game, env *object.Environment) bool {
 	// Run through the stored program without executing instructions.  Instead
 	// register all functions, procedures, subroutines and collect data.
-	l := &lexer.Lexer{}
 	env.Program.Start()
-	env.DeleteStore()
 	env.DeleteData()
 	env.DeleteSubroutines()
 	env.DeleteFunctions()
 	env.DeleteProcedures()
 	env.Prerun = true
+	blockIfs := []blockIf{}
 	for !env.Program.EndOfProgram() {
-		l.Scan(env.Program.GetLine())
-		p := parser.New(l, g)
-		line := p.ParseLine()
-		// Handle parsing error here --> need some tweaks
-		if errorMsg, hasError := p.GetError(); hasError {
-			g.Print(errorMsg)
-			g.Put(13)
-			p.JumpToToken(0)
-			g.Print(p.PrettyPrint())
-			g.Put(13)
-			return false
+		line, p := parseProgramLine(g, env)
+		// Only a freshly parsed line can have parsing errors
+		if p != nil {
+			// Handle parsing error here --> need some tweaks
+			if errorMsg, hasError := p.GetError(); hasError {
+				g.Print(errorMsg)
+				g.Put(13)
+				p.JumpToToken(0)
+				g.Print(p.PrettyPrint())
+				g.Put(13)
+				return false
+			}
 		}
 		// Only evaluate the following statements:
 		// FUNCTION, PROCEDURE, SUBROUTINE, DATA
 		for statementNumber, stmt := range line.Statements {
 			env.Program.CurrentStatementNumber = statementNumber
 			tokenType := stmt.TokenLiteral()
+			var obj object.Object
 			// Capture DATA statements, FUNCTION and PROCEDURE statements, and SUBROUTINE statements
 			if tokenType == token.DATA || tokenType == token.SUBROUTINE || tokenType == token.FUNCTION || tokenType == token.PROCEDURE {
-				obj := Eval(g, stmt, env)
-				// Handle eval error
-				if errorMsg, ok := obj.(*object.Error); ok {
-					if errorMsg.ErrorTokenIndex != 0 {
-						p.ErrorTokenIndex = errorMsg.ErrorTokenIndex
-					}
-					lineNumber := env.Program.GetLineNumber()
-					g.Print(fmt.Sprintf("%s in line %d", errorMsg.Message, lineNumber))
-					g.Put(13)
-					p.JumpToToken(0)
-					g.Print(fmt.Sprintf("%d %s", lineNumber, p.PrettyPrint()))
-					g.Put(13)
-					return false
-				}
+				obj = Eval(g, stmt, env)
+			} else {
+				// Check the structure of block IFs while we're here
+				blockIfs, obj = checkBlockIf(stmt, blockIfs, env.Program.GetLineNumber())
+			}
+			// Handle eval error
+			if errorMsg, ok := obj.(*object.Error); ok {
+				printPrerunError(g, env, errorMsg)
+				return false
 			}
 		}
 		env.Program.Next()
 	}
+	if len(blockIfs) > 0 {
+		// Go back to the block IF that wasn't closed to report it
+		unclosed := blockIfs[len(blockIfs)-1]
+		env.Program.Jump(unclosed.lineNumber, 0)
+		env.Program.Next()
+		printPrerunError(g, env, &object.Error{Code: syntaxerror.IfWithoutEndif, Message: syntaxerror.ErrorMessage(syntaxerror.IfWithoutEndif), ErrorTokenIndex: unclosed.tokenIndex})
+		return false
+	}
 	return true
 }
 
 func evalRunStatement(g *game.Game, stmt *ast.RunStatement, env *object.Environment) object.Object {
 	// Prerun stored program and return if prerun failed
+	env.ErrorSignal = false
 	if !prerun(g, env) {
+		env.ErrorSignal = true
 		return nil
 	}
 	// Otherwise execute stored program
-	l := &lexer.Lexer{}
 	env.Prerun = false
 	env.Program.Start()
 	env.DeleteStore()
 	env.JumpStack.New()
-	env.EndProgramSignal = false
-	env.LeaveFunctionSignal = false
+	env.ClearErrorTrap()
+	env.ClearBreakTrap()
 	// If a line number was passed, attempt to jump to it and return error if this fails
 	if stmt.Linenumber.Literal != "" {
 		val, _ := strconv.ParseFloat(stmt.Linenumber.Literal, 64)
 		lineNumber := int(val)
 		if lineNumber < 0 {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.PositiveValueRequired), ErrorTokenIndex: stmt.Linenumber.Index}
+			return &object.Error{Code: syntaxerror.PositiveValueRequired, Message: syntaxerror.ErrorMessage(syntaxerror.PositiveValueRequired), ErrorTokenIndex: stmt.Linenumber.Index}
 		}
 		if !env.Program.Jump(lineNumber, 0) {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.LineNumberDoesNotExist), ErrorTokenIndex: stmt.Linenumber.Index}
+			return &object.Error{Code: syntaxerror.LineNumberDoesNotExist, Message: syntaxerror.ErrorMessage(syntaxerror.LineNumberDoesNotExist), ErrorTokenIndex: stmt.Linenumber.Index}
 		} else {
 			env.Program.Next()
 		}
 	}
 	// And away we go
-	for !env.Program.EndOfProgram() && !g.BreakInterruptDetected && !env.EndProgramSignal {
-		l.Scan(env.Program.GetLine())
-		p := parser.New(l, g)
-		line := p.ParseLine()
-		//log.Printf("%s", env.Program.GetLine())
-		// Check of parser errors here.  Parser errors are handled just like evaluation errors but
-		// obviously we'll skip evaluation if parsing already failed.
-		if errorMsg, hasError := p.GetError(); hasError {
-			lineNumber := env.Program.GetLineNumber()
-			g.Print(fmt.Sprintf("%s in line %d", errorMsg, lineNumber))
-			g.Put(13)
-			p.JumpToToken(0)
-			g.Print(fmt.Sprintf("%d %s", lineNumber, p.PrettyPrint()))
+	return runProgram(g, env)
+}
+
+// printPaged prints lines in the current text box.  Each time the text box fills up it waits
+// for a key to be pressed before carrying on, or stops if <BREAK> is pressed instead.  If the
+// keyboard input runs out, e.g. when running from the command line, the rest is printed
+// without waiting.
+func printPaged(g *game.Game, lines []string) {
+	_, _, row1, _, row2 := g.AskWriting()
+	// Leave a row free for the prompt
+	pageLength := row2 - row1
+	if pageLength < 1 {
+		pageLength = 1
+	}
+	paging := !inputEnded(g)
+	for i, line := range lines {
+		if paging && i > 0 && i%pageLength == 0 {
+			g.Print("Press any key for more")
+			key := g.Get()
+			for key < 0 && !g.AskBreak() && !inputEnded(g) {
+				time.Sleep(100 * time.Millisecond)
+				key = g.Get()
+			}
 			g.Put(13)
-			return nil
+			if g.AskBreak() {
+				return
+			}
+			paging = key >= 0
 		}
-		// And this is temporary while we're still migrating from Monkey to RM Basic
-		if len(p.Errors()) > 0 {
-			g.Print("Oops! Some random parsing error occurred. These will be handled properly downstream by for now here's some spewage:")
-			g.Put(13)
-			p.JumpToToken(0)
-			g.Print(p.PrettyPrint())
-			g.Put(13)
-			for _, msg := range p.Errors() {
-				g.Print(msg)
+		g.Print(line)
+		g.Put(13)
+	}
+}
+
+// inputEnded returns true if the console's keyboard input has run out, so waiting for a key
+// would never end
+func inputEnded(g *game.Game) bool {
+	if c, ok := g.Console.(console.InputEnder); ok {
+		return c.InputEnded()
+	}
+	return false
+}
+
+// describeVariable returns a line of the LVAR listing for v
+func describeVariable(v object.Variable) string {
+	var typeName, value string
+	switch val := v.Value.(type) {
+	case *object.Numeric:
+		typeName = "Numeric"
+		value = fmt.Sprintf("%g", val.Value)
+	case *object.Integer:
+		typeName = "Integer"
+		value = fmt.Sprintf("%d", val.Value)
+	case *object.String:
+		typeName = "String"
+		value = fmt.Sprintf("\"%s\"", val.Value)
+	case *object.Array:
+		typeName = "Array"
+		s := make([]string, len(val.Subscripts))
+		for i, subscript := range val.Subscripts {
+			s[i] = strconv.Itoa(subscript)
+		}
+		value = fmt.Sprintf("(%s)", strings.Join(s, ", "))
+	default:
+		typeName = string(v.Value.Type())
+		value = v.Value.Inspect()
+	}
+	scope := fmt.Sprintf("Scope %d", v.Scope)
+	if v.Global {
+		scope = "GLOBAL"
+	}
+	return fmt.Sprintf("%-15s %-8s %-8s %s", v.Name, typeName, scope, value)
+}
+
+func evalLvarStatement(g *game.Game, stmt *ast.LvarStatement, env *object.Environment) object.Object {
+	lines := []string{}
+	for _, v := range env.Variables() {
+		lines = append(lines, describeVariable(v))
+	}
+	printPaged(g, lines)
+	return nil
+}
+
+// identifierList returns the names of the identifiers separated by commas, with () after
+// array references
+func identifierList(identifiers []*ast.Identifier) string {
+	names := make([]string, len(identifiers))
+	for i, identifier := range identifiers {
+		names[i] = identifier.Value
+		if identifier.IsArrayReference {
+			names[i] += "()"
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// procedureSignature returns the first line of a procedure definition, e.g. PROCEDURE Show A, B
+func procedureSignature(proc *ast.ProcedureDeclaration) string {
+	text := "PROCEDURE " + proc.Name.Value
+	if len(proc.ReceiveArgs) > 0 {
+		text += " " + identifierList(proc.ReceiveArgs)
+	}
+	if len(proc.ReturnArgs) > 0 {
+		text += " RETURN " + identifierList(proc.ReturnArgs)
+	}
+	return text
+}
+
+// functionSignature returns the first line of a function definition, e.g. FUNCTION Sq(N)
+func functionSignature(fun *ast.FunctionDeclaration) string {
+	return fmt.Sprintf("FUNCTION %s(%s)", fun.Name.Value, identifierList(fun.ReceiveArgs))
+}
+
+func evalProcsStatement(g *game.Game, stmt *ast.ProcsStatement, env *object.Environment) object.Object {
+	type definition struct {
+		lineNumber int
+		text       string
+	}
+	definitions := []definition{}
+	for _, proc := range env.Procedures() {
+		definitions = append(definitions, definition{proc.LineNumber, procedureSignature(proc)})
+	}
+	for _, fun := range env.Functions() {
+		definitions = append(definitions, definition{fun.LineNumber, functionSignature(fun)})
+	}
+	for _, sub := range env.Subroutines() {
+		definitions = append(definitions, definition{sub.LineNumber, "SUBROUTINE " + sub.Name.Value})
+	}
+	sort.SliceStable(definitions, func(i, j int) bool {
+		return definitions[i].lineNumber < definitions[j].lineNumber
+	})
+	lines := make([]string, len(definitions))
+	for i, d := range definitions {
+		lines[i] = fmt.Sprintf("%d %s", d.lineNumber, d.text)
+	}
+	printPaged(g, lines)
+	return nil
+}
+
+func evalContinueStatement(g *game.Game, stmt *ast.ContinueStatement, env *object.Environment) object.Object {
+	lineNumber, statementNumber, ok := env.ContinuePoint()
+	if !ok || !env.Program.Jump(lineNumber, statementNumber) {
+		return &object.Error{Code: syntaxerror.CannotContinue, Message: syntaxerror.ErrorMessage(syntaxerror.CannotContinue), ErrorTokenIndex: stmt.Token.Index}
+	}
+	env.Program.Next()
+	env.ErrorSignal = false
+	// If the program was stopped inside procedures, the continue point is the call that
+	// was made from here and the procedures are finished as the calls are evaluated again
+	env.ResumeSuspendedCalls(true)
+	defer env.ResumeSuspendedCalls(false)
+	return runProgram(g, env)
+}
+
+func evalStopStatement(g *game.Game, stmt *ast.StopStatement, env *object.Environment) object.Object {
+	lineNumber := env.Program.GetLineNumber()
+	g.Print(fmt.Sprintf("STOP in line %d", lineNumber))
+	g.Put(13)
+	// CONTINUE carries on from the next statement
+	env.SetContinuePoint(lineNumber, env.Program.CurrentStatementNumber+1)
+	env.EndProgram()
+	return nil
+}
+
+// setBreakContinuePoint records where CONTINUE carries on from after <BREAK> interrupted the
+// current line, unless a procedure called from the line has already recorded it
+func setBreakContinuePoint(env *object.Environment) {
+	if _, _, ok := env.ContinuePoint(); ok {
+		return
+	}
+	// CONTINUE starts from wherever the line was about to go next
+	if env.Program.Jumped() {
+		env.Program.Next()
+		env.SetContinuePoint(env.Program.GetLineNumber(), env.Program.JumpToStatement)
+	} else {
+		env.SetContinuePoint(env.Program.GetLineNumber(), env.Program.CurrentStatementNumber+1)
+	}
+}
+
+// runProgram executes the stored program from the current position until it ends, an error
+// occurs or it's stopped by STOP or <BREAK>.  If the program is stopped, the position it
+// stopped at is kept so that it can be continued.
+func runProgram(g *game.Game, env *object.Environment) object.Object {
+	env.ClearContinuePoint()
+	env.EndProgramSignal = false
+	env.LeaveFunctionSignal = false
+	interruptedLine := 0
+	for !env.Program.EndOfProgram() && !env.EndProgramSignal {
+		if g.AskBreak() {
+			// Interrupted between lines so CONTINUE starts this line
+			interruptedLine = env.Program.GetLineNumber()
+			env.SetContinuePoint(interruptedLine, env.Program.JumpToStatement)
+			break
+		}
+		line, p := parseProgramLine(g, env)
+		// Only a freshly parsed line can have parsing errors
+		if p != nil {
+			if errObj := lineParseError(p); errObj != nil && trapError(g, env, errObj) {
+				env.Program.Next()
+				continue
+			}
+			// Check of parser errors here.  Parser errors are handled just like evaluation errors but
+			// obviously we'll skip evaluation if parsing already failed.
+			if errorMsg, hasError := p.GetError(); hasError {
+				lineNumber := env.Program.GetLineNumber()
+				g.Print(fmt.Sprintf("%s in line %d", errorMsg, lineNumber))
+				g.Put(13)
+				p.JumpToToken(0)
+				g.Print(fmt.Sprintf("%d %s", lineNumber, p.PrettyPrint()))
 				g.Put(13)
+				env.ErrorSignal = true
+				return nil
+			}
+			// And this is temporary while we're still migrating from Monkey to RM Basic
+			if len(p.Errors()) > 0 {
+				g.Print("Oops! Some random parsing error occurred. These will be handled properly downstream by for now here's some spewage:")
+				g.Put(13)
+				p.JumpToToken(0)
+				g.Print(p.PrettyPrint())
+				g.Put(13)
+				for _, msg := range p.Errors() {
+					g.Print(msg)
+					g.Put(13)
+				}
+				env.ErrorSignal = true
+				return nil
 			}
-			return nil
 		}
+		traceLine(g, env)
 		// Execute each statement in the program line.  If an error occurs, print the
 		// error message and stop.  If JumpToStatement is non-zero, all statements in
 		// the line will be skipped until i == JumpToStatement.
 		for statementNumber, stmt := range line.Statements {
+			if statementNumber < env.Program.JumpToStatement {
+				continue
+			}
 			env.Program.CurrentStatementNumber = statementNumber
 			obj := Eval(g, stmt, env)
-			if errorMsg, ok := obj.(*object.Error); ok {
+			// Errors inside a procedure or function have already been reported
+			if env.ErrorSignal {
+				return nil
+			}
+			if unwinding := env.UnwindingError(); unwinding != nil {
+				obj = unwinding
+			}
+			if errorMsg, ok := obj.(*object.Error); ok && !trapError(g, env, errorMsg) {
+				p := programLineParser(g, env)
 				if errorMsg.ErrorTokenIndex != 0 {
 					p.ErrorTokenIndex = errorMsg.ErrorTokenIndex
 				}
@@ -3534,17 +4381,27 @@ func evalRunStatement(g *game.Game, stmt *ast.RunStatement, env *object.Environm
 				p.JumpToToken(0)
 				g.Print(fmt.Sprintf("%d %s", lineNumber, p.PrettyPrint()))
 				g.Put(13)
+				env.ErrorSignal = true
 				return nil
 			}
-			if g.BreakInterruptDetected {
+			if g.AskBreak() {
+				trapBreak(g, env)
+			}
+			if env.Program.Jumped() || g.AskBreak() || env.EndProgramSignal {
 				break
 			}
 		}
+		if g.AskBreak() {
+			interruptedLine = env.Program.GetLineNumber()
+			setBreakContinuePoint(env)
+			break
+		}
 		env.Program.Next()
 	}
-	if g.BreakInterruptDetected {
-		g.Print(fmt.Sprintf("%s in line %d", syntaxerror.ErrorMessage(syntaxerror.InterruptedByBreakKey), env.Program.GetLineNumber()))
+	if g.AskBreak() {
+		g.Print(fmt.Sprintf("%s in line %d", syntaxerror.ErrorMessage(syntaxerror.InterruptedByBreakKey), interruptedLine))
 		g.Put(13)
+		env.ErrorSignal = true
 		time.Sleep(150 * time.Millisecond)
 	}
 	return nil
@@ -3561,7 +4418,7 @@ func evalClsStatement(g *game.Game, stmt *ast.ClsStatement, env *object.Environm
 		if val, ok := obj.(*object.Numeric); ok {
 			TextBoxSlot = int(val.Value)
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	}
 
@@ -3607,12 +4464,12 @@ func evalDirStatement(g *game.Game, stmt *ast.DirStatement, env *object.Environm
 	if stmt.Channel != nil {
 		if val, ok := Eval(g, stmt.Channel, env).(*object.Numeric); ok {
 			if val.Value < 11 || val.Value > 127 {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.WrongChannelNumberUsed), ErrorTokenIndex: stmt.Token.Index}
+				return &object.Error{Code: syntaxerror.WrongChannelNumberUsed, Message: syntaxerror.ErrorMessage(syntaxerror.WrongChannelNumberUsed), ErrorTokenIndex: stmt.Token.Index}
 			} else {
 				channel = int(val.Value)
 			}
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index}
 		}
 	}
 	// Evaluate and handle TextBoxSlot if set
@@ -3624,7 +4481,7 @@ func evalDirStatement(g *game.Game, stmt *ast.DirStatement, env *object.Environm
 		if val, ok := obj.(*object.Numeric); ok {
 			tempTextBoxSlot = int(val.Value)
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	}
 	if channel == 0 {
@@ -3649,7 +4506,7 @@ func evalDirStatement(g *game.Game, stmt *ast.DirStatement, env *object.Environm
 				g.SetWriting(oldTextBoxSlot)
 				g.SetCurpos(1, curY)
 			}
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.StringExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.StringExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.StringExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	}
 	// add *.BAS if no extension given
@@ -3668,7 +4525,7 @@ func evalDirStatement(g *game.Game, stmt *ast.DirStatement, env *object.Environm
 			g.SetWriting(oldTextBoxSlot)
 			g.SetCurpos(1, curY)
 		}
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.DirectoryCannotBeFound), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.DirectoryCannotBeFound, Message: syntaxerror.ErrorMessage(syntaxerror.DirectoryCannotBeFound), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	if channel == 0 {
 		g.Print(fmt.Sprintf("Directory of %s", nimbusPath))
@@ -3701,7 +4558,7 @@ func evalDirStatement(g *game.Game, stmt *ast.DirStatement, env *object.Environm
 			g.SetWriting(oldTextBoxSlot)
 			g.SetCurpos(1, curY)
 		}
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.FileOperationFailure), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.FileOperationFailure, Message: syntaxerror.ErrorMessage(syntaxerror.FileOperationFailure), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	for _, d := range dirs {
 		if d.IsDir() {
@@ -3731,7 +4588,7 @@ func evalDirStatement(g *game.Game, stmt *ast.DirStatement, env *object.Environm
 				g.SetWriting(oldTextBoxSlot)
 				g.SetCurpos(1, curY)
 			}
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.FileOperationFailure), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.FileOperationFailure, Message: syntaxerror.ErrorMessage(syntaxerror.FileOperationFailure), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 		fileSize := fileInfo.Size()
 		fileName := filepath.Base(f)
@@ -3780,7 +4637,7 @@ func evalChdirStatement(g *game.Game, stmt *ast.ChdirStatement, env *object.Envi
 		if stringVal, ok := obj.(*object.String); ok {
 			val = stringVal.Value
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.StringExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.StringExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.StringExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	}
 	// execute
@@ -3791,7 +4648,7 @@ func evalChdirStatement(g *game.Game, stmt *ast.ChdirStatement, env *object.Envi
 	}
 	err := os.Chdir(systemPath)
 	if err != nil {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.DirectoryCannotBeFound), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.DirectoryCannotBeFound, Message: syntaxerror.ErrorMessage(syntaxerror.DirectoryCannotBeFound), ErrorTokenIndex: stmt.Token.Index + 1}
 	} else {
 		return nil
 	}
@@ -3808,7 +4665,7 @@ func evalMkdirStatement(g *game.Game, stmt *ast.MkdirStatement, env *object.Envi
 		if stringVal, ok := obj.(*object.String); ok {
 			val = stringVal.Value
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.StringExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.StringExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.StringExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	}
 	// execute
@@ -3819,7 +4676,7 @@ func evalMkdirStatement(g *game.Game, stmt *ast.MkdirStatement, env *object.Envi
 	}
 	err := os.Mkdir(systemPath, 0755)
 	if err != nil {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.UnableToCreateDirectory), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.UnableToCreateDirectory, Message: syntaxerror.ErrorMessage(syntaxerror.UnableToCreateDirectory), ErrorTokenIndex: stmt.Token.Index + 1}
 	} else {
 		return nil
 	}
@@ -3836,7 +4693,7 @@ func evalRmdirStatement(g *game.Game, stmt *ast.RmdirStatement, env *object.Envi
 		if stringVal, ok := obj.(*object.String); ok {
 			val = stringVal.Value
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.StringExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.StringExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.StringExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	}
 	// execute
@@ -3848,14 +4705,14 @@ func evalRmdirStatement(g *game.Game, stmt *ast.RmdirStatement, env *object.Envi
 	// ensure systemPath is a directory and not a file
 	fileInfo, err := os.Stat(systemPath)
 	if err != nil {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.FileOperationFailure), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.FileOperationFailure, Message: syntaxerror.ErrorMessage(syntaxerror.FileOperationFailure), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	if !fileInfo.IsDir() {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.DirectoryCannotBeFound), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.DirectoryCannotBeFound, Message: syntaxerror.ErrorMessage(syntaxerror.DirectoryCannotBeFound), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	err = os.Remove(systemPath)
 	if err != nil {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.UnableToRemoveDirectory), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.UnableToRemoveDirectory, Message: syntaxerror.ErrorMessage(syntaxerror.UnableToRemoveDirectory), ErrorTokenIndex: stmt.Token.Index + 1}
 	} else {
 		return nil
 	}
@@ -3872,12 +4729,12 @@ func evalEraseStatement(g *game.Game, stmt *ast.EraseStatement, env *object.Envi
 		if stringVal, ok := obj.(*object.String); ok {
 			val = stringVal.Value
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.StringExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.StringExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.StringExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	}
 	// Don't allow * or ?
 	if strings.Contains(val, "*") || strings.Contains(val, "?") {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.ExactFilenameIsNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.ExactFilenameIsNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.ExactFilenameIsNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Add .BAS if necessary
 	if !strings.HasSuffix(strings.ToUpper(val), ".BAS") {
@@ -3892,14 +4749,14 @@ func evalEraseStatement(g *game.Game, stmt *ast.EraseStatement, env *object.Envi
 	// ensure systemPath is a file and not a directory
 	fileInfo, err := os.Stat(systemPath)
 	if err != nil {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.UnableToEraseTheFile), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.UnableToEraseTheFile, Message: syntaxerror.ErrorMessage(syntaxerror.UnableToEraseTheFile), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	if fileInfo.IsDir() {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.FilenameIsADirectory), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.FilenameIsADirectory, Message: syntaxerror.ErrorMessage(syntaxerror.FilenameIsADirectory), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	err = os.Remove(systemPath)
 	if err != nil {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.UnableToEraseTheFile), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.UnableToEraseTheFile, Message: syntaxerror.ErrorMessage(syntaxerror.UnableToEraseTheFile), ErrorTokenIndex: stmt.Token.Index + 1}
 	} else {
 		return nil
 	}
@@ -3916,12 +4773,12 @@ func evalRenameStatement(g *game.Game, stmt *ast.RenameStatement, env *object.En
 		if stringVal, ok := obj.(*object.String); ok {
 			val1 = stringVal.Value
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.StringExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.StringExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.StringExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	}
 	// Don't allow * or ?
 	if strings.Contains(val1, "*") || strings.Contains(val1, "?") {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.ExactFilenameIsNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.ExactFilenameIsNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.ExactFilenameIsNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Add .BAS if necessary
 	if !strings.HasSuffix(strings.ToUpper(val1), ".BAS") {
@@ -3930,10 +4787,10 @@ func evalRenameStatement(g *game.Game, stmt *ast.RenameStatement, env *object.En
 	// ensure val1 is a file and not a directory
 	fileInfo, err := os.Stat(val1)
 	if err != nil {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.UnableToRenameTheFile), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.UnableToRenameTheFile, Message: syntaxerror.ErrorMessage(syntaxerror.UnableToRenameTheFile), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	if fileInfo.IsDir() {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.FilenameIsADirectory), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.FilenameIsADirectory, Message: syntaxerror.ErrorMessage(syntaxerror.FilenameIsADirectory), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// evaluate filename2
 	val2 := ""
@@ -3945,12 +4802,12 @@ func evalRenameStatement(g *game.Game, stmt *ast.RenameStatement, env *object.En
 		if stringVal, ok := obj.(*object.String); ok {
 			val2 = stringVal.Value
 		} else {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.StringExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+			return &object.Error{Code: syntaxerror.StringExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.StringExpressionNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 		}
 	}
 	// Don't allow * or ?
 	if strings.Contains(val2, "*") || strings.Contains(val2, "?") {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.ExactFilenameIsNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.ExactFilenameIsNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.ExactFilenameIsNeeded), ErrorTokenIndex: stmt.Token.Index + 1}
 	}
 	// Add .BAS if necessary
 	if !strings.HasSuffix(strings.ToUpper(val2), ".BAS") {
@@ -3962,7 +4819,7 @@ func evalRenameStatement(g *game.Game, stmt *ast.RenameStatement, env *object.En
 	// rename file 1 to file 2
 	err = os.Rename(systemPath1, systemPath2)
 	if err != nil {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.UnableToRenameTheFile), ErrorTokenIndex: stmt.Token.Index + 1}
+		return &object.Error{Code: syntaxerror.UnableToRenameTheFile, Message: syntaxerror.ErrorMessage(syntaxerror.UnableToRenameTheFile), ErrorTokenIndex: stmt.Token.Index + 1}
 	} else {
 		return nil
 	}
@@ -3983,29 +4840,17 @@ func evalExpressions(g *game.Game, exps []ast.Expression, env *object.Environmen
 // canObjectCastToIdentifierType checks if the object can be cast to the identifier type.  If so it
 // returns the cast value and true, otherwise it return an error object and false.
 func canObjectCastToIdentifierType(obj object.Object, identifierName string) (object.Object, bool) {
-	if identifierName[len(identifierName)-1:] == "$" {
-		// object must be string type
-		if obj.Type() != object.STRING_OBJ {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.StringExpressionNeeded), ErrorTokenIndex: 0}, false
-		} else {
-			return obj, true
-		}
-	}
-	if identifierName[len(identifierName)-1:] == "%" {
-		// object must be numeric type and cast to integer
-		if obj.Type() != object.STRING_OBJ {
-			return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: 0}, false
-		} else {
-			val := int(obj.(*object.Numeric).Value)
-			return &object.Numeric{Value: float64(val)}, true
-		}
-	}
-	// object must be numeric but not casting to integer
-	if obj.Type() != object.NUMERIC_OBJ {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: 0}, false
-	} else {
-		return obj, true
+	obj = object.CastToVariableType(identifierName, obj)
+	return obj, !isError(obj)
+}
+
+// promoteInteger returns the value of an integer variable as a Numeric so that it can be
+// used in an expression
+func promoteInteger(obj object.Object) object.Object {
+	if i, ok := obj.(*object.Integer); ok {
+		return &object.Numeric{Value: float64(i.Value)}
 	}
+	return obj
 }
 
 // execute runs the function or procedure code and returns the return vals
@@ -4013,47 +4858,77 @@ func executeFunction(g *game.Game, env *object.Environment, startLine int, state
 	// jump to position and execute
 	env.Program.Jump(startLine, statementNumber)
 	env.Program.Next()
-	l := &lexer.Lexer{}
 	env.Prerun = false
-	for !env.Program.EndOfProgram() && !g.BreakInterruptDetected && !env.LeaveFunctionSignal && !env.EndProgramSignal {
-		l.Scan(env.Program.GetLine())
-		p := parser.New(l, g)
-		line := p.ParseLine()
-		// Check of parser errors here.  Parser errors are handled just like evaluation errors but
-		// obviously we'll skip evaluation if parsing already failed.
-		if errorMsg, hasError := p.GetError(); hasError {
-			lineNumber := env.Program.GetLineNumber()
-			g.Print(fmt.Sprintf("%s in line %d", errorMsg, lineNumber))
-			g.Put(13)
-			p.JumpToToken(0)
-			g.Print(fmt.Sprintf("%d %s", lineNumber, p.PrettyPrint()))
-			g.Put(13)
-			return nil
+	for !env.Program.EndOfProgram() && !env.LeaveFunctionSignal && !env.EndProgramSignal {
+		if g.AskBreak() {
+			if !skipFirstStatement {
+				// Interrupted between lines so CONTINUE starts this line
+				env.SetContinuePoint(env.Program.GetLineNumber(), env.Program.JumpToStatement)
+			}
+			break
 		}
-		// And this is temporary while we're still migrating from Monkey to RM Basic
-		if len(p.Errors()) > 0 {
-			g.Print("Oops! Some random parsing error occurred. These will be handled properly downstream by for now here's some spewage:")
-			g.Put(13)
-			p.JumpToToken(0)
-			g.Print(p.PrettyPrint())
-			g.Put(13)
-			for _, msg := range p.Errors() {
-				g.Print(msg)
+		line, p := parseProgramLine(g, env)
+		// Only a freshly parsed line can have parsing errors
+		if p != nil {
+			errObj := lineParseError(p)
+			if errObj != nil && trapError(g, env, errObj) {
+				if unwinding := env.UnwindingError(); unwinding != nil {
+					return []object.Object{unwinding}
+				}
+				env.Program.Next()
+				continue
+			}
+			// Check of parser errors here.  Parser errors are handled just like evaluation errors but
+			// obviously we'll skip evaluation if parsing already failed.
+			if errorMsg, hasError := p.GetError(); hasError {
+				lineNumber := env.Program.GetLineNumber()
+				g.Print(fmt.Sprintf("%s in line %d", errorMsg, lineNumber))
+				g.Put(13)
+				p.JumpToToken(0)
+				g.Print(fmt.Sprintf("%d %s", lineNumber, p.PrettyPrint()))
 				g.Put(13)
+				printCallFrames(g, env)
+				env.ErrorSignal = true
+				return []object.Object{errObj}
+			}
+			// And this is temporary while we're still migrating from Monkey to RM Basic
+			if len(p.Errors()) > 0 {
+				g.Print("Oops! Some random parsing error occurred. These will be handled properly downstream by for now here's some spewage:")
+				g.Put(13)
+				p.JumpToToken(0)
+				g.Print(p.PrettyPrint())
+				g.Put(13)
+				for _, msg := range p.Errors() {
+					g.Print(msg)
+					g.Put(13)
+				}
+				env.ErrorSignal = true
+				return []object.Object{errObj}
 			}
-			return nil
 		}
+		traceLine(g, env)
 		// Execute each statement in the program line.  If an error occurs, print the
 		// error message and stop.  If JumpToStatement is non-zero, all statements in
 		// the line will be skipped until i == JumpToStatement.
 		for statementNumber, stmt := range line.Statements {
+			if statementNumber < env.Program.JumpToStatement {
+				continue
+			}
 			if skipFirstStatement {
 				skipFirstStatement = false
 				continue
 			}
 			env.Program.CurrentStatementNumber = statementNumber
 			obj := Eval(g, stmt, env)
-			if errorMsg, ok := obj.(*object.Error); ok {
+			// Errors in nested calls have already been reported so just pass them back
+			if env.ErrorSignal {
+				return []object.Object{obj}
+			}
+			if unwinding := env.UnwindingError(); unwinding != nil {
+				obj = unwinding
+			}
+			if errorMsg, ok := obj.(*object.Error); ok && !trapError(g, env, errorMsg) {
+				p := programLineParser(g, env)
 				if errorMsg.ErrorTokenIndex != 0 {
 					p.ErrorTokenIndex = errorMsg.ErrorTokenIndex
 				}
@@ -4063,53 +4938,234 @@ func executeFunction(g *game.Game, env *object.Environment, startLine int, state
 				p.JumpToToken(0)
 				g.Print(fmt.Sprintf("%d %s", lineNumber, p.PrettyPrint()))
 				g.Put(13)
-				return nil
+				printCallFrames(g, env)
+				env.ErrorSignal = true
+				return []object.Object{errorMsg}
+			}
+			if unwinding := env.UnwindingError(); unwinding != nil {
+				// Leave this call so the caller that set ON ERROR can handle the error
+				return []object.Object{unwinding}
+			}
+			if g.AskBreak() {
+				trapBreak(g, env)
 			}
-			if g.BreakInterruptDetected {
+			// RESULT, LEAVE and ENDPROC leave the call without running the rest of the line
+			if env.Program.Jumped() || g.AskBreak() || env.EndProgramSignal || env.LeaveFunctionSignal {
 				break
 			}
 		}
+		if g.AskBreak() && !env.LeaveFunctionSignal && !env.EndProgramSignal {
+			setBreakContinuePoint(env)
+			break
+		}
 		env.Program.Next()
 	}
 	return env.ReturnVals
 }
 
 func evalProcedureCallStatement(g *game.Game, stmt *ast.ProcedureCallStatement, env *object.Environment) object.Object {
-	if proc, ok := env.GetProcedure(stmt.Name.Value); ok {
-		args := make([]object.Object, len(stmt.Args))
-		for i := 0; i < len(stmt.Args); i++ {
-			args[i] = Eval(g, stmt.Args[i], env)
-			if isError(args[i]) {
-				return args[i]
-			}
-		}
-		newEnv := object.NewEnvironment(env.GlobalEnv)
-		newEnv.Copy(env.Dump())
-		newEnv.NewScope()
-		for i := 0; i < len(proc.ReceiveArgs); i++ {
-			obj := newEnv.Set(proc.ReceiveArgs[i].Value, args[i])
-			if isError(obj) {
-				return obj
-			}
+	proc, ok := env.GetProcedure(stmt.Name.Value)
+	if !ok {
+		return &object.Error{Code: syntaxerror.UnknownCommandProcedure, Message: syntaxerror.ErrorMessage(syntaxerror.UnknownCommandProcedure), ErrorTokenIndex: stmt.Token.Index}
+	}
+	var retVal object.Object
+	var newEnv *object.Environment
+	if call, ok := env.ResumeCall(); ok {
+		// CONTINUE is getting back into a procedure that was stopped
+		newEnv = call.Callee
+		newEnv.EndProgramSignal = false
+		retVal = runCall(g, env, newEnv, stmt.Name.Value, call.LineNumber, call.StatementNumber, false)
+	} else {
+		args, errObj := evalArguments(g, env, stmt.Name, proc.ReceiveArgs, stmt.Args)
+		if errObj != nil {
+			return errObj
 		}
-		retVal := executeFunction(g, newEnv, proc.LineNumber, proc.StatementNumber, true)[0]
-		if newEnv.EndProgramSignal {
-			env.EndProgram()
+		if errObj := checkReceiveArgs(stmt, proc); errObj != nil {
+			return errObj
 		}
-		if isError(retVal) {
-			return retVal
+		retVal, newEnv = callDefinition(g, env, stmt.Name, proc.LineNumber, proc.StatementNumber, proc.ReceiveArgs, args)
+	}
+	if isError(retVal) {
+		return retVal
+	}
+	if _, _, stopped := env.ContinuePoint(); stopped {
+		// The procedure is finished when the program is continued, which can only be done
+		// if the call is a statement of its own rather than part of one such as IF
+		if line, ok := env.Program.GetParsedLine(); ok && env.Program.CurrentStatementNumber < len(line.Statements) && line.Statements[env.Program.CurrentStatementNumber] == stmt {
+			env.SuspendCall(newEnv)
+		} else {
+			env.ClearContinuePoint()
 		}
-		for i := 0; i < len(stmt.ReceiveArgs); i++ {
-			val, _ := newEnv.Get(proc.ReturnArgs[i].Value)
-			obj := env.Set(stmt.ReceiveArgs[i].Value, val)
-			if isError(obj) {
-				return obj
+		return nil
+	}
+	for i := 0; i < len(stmt.ReceiveArgs); i++ {
+		var obj object.Object
+		if proc.ReturnArgs[i].IsArrayReference {
+			arr, ok := newEnv.GetArrayReference(proc.ReturnArgs[i].Value)
+			if !ok {
+				return &object.Error{Code: syntaxerror.FunctionArrayNotFound, Message: syntaxerror.ErrorMessage(syntaxerror.FunctionArrayNotFound), ErrorTokenIndex: stmt.ReceiveArgs[i].Token.Index}
 			}
+			obj = env.SetArrayReference(stmt.ReceiveArgs[i].Value, arr)
+		} else {
+			val, ok := newEnv.Get(proc.ReturnArgs[i].Value)
+			if !ok {
+				// A RETURN parameter the procedure never set is empty, like any unset variable
+				val = &object.Numeric{Value: 0}
+				if strings.HasSuffix(proc.ReturnArgs[i].Value, "$") {
+					val = &object.String{Value: ""}
+				}
+			}
+			obj = env.Set(stmt.ReceiveArgs[i].Value, val)
+		}
+		if isError(obj) {
+			return obj
 		}
+	}
+	return nil
+}
+
+// callDefinition runs the procedure or function defined at lineNumber and statementNumber
+// with its parameters set to args.  It returns the result and the environment the call ran
+// in, which is nil if the call couldn't be made.  Each call gets its own environment so
+// recursive calls don't share local variables, and a call frame is kept for each call that
+// hasn't finished so the nesting can be limited and errors can show where calls were made.
+func callDefinition(g *game.Game, env *object.Environment, name *ast.Identifier, lineNumber, statementNumber int, params []*ast.Identifier, args []object.Object) (object.Object, *object.Environment) {
+	if len(env.CallFrames()) >= maxCallDepth(g) {
+		return &object.Error{Code: syntaxerror.FunctionNestingTooDeep, Message: syntaxerror.ErrorMessage(syntaxerror.FunctionNestingTooDeep), ErrorTokenIndex: name.Token.Index}, nil
+	}
+	newEnv := object.NewEnvironment(env.GlobalEnv)
+	newEnv.Copy(env.Dump())
+	newEnv.NewScope()
+	if obj := bindArguments(newEnv, params, args); obj != nil {
+		return obj, nil
+	}
+	return runCall(g, env, newEnv, name.Value, lineNumber, statementNumber, true), newEnv
+}
+
+// runCall runs the procedure or function called name in newEnv from lineNumber and
+// statementNumber, with a call frame for the call made from the current statement of env
+func runCall(g *game.Game, env *object.Environment, newEnv *object.Environment, name string, lineNumber, statementNumber int, skipFirstStatement bool) object.Object {
+	env.PushCallFrame(object.CallFrame{Name: name, LineNumber: env.Program.GetLineNumber()})
+	defer env.PopCallFrame()
+	retVals := executeFunction(g, newEnv, lineNumber, statementNumber, skipFirstStatement)
+	if newEnv.EndProgramSignal {
+		env.EndProgram()
+	}
+	if newEnv.ErrorSignal {
+		env.ErrorSignal = true
+	}
+	if len(retVals) == 0 {
+		// Ran off the end of the program
 		return nil
-	} else {
-		return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.UnknownCommandProcedure), ErrorTokenIndex: stmt.Token.Index}
 	}
+	return retVals[0]
+}
+
+// maxCallDepth returns the number of procedure and function calls that can be nested
+func maxCallDepth(g *game.Game) int {
+	if g.Config.MaxCallDepth > game.MaxCallDepthLimit {
+		return game.MaxCallDepthLimit
+	}
+	if g.Config.MaxCallDepth > 0 {
+		return g.Config.MaxCallDepth
+	}
+	return game.DefaultMaxCallDepth
+}
+
+// maxCallFramesShown is the number of calls printCallFrames lists before summing up the rest
+const maxCallFramesShown = 5
+
+// printCallFrames prints where the unfinished procedure and function calls were made from,
+// most recent first, after an error has been reported inside a call
+func printCallFrames(g *game.Game, env *object.Environment) {
+	frames := env.CallFrames()
+	for i := len(frames) - 1; i >= 0; i-- {
+		if len(frames)-i > maxCallFramesShown {
+			g.Print(fmt.Sprintf("... and %d more calls", i+1))
+			g.Put(13)
+			return
+		}
+		g.Print(fmt.Sprintf("%s called from line %d", frames[i].Name, frames[i].LineNumber))
+		g.Put(13)
+	}
+}
+
+// evalArguments evaluates the arguments passed to a procedure or function.  The argument for
+// an array parameter must be an array reference, e.g. A(), and is passed as the whole array.
+func evalArguments(g *game.Game, env *object.Environment, name *ast.Identifier, params []*ast.Identifier, argExprs []ast.Expression) ([]object.Object, object.Object) {
+	if len(argExprs) < len(params) {
+		return nil, &object.Error{Code: syntaxerror.NotEnoughParametersFor, Message: syntaxerror.ErrorMessage(syntaxerror.NotEnoughParametersFor) + name.Value, ErrorTokenIndex: name.Token.Index}
+	}
+	if len(argExprs) > len(params) {
+		return nil, &object.Error{Code: syntaxerror.TooManyParametersFor, Message: syntaxerror.ErrorMessage(syntaxerror.TooManyParametersFor) + name.Value, ErrorTokenIndex: name.Token.Index}
+	}
+	args := make([]object.Object, len(argExprs))
+	for i, argExpr := range argExprs {
+		ref, isRef := argExpr.(*ast.Identifier)
+		isRef = isRef && ref.IsArrayReference
+		if params[i].IsArrayReference {
+			if !isRef {
+				return nil, &object.Error{Code: syntaxerror.ArrayNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.ArrayNeeded), ErrorTokenIndex: name.Token.Index}
+			}
+			arr, ok := env.GetArrayReference(ref.Value)
+			if !ok {
+				return nil, &object.Error{Code: syntaxerror.FunctionArrayNotFound, Message: syntaxerror.ErrorMessage(syntaxerror.FunctionArrayNotFound), ErrorTokenIndex: ref.Token.Index}
+			}
+			args[i] = arr
+			continue
+		}
+		if isRef {
+			return nil, &object.Error{Code: expressionNeeded(params[i].Value), Message: syntaxerror.ErrorMessage(expressionNeeded(params[i].Value)), ErrorTokenIndex: ref.Token.Index}
+		}
+		args[i] = Eval(g, argExpr, env)
+		if isError(args[i]) {
+			return nil, args[i]
+		}
+	}
+	return args, nil
+}
+
+// checkReceiveArgs checks that the variables after RECEIVE in a procedure call match the
+// RETURN parameters of the procedure
+func checkReceiveArgs(stmt *ast.ProcedureCallStatement, proc *ast.ProcedureDeclaration) object.Object {
+	if len(stmt.ReceiveArgs) > len(proc.ReturnArgs) {
+		return &object.Error{Code: syntaxerror.TooManyParametersFor, Message: syntaxerror.ErrorMessage(syntaxerror.TooManyParametersFor) + stmt.Name.Value, ErrorTokenIndex: stmt.Name.Token.Index}
+	}
+	for i, receive := range stmt.ReceiveArgs {
+		if proc.ReturnArgs[i].IsArrayReference && !receive.IsArrayReference {
+			return &object.Error{Code: syntaxerror.ArrayNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.ArrayNeeded), ErrorTokenIndex: receive.Token.Index}
+		}
+		if !proc.ReturnArgs[i].IsArrayReference && receive.IsArrayReference {
+			return &object.Error{Code: expressionNeeded(receive.Value), Message: syntaxerror.ErrorMessage(expressionNeeded(receive.Value)), ErrorTokenIndex: receive.Token.Index}
+		}
+	}
+	return nil
+}
+
+// bindArguments sets each parameter in env to the value of its argument.  Arrays are
+// copied so changes made by the procedure or function aren't seen by the caller.
+func bindArguments(env *object.Environment, params []*ast.Identifier, args []object.Object) object.Object {
+	for i, param := range params {
+		var obj object.Object
+		if arr, ok := args[i].(*object.Array); ok {
+			obj = env.SetArrayReference(param.Value, arr)
+		} else {
+			obj = env.Set(param.Value, args[i])
+		}
+		if isError(obj) {
+			return obj
+		}
+	}
+	return nil
+}
+
+// expressionNeeded returns the error code for a value of the wrong kind being given for the
+// named variable
+func expressionNeeded(name string) int {
+	if strings.HasSuffix(name, "$") {
+		return syntaxerror.StringExpressionNeeded
+	}
+	return syntaxerror.NumericExpressionNeeded
 }
 
 func evalIdentifier(g *game.Game, node *ast.Identifier, env *object.Environment) object.Object {
@@ -4119,31 +5175,27 @@ func evalIdentifier(g *game.Game, node *ast.Identifier, env *object.Environment)
 	if len(node.Subscripts) > 0 || len(node.ArrayRefs) > 0 {
 		if fun, ok := env.GetFunction(node.Value); ok {
 			// Handle function
-			newEnv := object.NewEnvironment(env.GlobalEnv)
-			newEnv.Copy(env.Dump())
-			newEnv.NewScope()
-			subscripts := make([]object.Object, len(node.Subscripts))
-			for i := 0; i < len(node.Subscripts); i++ {
-				subscripts[i] = Eval(g, node.Subscripts[i], env)
-				if isError(subscripts[i]) {
-					return subscripts[i]
-				}
-			}
-			for i := 0; i < len(node.Subscripts); i++ {
-				obj := newEnv.Set(fun.ReceiveArgs[i].Value, subscripts[i])
-				if isError(obj) {
-					return obj
-				}
-			}
-			retVal := executeFunction(g, newEnv, fun.LineNumber, fun.StatementNumber, true)[0]
-			if newEnv.EndProgramSignal {
-				env.EndProgram()
+			args, errObj := evalArguments(g, env, node, fun.ReceiveArgs, node.Subscripts)
+			if errObj != nil {
+				return errObj
 			}
+			retVal, _ := callDefinition(g, env, node, fun.LineNumber, fun.StatementNumber, fun.ReceiveArgs, args)
 			if isError(retVal) {
 				return retVal
+			} else if retVal == nil {
+				if env.EndProgramSignal || g.AskBreak() {
+					// There's no getting back into the middle of an expression so a
+					// program stopped inside a function can't be continued
+					env.ClearContinuePoint()
+					return nil
+				}
+				if env.ErrorSignal {
+					return nil
+				}
+				return &object.Error{Code: syntaxerror.NeedResultToExitFunction, Message: syntaxerror.ErrorMessage(syntaxerror.NeedResultToExitFunction), ErrorTokenIndex: node.Token.Index}
 			} else {
 				obj, _ := canObjectCastToIdentifierType(retVal, fun.Name.Value)
-				return obj
+				return promoteInteger(obj)
 			}
 		} else {
 			// handle array
@@ -4153,16 +5205,16 @@ func evalIdentifier(g *game.Game, node *ast.Identifier, env *object.Environment)
 				if val, ok := obj.(*object.Numeric); ok {
 					subscripts[i] = int(val.Value)
 				} else {
-					return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: node.Token.Index}
+					return &object.Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded), ErrorTokenIndex: node.Token.Index}
 				}
 			}
 			// Error handling here?
 			val, _ := env.GetArray(node.Value, subscripts)
-			return val
+			return promoteInteger(val)
 		}
 	}
 	if val, ok := env.Get(node.Value); ok {
-		return val
+		return promoteInteger(val)
 	}
 	// Create a new variable with null value and return warning.  It's then up to the caller
 	// to print the warning and do env.Get again to get the value.
@@ -4201,6 +5253,12 @@ func evalIfStatement(g *game.Game, ie *ast.IfStatement, env *object.Environment)
 	if isError(condition) {
 		return condition
 	}
+	if ie.Block {
+		if isTruthy(condition) {
+			return nil
+		}
+		return jumpToIfBranch(g, env, ie.Token)
+	}
 	var returnObject object.Object
 	if isTruthy(condition) {
 		// Special case THEN lineNumber (empty LineString and LineNumber > 0)
@@ -4208,7 +5266,7 @@ func evalIfStatement(g *game.Game, ie *ast.IfStatement, env *object.Environment)
 			if env.Program.Jump(ie.Consequence.LineNumber, 0) {
 				return nil
 			} else {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.LineNumberDoesNotExist), ErrorTokenIndex: 0}
+				return &object.Error{Code: syntaxerror.LineNumberDoesNotExist, Message: syntaxerror.ErrorMessage(syntaxerror.LineNumberDoesNotExist), ErrorTokenIndex: 0}
 			}
 		}
 
@@ -4219,6 +5277,10 @@ func evalIfStatement(g *game.Game, ie *ast.IfStatement, env *object.Environment)
 			if isError(obj) {
 				return obj
 			}
+			// Don't carry on after a GOTO, GOSUB etc.
+			if env.Program.Jumped() {
+				break
+			}
 		}
 		return returnObject
 	} else if ie.Alternative != nil {
@@ -4227,7 +5289,7 @@ func evalIfStatement(g *game.Game, ie *ast.IfStatement, env *object.Environment)
 			if env.Program.Jump(ie.Alternative.LineNumber, 0) {
 				return nil
 			} else {
-				return &object.Error{Message: syntaxerror.ErrorMessage(syntaxerror.LineNumberDoesNotExist), ErrorTokenIndex: 0}
+				return &object.Error{Code: syntaxerror.LineNumberDoesNotExist, Message: syntaxerror.ErrorMessage(syntaxerror.LineNumberDoesNotExist), ErrorTokenIndex: 0}
 			}
 		}
 
@@ -4238,11 +5300,105 @@ func evalIfStatement(g *game.Game, ie *ast.IfStatement, env *object.Environment)
 			if isError(obj) {
 				return obj
 			}
+			// Don't carry on after a GOTO, GOSUB etc.
+			if env.Program.Jumped() {
+				break
+			}
 		}
 	}
 	return returnObject
 }
 
+func evalElseStatement(g *game.Game, stmt *ast.ElseStatement, env *object.Environment) object.Object {
+	// An ELSE IF that jumpToIfBranch has jumped to needs its condition testing
+	if stmt.Condition != nil && env.Program.ReachedElseIf() {
+		condition := Eval(g, stmt.Condition, env)
+		if isError(condition) {
+			return condition
+		}
+		if isTruthy(condition) {
+			return nil
+		}
+		return jumpToIfBranch(g, env, stmt.Token)
+	}
+	// Otherwise the branch before this one has finished so skip to the ENDIF
+	_, lineNumber, statementNumber, ok := findIfBranch(g, env, true)
+	if !ok {
+		return &object.Error{Code: syntaxerror.IfWithoutEndif, Message: syntaxerror.ErrorMessage(syntaxerror.IfWithoutEndif), ErrorTokenIndex: stmt.Token.Index}
+	}
+	env.Program.Jump(lineNumber, statementNumber+1)
+	return nil
+}
+
+// jumpToIfBranch is used when the condition of a block IF or ELSE IF is false.  It jumps to
+// the next ELSE IF to test its condition, or to the statement after the next ELSE or ENDIF.
+func jumpToIfBranch(g *game.Game, env *object.Environment, tok token.Token) object.Object {
+	branch, lineNumber, statementNumber, ok := findIfBranch(g, env, false)
+	if !ok {
+		return &object.Error{Code: syntaxerror.IfWithoutEndif, Message: syntaxerror.ErrorMessage(syntaxerror.IfWithoutEndif), ErrorTokenIndex: tok.Index}
+	}
+	if elseStmt, isElse := branch.(*ast.ElseStatement); isElse && elseStmt.Condition != nil {
+		env.Program.JumpToElseIf(lineNumber, statementNumber)
+		return nil
+	}
+	env.Program.Jump(lineNumber, statementNumber+1)
+	return nil
+}
+
+// findIfBranch looks through the program after the current statement for the ELSE, ELSE IF or
+// ENDIF that ends the current branch of a block IF, skipping over any block IFs nested inside
+// it.  If endifOnly is true then any ELSE and ELSE IF are skipped too.
+func findIfBranch(g *game.Game, env *object.Environment, endifOnly bool) (branch ast.Statement, lineNumber, statementNumber int, ok bool) {
+	depth := 0
+	ok = scanProgram(g, env, func(stmt ast.Statement, l, s int) bool {
+		switch stmt := stmt.(type) {
+		case *ast.IfStatement:
+			if stmt.Block {
+				depth++
+			}
+		case *ast.ElseStatement:
+			if depth == 0 && !endifOnly {
+				branch, lineNumber, statementNumber = stmt, l, s
+				return true
+			}
+		case *ast.EndifStatement:
+			if depth == 0 {
+				branch, lineNumber, statementNumber = stmt, l, s
+				return true
+			}
+			depth--
+		}
+		return false
+	})
+	return branch, lineNumber, statementNumber, ok
+}
+
+// scanProgram calls found with each statement of the program after the current one, along with
+// its line number and statement number, until found returns true.  It returns false if the end
+// of the program was reached first.
+func scanProgram(g *game.Game, env *object.Environment, found func(stmt ast.Statement, lineNumber, statementNumber int) bool) bool {
+	if env.Program.EndOfProgram() {
+		return false
+	}
+	fromLineNumber, fromStatementNumber := env.Program.GetLineNumber(), env.Program.CurrentStatementNumber
+	for _, lineNumber := range env.Program.LinesFromCurrent() {
+		line, ok := parseLineNumber(g, env, lineNumber)
+		if !ok {
+			// Lines that don't parse can't be part of the structure
+			continue
+		}
+		for statementNumber, stmt := range line.Statements {
+			if lineNumber == fromLineNumber && statementNumber <= fromStatementNumber {
+				continue
+			}
+			if found(stmt, lineNumber, statementNumber) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func isTruthy(obj object.Object) bool {
 	val := obj.(*object.Numeric).Value
 	// In RM Basic -1 was true, any other value was false
@@ -4266,9 +5422,9 @@ func evalInfixExpression(operator string, left, right object.Object) object.Obje
 	case left.Type() == object.BOOLEAN_OBJ && right.Type() == object.BOOLEAN_OBJ:
 		return evalBooleanInfixExpression(operator, left, right)
 	case left.Type() != right.Type():
-		return newError("%s (type mismatch: %s %s %s)", syntaxerror.ErrorMessage(syntaxerror.InvalidExpressionFound), left.Type(), operator, right.Type())
+		return &object.Error{Code: syntaxerror.InvalidExpressionFound, Message: fmt.Sprintf("%s (type mismatch: %s %s %s)", syntaxerror.ErrorMessage(syntaxerror.InvalidExpressionFound), left.Type(), operator, right.Type())}
 	default:
-		return newError("%s (unknown operator: %s %s %s)", syntaxerror.ErrorMessage(syntaxerror.InvalidExpressionFound), left.Type(), operator, right.Type())
+		return &object.Error{Code: syntaxerror.InvalidExpressionFound, Message: fmt.Sprintf("%s (unknown operator: %s %s %s)", syntaxerror.ErrorMessage(syntaxerror.InvalidExpressionFound), left.Type(), operator, right.Type())}
 	}
 }
 
@@ -4297,8 +5453,15 @@ func evalStringInfixExpression(operator string, left, right object.Object) objec
 		} else {
 			return &object.Numeric{Value: 0}
 		}
+	case operator == "<" || operator == "<=" || operator == "=<" || operator == "<>" ||
+		operator == "><" || operator == ">" || operator == ">=" || operator == "=>":
+		// ordering is by character code, so "B" < "a"
+		leftVal := left.(*object.String).Value
+		rightVal := right.(*object.String).Value
+		return evalNumericInfixExpression(operator,
+			&object.Numeric{Value: float64(strings.Compare(leftVal, rightVal))}, &object.Numeric{Value: 0})
 	default:
-		return newError("%s (unknown operator: %s %s %s)", syntaxerror.ErrorMessage(syntaxerror.InvalidExpressionFound), left.Type(), operator, right.Type())
+		return &object.Error{Code: syntaxerror.InvalidExpressionFound, Message: fmt.Sprintf("%s (unknown operator: %s %s %s)", syntaxerror.ErrorMessage(syntaxerror.InvalidExpressionFound), left.Type(), operator, right.Type())}
 	}
 
 }
@@ -4315,7 +5478,7 @@ func evalBooleanInfixExpression(operator string, left, right object.Object) obje
 	case "XOR":
 		return &object.Numeric{Value: float64(int(leftVal) ^ int(rightVal))}
 	default:
-		return newError("%s (unknown operator: %s %s %s)", syntaxerror.ErrorMessage(syntaxerror.InvalidExpressionFound), left.Type(), operator, right.Type())
+		return &object.Error{Code: syntaxerror.InvalidExpressionFound, Message: fmt.Sprintf("%s (unknown operator: %s %s %s)", syntaxerror.ErrorMessage(syntaxerror.InvalidExpressionFound), left.Type(), operator, right.Type())}
 	}
 }
 
@@ -4333,10 +5496,29 @@ func evalNumericInfixExpression(operator string, left, right object.Object) obje
 	case "/":
 		// catch divide by zero
 		if rightVal == 0 {
-			return newError(syntaxerror.ErrorMessage(syntaxerror.TryingToDivideByZero))
+			return &object.Error{Code: syntaxerror.TryingToDivideByZero, Message: syntaxerror.ErrorMessage(syntaxerror.TryingToDivideByZero)}
 		} else {
 			return &object.Numeric{Value: leftVal / rightVal}
 		}
+	case "\\":
+		// integer division discards the fractional part of the result
+		if rightVal == 0 {
+			return &object.Error{Code: syntaxerror.TryingToDivideByZero, Message: syntaxerror.ErrorMessage(syntaxerror.TryingToDivideByZero)}
+		}
+		return &object.Numeric{Value: math.Trunc(leftVal / rightVal)}
+	case "^":
+		if leftVal == 0 && rightVal < 0 {
+			return &object.Error{Code: syntaxerror.TryingToDivideByZero, Message: syntaxerror.ErrorMessage(syntaxerror.TryingToDivideByZero)}
+		}
+		result := math.Pow(leftVal, rightVal)
+		if math.IsNaN(result) {
+			// e.g. a negative number raised to a fractional power
+			return &object.Error{Code: syntaxerror.InvalidExpressionFound, Message: syntaxerror.ErrorMessage(syntaxerror.InvalidExpressionFound)}
+		}
+		if math.IsInf(result, 0) {
+			return &object.Error{Code: syntaxerror.NumberTooBig, Message: syntaxerror.ErrorMessage(syntaxerror.NumberTooBig)}
+		}
+		return &object.Numeric{Value: result}
 	case "<":
 		return nativeBoolToBooleanObject(leftVal < rightVal)
 	case "<=":
@@ -4355,21 +5537,17 @@ func evalNumericInfixExpression(operator string, left, right object.Object) obje
 		return nativeBoolToBooleanObject(leftVal >= rightVal)
 	case "=":
 		return nativeBoolToBooleanObject(leftVal == rightVal)
-	case "AND":
-		return &object.Numeric{Value: float64(int(leftVal) & int(rightVal))}
-	case "OR":
-		return &object.Numeric{Value: float64(int(leftVal) | int(rightVal))}
-	case "XOR":
-		return &object.Numeric{Value: float64(int(leftVal) ^ int(rightVal))}
+	case "AND", "OR", "XOR":
+		return evalBitwiseExpression(operator, leftVal, rightVal)
 	case "MOD":
 		// catch divide by zero
 		if rightVal == 0 {
-			return newError(syntaxerror.ErrorMessage(syntaxerror.TryingToDivideByZero))
+			return &object.Error{Code: syntaxerror.TryingToDivideByZero, Message: syntaxerror.ErrorMessage(syntaxerror.TryingToDivideByZero)}
 		} else {
 			return &object.Numeric{Value: math.Mod(leftVal, rightVal)}
 		}
 	default:
-		return newError("%s (unknown operator: %s %s %s)", syntaxerror.ErrorMessage(syntaxerror.InvalidExpressionFound), left.Type(), operator, right.Type())
+		return &object.Error{Code: syntaxerror.InvalidExpressionFound, Message: fmt.Sprintf("%s (unknown operator: %s %s %s)", syntaxerror.ErrorMessage(syntaxerror.InvalidExpressionFound), left.Type(), operator, right.Type())}
 	}
 }
 
@@ -4380,13 +5558,46 @@ func evalPrefixExpression(operator string, right object.Object) object.Object {
 	case "-":
 		return evalMinusPrefixOperatorExpression(right)
 	default:
-		return newError("%s (unknown operator: %s%s)", syntaxerror.ErrorMessage(syntaxerror.InvalidExpressionFound), operator, right.Type())
+		return &object.Error{Code: syntaxerror.InvalidExpressionFound, Message: fmt.Sprintf("%s (unknown operator: %s%s)", syntaxerror.ErrorMessage(syntaxerror.InvalidExpressionFound), operator, right.Type())}
+	}
+}
+
+// bitwiseOperand returns the 16-bit integer pattern of a value for AND, OR, XOR and NOT.  The
+// value can be signed or unsigned, so -1 and 65535 are the same pattern.
+func bitwiseOperand(val float64) (uint16, bool) {
+	val = math.Trunc(val)
+	if val < math.MinInt16 || val > math.MaxUint16 {
+		return 0, false
+	}
+	return uint16(int32(val)), true
+}
+
+// evalBitwiseExpression evaluates AND, OR or XOR on 16-bit integers.  The result is signed, so
+// TRUE AND TRUE is still TRUE.
+func evalBitwiseExpression(operator string, leftVal, rightVal float64) object.Object {
+	left, leftOk := bitwiseOperand(leftVal)
+	right, rightOk := bitwiseOperand(rightVal)
+	if !leftOk || !rightOk {
+		return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange)}
+	}
+	var result uint16
+	switch operator {
+	case "AND":
+		result = left & right
+	case "OR":
+		result = left | right
+	case "XOR":
+		result = left ^ right
 	}
+	return &object.Numeric{Value: float64(int16(result))}
 }
 
 func evalNotOperatorExpression(right object.Object) object.Object {
-	val := right.(*object.Numeric).Value
-	return &object.Numeric{Value: float64(^int(val))}
+	val, ok := bitwiseOperand(right.(*object.Numeric).Value)
+	if !ok {
+		return &object.Error{Code: syntaxerror.NumberNotAllowedInRange, Message: syntaxerror.ErrorMessage(syntaxerror.NumberNotAllowedInRange)}
+	}
+	return &object.Numeric{Value: float64(int16(^val))}
 	//switch right {
 	//case TRUE:
 	//	return FALSE
@@ -4401,7 +5612,7 @@ func evalNotOperatorExpression(right object.Object) object.Object {
 
 func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
 	if right.Type() != object.NUMERIC_OBJ {
-		return newError("%s (unknown operator: -%s)", syntaxerror.ErrorMessage(syntaxerror.InvalidExpressionFound), right.Type())
+		return &object.Error{Code: syntaxerror.InvalidExpressionFound, Message: fmt.Sprintf("%s (unknown operator: -%s)", syntaxerror.ErrorMessage(syntaxerror.InvalidExpressionFound), right.Type())}
 	}
 	value := right.(*object.Numeric).Value
 	return &object.Numeric{Value: -value}
@@ -1,13 +1,23 @@
 package evaluator
 
 import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
 	"log"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/ast"
+	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/console"
 	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/game"
+	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/game/examples"
 	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/lexer"
 	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/object"
 	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/parser"
+	"github.com/adamstimb/rmbasicx64/pkg/nimgobus/keycode"
 )
 
 func TestEvalNumericExpression(t *testing.T) {
@@ -31,6 +41,14 @@ func TestEvalNumericExpression(t *testing.T) {
 		{"3 * 3 * 3 + 10", 37},
 		{"3 * (3 * 3) + 10", 37},
 		{"(5 + 10 * 2 + 15 / 3) * 2 + -10", 50},
+		{"2 ^ 10", 1024},
+		{"2 ^ 3 ^ 2", 512},
+		{"-(2) ^ 2", -4},
+		{"2 ^ -1", 0.5},
+		{"3 * 2 ^ 2", 12},
+		{"7 \\ 2", 3},
+		{"-7 \\ 2", -3},
+		{"7.9 \\ 2 * 2", 6},
 	}
 
 	for _, tt := range tests {
@@ -40,15 +58,190 @@ func TestEvalNumericExpression(t *testing.T) {
 }
 
 func testEval(input string) object.Object {
+	g := game.New(console.NewHeadless(strings.NewReader(""), &bytes.Buffer{}))
 	l := &lexer.Lexer{}
 	l.Scan(input)
-	p := parser.New(l, &game.Game{})
+	p := parser.New(l, g)
 	program := p.ParseProgram()
 	env := object.NewEnvironment(nil)
-	g := &game.Game{}
 	return Eval(g, program, env)
 }
 
+// testRun stores the program lines, RUNs them on a headless console fed with input and
+// returns everything that was printed
+func testRun(program string, input string) string {
+	var out bytes.Buffer
+	g := game.New(console.NewHeadless(strings.NewReader(input), &out))
+	env := testStore(g, program)
+	Eval(g, &ast.RunStatement{}, env)
+	return out.String()
+}
+
+// testStore returns a new environment with the program lines stored in it
+func testStore(g *game.Game, program string) *object.Environment {
+	env := object.NewEnvironment(object.NewEnvironment(nil))
+	for _, rawLine := range strings.Split(strings.TrimSpace(program), "\n") {
+		l := &lexer.Lexer{}
+		l.Scan(strings.TrimSpace(rawLine))
+		p := parser.New(l, g)
+		line := p.ParseLine()
+		env.Program.AddLine(line.LineNumber, line.LineString)
+	}
+	return env
+}
+
+func TestRunProgram(t *testing.T) {
+	tests := []struct {
+		program  string
+		input    string
+		expected string
+	}{
+		{`10 PRINT "Hello"`, "", "Hello\n"},
+		{`10 FOR I% := 1 TO 3
+		  20 PRINT I%
+		  30 NEXT I%`, "", "1\n2\n3\n"},
+		{`10 INPUT A$
+		  20 PRINT "Hi "; A$`, "Bob\n", "Bob\nHi Bob\n"},
+		{`10 GOSUB 30
+		  20 END
+		  30 PRINT "Sub"
+		  40 RETURN`, "", "Sub\n"},
+		{`10 PRINT "A"
+		  20 GOTO 99`, "", "A\nLine number does not exist in line 20\n20 GOTO >> 99\n"},
+		{`10 GOTO 30: PRINT "Skipped"
+		  30 PRINT "Jumped"`, "", "Jumped\n"},
+		{`10 ON ERROR GOTO 50
+		  20 GOTO 99
+		  30 PRINT "Not here"
+		  50 PRINT ERL; " "; ERR$`, "", "20 Line number does not exist\n"},
+		{`10 ON ERROR GOTO 50
+		  20 PRINT "A": GOTO 99: PRINT "B"
+		  30 END
+		  50 PRINT "Trapped"
+		  60 RESUME NEXT`, "", "A\nTrapped\nB\n"},
+		{`10 ON ERROR GOSUB 50
+		  20 GOTO 99
+		  30 PRINT "Back"
+		  40 END
+		  50 PRINT "Handler"
+		  60 RETURN`, "", "Handler\nBack\n"},
+		{`10 ON ERROR GOTO 50
+		  20 ON ERROR
+		  30 GOTO 99
+		  50 PRINT "Trapped"`, "", "Line number does not exist in line 30\n"},
+		{`10 ON ERROR GOTO 50
+		  20 Show 2, 3
+		  50 PRINT ERR; " "; ERR$
+		  60 END
+		  70 PROCEDURE Show A
+		  80 ENDPROC`, "", "9 Too many parameters for Show\n"},
+		{`10 ON ERROR GOTO 50
+		  20 Show
+		  50 PRINT ERR; " at "; ERL
+		  60 END
+		  70 PROCEDURE Show
+		  80 PRINT (1
+		  90 ENDPROC`, "", "17 at 20\n"},
+		{`10 ON ERROR GOTO 50
+		  20 PRINT (1: PRINT "Not here"
+		  30 PRINT "After"
+		  40 END
+		  50 PRINT ERR; " at "; ERL
+		  60 RESUME NEXT`, "", "17 at 20\nAfter\n"},
+		{`10 ON ERROR GOTO 100
+		  20 PRINT Inverse(0): PRINT "After"
+		  30 END
+		  100 PRINT "Trapped "; ERR$; " at "; ERL
+		  110 RESUME NEXT
+		  200 FUNCTION Inverse(N)
+		  210 RESULT 1 / N
+		  220 ENDFUN`, "", "Trapped Trying to divide by zero at 20\nAfter\n"},
+		{`10 ON ERROR GOSUB 100
+		  20 Outer
+		  30 PRINT "Back"
+		  40 END
+		  100 PRINT "Handler"
+		  110 RETURN
+		  200 PROCEDURE Outer
+		  210 Inner
+		  220 PRINT "Not here"
+		  230 ENDPROC
+		  300 PROCEDURE Inner
+		  310 GOTO 999
+		  320 ENDPROC`, "", "Handler\nBack\n"},
+		{`10 RESUME`, "", "RESUME without any error in line 10\n"},
+		{`10 PRINT 1 \ 0`, "", "Trying to divide by zero in line 10\n"},
+		{`10 PRINT 10 ^ 400`, "", "Number too big in line 10\n"},
+		{`10 PRINT (-8) ^ 0.5`, "", "Invalid expression found in line 10\n"},
+		{`10 A$ := "pear": B$ := "apple"
+		  20 IF A$ > B$ THEN PRINT B$; " "; A$`, "", "apple pear\n"},
+		{`10 DIM A(5, 2)
+		  20 A(5, 0) := 1: A(4, 1) := 2: A(5, 2) := 3
+		  30 PRINT A(5, 0); A(4, 1); A(5, 2)`, "", "123\n"},
+	}
+
+	for _, tt := range tests {
+		got := testRun(tt.program, tt.input)
+		if !strings.HasPrefix(got, tt.expected) {
+			t.Errorf("wrong output for %q, got %q, want %q", tt.program, got, tt.expected)
+		}
+	}
+}
+
+// breakingConsole is a headless console that makes a <BREAK> when a given string is printed
+type breakingConsole struct {
+	*console.Headless
+	breakOn string
+}
+
+func (c *breakingConsole) Print(s string) {
+	c.Headless.Print(s)
+	if s == c.breakOn {
+		c.SetBreak(true)
+	}
+}
+
+func TestOnBreak(t *testing.T) {
+	tests := []struct {
+		program  string
+		expected string
+	}{
+		{`10 PRINT "Go"
+		  20 PRINT "Not here"`, "Go\nInterrupted by BREAK key in line 10\n"},
+		{`10 ON BREAK GOSUB 100
+		  20 PRINT "Go": PRINT "Back"
+		  30 END
+		  100 PRINT "Break"
+		  110 RETURN`, "Go\nBreak\nBack\n"},
+		{`10 ON BREAK GOTO 100
+		  20 PRINT "Go"
+		  30 PRINT "Not here"
+		  100 PRINT "Break"`, "Go\nBreak\n"},
+		{`10 ON BREAK GOSUB 100
+		  20 ON BREAK
+		  30 PRINT "Go"
+		  40 PRINT "Not here"
+		  100 PRINT "Break"
+		  110 RETURN`, "Go\nInterrupted by BREAK key in line 30\n"},
+		{`10 ON BREAK GOSUB 100
+		  20 PRINT "Go"
+		  30 END
+		  100 PRINT "Go"
+		  110 PRINT "Not here"
+		  120 RETURN`, "Go\nGo\nInterrupted by BREAK key in line 100\n"},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		g := game.New(&breakingConsole{console.NewHeadless(strings.NewReader(""), &out), "Go"})
+		env := testStore(g, tt.program)
+		Eval(g, &ast.RunStatement{}, env)
+		if got := out.String(); got != tt.expected {
+			t.Errorf("wrong output for %q, got %q, want %q", tt.program, got, tt.expected)
+		}
+	}
+}
+
 func testNumericObject(t *testing.T, obj object.Object, expected float64) bool {
 	result, ok := obj.(*object.Numeric)
 	if !ok {
@@ -92,6 +285,14 @@ func TestEvalBooleanExpression(t *testing.T) {
 		{"\"this\" = \"that\"", 0},
 		{"\"that\" = \"that\"", -1.0},
 		{"\"this\" == \"THIS\"", -1.0},
+		{"\"apple\" < \"banana\"", -1.0},
+		{"\"apple\" > \"banana\"", 0},
+		{"\"app\" < \"apple\"", -1.0},
+		{"\"B\" < \"a\"", -1.0},
+		{"\"abc\" <= \"abc\"", -1.0},
+		{"\"abc\" >= \"abd\"", 0},
+		{"\"abc\" <> \"ABC\"", -1.0},
+		{"\"abc\" >< \"abc\"", 0},
 	}
 
 	for _, tt := range tests {
@@ -159,190 +360,177 @@ func testNullObject(t *testing.T, obj object.Object) bool {
 
 func TestReturnStatements(t *testing.T) {
 	tests := []struct {
-		input    string
-		expected float64
+		program  string
+		expected string
 	}{
-		{"return 10;", 10}, // <-- this should fail
-		{`return 10
-9
-`, 10},
-		{`return 2 * 5 : 9
-`, 10},
-		{`
-9
-return 2 * 5 : 9
-`, 10},
-		{`
-if (10 > 1) {
-	if (10 > 1) {
-		return 10;
-	}
-	return 1;
-}
-`, 10},
+		{`10 GOSUB 100: PRINT "Back"
+		  20 END
+		  100 PRINT "Sub"
+		  110 RETURN`, "Sub\nBack\n"},
+		{`10 RETURN`, "RETURN without any GOSUB in line 10\n"},
+		{`10 PRINT Ten(1)
+		  20 END
+		  30 FUNCTION Ten(N)
+		  40 RESULT 2 * 5: PRINT 9
+		  50 ENDFUN`, "10\n"},
+		{`10 PRINT Ten(1)
+		  20 END
+		  30 FUNCTION Ten(N)
+		  40 IF 10 > N THEN
+		  50 IF 10 > N THEN RESULT 10
+		  60 RESULT 1
+		  70 ENDIF
+		  80 RESULT 2
+		  90 ENDFUN`, "10\n"},
+		{`10 Show: PRINT "Back"
+		  20 END
+		  30 PROCEDURE Show
+		  40 PRINT 1: LEAVE: PRINT 9
+		  50 ENDPROC`, "1\nBack\n"},
+		{`10 RESULT 5`, "Function exit without call in line 10\n"},
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
-		testNumericObject(t, evaluated, tt.expected)
+		got := testRun(tt.program, "")
+		if !strings.HasPrefix(got, tt.expected) {
+			t.Errorf("wrong output for %q, got %q, want %q", tt.program, got, tt.expected)
+		}
 	}
 }
 
 func TestErrorHandling(t *testing.T) {
 	tests := []struct {
-		input           string
-		expectedMessage string
+		program  string
+		expected string
 	}{
-		{
-			"5 + true;", // <-- again, this should fail
-			"type mismatch: NUMERIC + BOOLEAN",
-		},
-		{
-			"5 + true : 5",
-			"type mismatch: NUMERIC + BOOLEAN",
-		},
-		{
-			"-true",
-			"unknown operator: -BOOLEAN",
-		},
-		{
-			"true + false;",
-			"unknown operator: BOOLEAN + BOOLEAN",
-		},
-		{
-			"5 : true + false : 5",
-			"unknown operator: BOOLEAN + BOOLEAN",
-		},
-		{
-			"if (10 > 1) { true + false; }",
-			"unknown operator: BOOLEAN + BOOLEAN",
-		},
-		{
-			`
-if (10 > 1) {
-	if (10 > 1) {
-		return true + false;
-	}
-	return 1;
-}`,
-			"unknown operator: BOOLEAN + BOOLEAN",
-		},
-		{
-			"foobar;",
-			"identifier not found: Foobar",
-		},
-		{
-			`"Hello" - "World"`,
-			"unknown operator: STRING - STRING",
-		},
+		{`10 PRINT 5 + "a"`, "Invalid expression found (type mismatch: NUMERIC + STRING) in line 10\n"},
+		{`10 PRINT 1: PRINT "a" + 1: PRINT 2`, "1\nInvalid expression found (type mismatch: STRING + NUMERIC) in line 10\n"},
+		{`10 PRINT -"a"`, "Invalid expression found (unknown operator: -STRING) in line 10\n"},
+		{`10 IF 10 > 1 THEN PRINT "a" - "b"`, "Invalid expression found (unknown operator: STRING - STRING) in line 10\n"},
+		{`10 Foobar`, "Unknown command/procedure in line 10\n"},
+		{`10 PRINT "Hello" - "World"`, "Invalid expression found (unknown operator: STRING - STRING) in line 10\n"},
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
-
-		errObj, ok := evaluated.(*object.Error)
-		if !ok {
-			t.Errorf("no error object returned, got %T (%+v)", evaluated, evaluated)
-			continue
-		}
-		if errObj.Message != tt.expectedMessage {
-			t.Errorf("wrong error message, expected %q, got %q", tt.expectedMessage, errObj.Message)
+		got := testRun(tt.program, "")
+		if !strings.HasPrefix(got, tt.expected) {
+			t.Errorf("wrong output for %q, got %q, want %q", tt.program, got, tt.expected)
 		}
 	}
 }
 
 func TestLetStatements(t *testing.T) {
 	tests := []struct {
-		input    string
-		expected float64
+		program  string
+		expected string
 	}{
-		{`let a = 5 : a`, 5},
-		{`let a = 5 * 5
-a
-`, 25},
-		{"let a = 5 : let b = a : b", 5},
-		{`let a = 5
-let b = a : let c = a + b + 5
-c
-`, 15},
+		{`10 LET A = 5: PRINT A`, "5\n"},
+		{`10 LET A = 5 * 5
+		  20 PRINT A`, "25\n"},
+		{`10 LET A = 5: LET B = A: PRINT B`, "5\n"},
+		{`10 LET A = 5
+		  20 LET B = A: LET C = A + B + 5
+		  30 PRINT C`, "15\n"},
 	}
 
 	for _, tt := range tests {
-		testNumericObject(t, testEval(tt.input), tt.expected)
+		got := testRun(tt.program, "")
+		if !strings.HasPrefix(got, tt.expected) {
+			t.Errorf("wrong output for %q, got %q, want %q", tt.program, got, tt.expected)
+		}
 	}
 }
 
 func TestBindStatements(t *testing.T) {
 	tests := []struct {
-		input    string
-		expected float64
+		program  string
+		expected string
 	}{
-		{`a := 5 : a`, 5},
-		{`a := 5 * 5
-a
-`, 25},
-		{"a := 5 : b := a : b", 5},
-		{`a := 5
-b := a : c := a + b + 5
-c
-`, 15},
+		{`10 A := 5: PRINT A`, "5\n"},
+		{`10 A := 5 * 5
+		  20 PRINT A`, "25\n"},
+		{`10 A := 5: B := A: PRINT B`, "5\n"},
+		{`10 A := 5
+		  20 B := A: C := A + B + 5
+		  30 PRINT C`, "15\n"},
 	}
 
 	for _, tt := range tests {
-		testNumericObject(t, testEval(tt.input), tt.expected)
+		got := testRun(tt.program, "")
+		if !strings.HasPrefix(got, tt.expected) {
+			t.Errorf("wrong output for %q, got %q, want %q", tt.program, got, tt.expected)
+		}
 	}
 }
 
 func TestFloatToIntegerBind(t *testing.T) {
 	tests := []struct {
-		input    string
-		expected float64
+		program  string
+		expected string
 	}{
-		{"let a = 1.23456 : a", 1.23456},
-		{"let a% = 1.23456 : a%", 1.0},
-		{"let a% = 1.99999 : a%", 1.0},
+		{`10 LET A = 1.23456: PRINT A`, "1.23456\n"},
+		{`10 LET A% = 1.23456: PRINT A%`, "1\n"},
+		{`10 LET A% = 1.99999: PRINT A%`, "1\n"},
 	}
 
 	for _, tt := range tests {
-		testNumericObject(t, testEval(tt.input), tt.expected)
+		got := testRun(tt.program, "")
+		if !strings.HasPrefix(got, tt.expected) {
+			t.Errorf("wrong output for %q, got %q, want %q", tt.program, got, tt.expected)
+		}
 	}
 }
 
 func TestFunctionObject(t *testing.T) {
-	input := "function(x) : x + 2 : endfun"
-	evaluated := testEval(input)
-	fn, ok := evaluated.(*object.Function)
+	g := game.New(console.NewHeadless(strings.NewReader(""), &bytes.Buffer{}))
+	env := testStore(g, `10 END
+	  20 FUNCTION Double(X)
+	  30 RESULT X * 2
+	  40 ENDFUN`)
+	if !prerun(g, env) {
+		t.Fatalf("prerun failed")
+	}
+	fn, ok := env.GetFunction("Double")
 	if !ok {
-		t.Fatalf("object is not Function, got %T (%+v)", evaluated, evaluated)
+		t.Fatalf("function Double was not registered")
 	}
-	if len(fn.Parameters) != 1 {
-		t.Fatalf("function has wrong parameters.Parameters=%+v", fn.Parameters)
+	if len(fn.ReceiveArgs) != 1 {
+		t.Fatalf("function has wrong parameters, got %+v", fn.ReceiveArgs)
 	}
-	if fn.Parameters[0].String() != "X" {
-		t.Fatalf("parameter is not X, got %q", fn.Parameters[0])
+	if fn.ReceiveArgs[0].String() != "X" {
+		t.Fatalf("parameter is not X, got %q", fn.ReceiveArgs[0])
 	}
-	expectedBody := "(X + 2)"
-	if fn.Body.String() != expectedBody {
-		t.Fatalf("body is not %q, got %q", expectedBody, fn.Body.String())
+	if fn.LineNumber != 20 {
+		t.Fatalf("function is not on line 20, got %d", fn.LineNumber)
 	}
 }
 
 func TestFunctionApplication(t *testing.T) {
+	functions := `
+	  100 FUNCTION Identity(X)
+	  110 RESULT X
+	  120 ENDFUN
+	  200 FUNCTION Double(X)
+	  210 RESULT X * 2
+	  220 ENDFUN
+	  300 FUNCTION Add(X, Y)
+	  310 RESULT X + Y
+	  320 ENDFUN`
 	tests := []struct {
-		input    string
-		expected float64
+		program  string
+		expected string
 	}{
-		{"FUNCTION identity(x) : x : result 5 : endfun : Identity(5)", 5},
-		//{"LET identity := function(x) : result x : endfun : Identity(5)", 5},
-		//{"let double := function(x) : x * 2 : endfun : Double(5)", 10},
-		//{"let add = function(x, y) : x + y : endfun : Add(5, 5)", 10},
-		//{"let add = function(x, y) : x + y : endfun : Add(5 + 5, Add(5, 5))", 20},
-		//{"function(x) : x : endfun : (5)", 5},
+		{`10 PRINT Identity(5): END`, "5\n"},
+		{`10 PRINT Double(5): END`, "10\n"},
+		{`10 PRINT Add(5, 5): END`, "10\n"},
+		{`10 PRINT Add(5 + 5, Add(5, 5)): END`, "20\n"},
 	}
 
 	for _, tt := range tests {
-		log.Printf("input: %s\n", tt.input)
-		testNumericObject(t, testEval(tt.input), tt.expected)
+		got := testRun(tt.program+functions, "")
+		if !strings.HasPrefix(got, tt.expected) {
+			t.Errorf("wrong output for %q, got %q, want %q", tt.program, got, tt.expected)
+		}
 	}
 }
 
@@ -373,37 +561,1073 @@ func TestStringConcatenation(t *testing.T) {
 }
 
 func TestClosures(t *testing.T) {
-	input := `let newAdder = function(x) : function(y) : x + y : endfun : endfun : let addTwo = newAdder(2) : addTwo(2)`
-	testNumericObject(t, testEval(input), 4)
+	// RM Basic has no closures, but a function calling another must see only its own parameters
+	program := `10 X := 1
+	  20 PRINT AddTwo(2); " "; X
+	  30 END
+	  40 FUNCTION AddTwo(Y)
+	  50 RESULT Adder(2, Y)
+	  60 ENDFUN
+	  70 FUNCTION Adder(X, Y)
+	  80 RESULT X + Y
+	  90 ENDFUN`
+	if got := testRun(program, ""); got != "4 1\n" {
+		t.Errorf("wrong output, got %q, want %q", got, "4 1\n")
+	}
 }
 
 func TestBuiltinFunctions(t *testing.T) {
 	tests := []struct {
 		input    string
-		expected interface{}
+		expected string
 	}{
-		{`len("")`, 0},
-		{`len("four")`, 4},
-		{`len("hello world")`, 11},
-		{`len(1)`, "argument to `LEN` not supported, got NUMERIC"},
-		{`len("one", "two")`, "wrong number of arguments, got 2, want 1"},
+		{`LEN("")`, "0"},
+		{`LEN("four")`, "4"},
+		{`LEN("hello world")`, "11"},
+		{`LEN(1)`, "argument to `LEN` not supported, got NUMERIC in line 10"},
+		{`LEN("one", "two")`, "wrong number of arguments, got 2, want 1 in line 10"},
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		got := testRun("10 PRINT "+tt.input, "")
+		if !strings.HasPrefix(got, tt.expected+"\n") {
+			t.Errorf("wrong output for %s, got %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
 
-		switch expected := tt.expected.(type) {
-		case int:
-			testNumericObject(t, evaluated, float64(expected))
-		case string:
-			errObj, ok := evaluated.(*object.Error)
-			if !ok {
-				t.Errorf("object is not Error, got %T (%+v)", evaluated, evaluated)
-				continue
-			}
-			if errObj.Message != expected {
-				t.Errorf("wrong error message, expected %q, got %q", expected, errObj.Message)
+func TestStringFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`LEFT$("Nimbus", 3)`, "Nim"},
+		{`LEFT$("Nimbus", 10)`, "Nimbus"},
+		{`RIGHT$("Nimbus", 3)`, "bus"},
+		{`RIGHT$("Nimbus", 0)`, ""},
+		{`MID$("Nimbus", 2, 3)`, "imb"},
+		{`MID$("Nimbus", 4)`, "bus"},
+		{`MID$("Nimbus", 5, 10)`, "us"},
+		{`MID$("Nimbus", 10)`, ""},
+		{`INSTR("Nimbus", "bus")`, "4"},
+		{`INSTR("Nimbus", "x")`, "0"},
+		{`INSTR("banana", "an", 3)`, "4"},
+		{`INSTR(3, "banana", "an")`, "String expression needed in line 10"},
+		{`VAL("42")`, "42"},
+		{`VAL(" -1.5e2xyz")`, "-150"},
+		{`VAL("abc")`, "0"},
+		{`VAL("nancy")`, "0"},
+		{`VAL("0x10")`, "0"},
+		{`ASC("A")`, "65"},
+		{`ASC("")`, "0"},
+		{`ASC(CHR$(200))`, "200"},
+		{`ASC(STRING$(3, 255))`, "255"},
+		{`HEX$(255)`, "FF"},
+		{`HEX$(-1)`, "FFFF"},
+		{`STRING$(3, "ab")`, "ababab"},
+		{`STRING$(2, 42)`, "**"},
+		{`STRING$(2, 256)`, "Number not allowed in range in line 10"},
+		{`CHR$(65)`, "A"},
+		{`CHR$(-1)`, "Number not allowed in range in line 10"},
+		{`LEFT$("Nimbus")`, "Not enough parameters for LEFT$ in line 10"},
+		{`MID$("Nimbus", 1, 2, 3)`, "Too many parameters for MID$ in line 10"},
+		{`MID$("Nimbus", 0)`, "Number not allowed in range in line 10"},
+		{`RIGHT$("Nimbus", -1)`, "Positive value required in line 10"},
+		{`VAL(1)`, "String expression needed in line 10"},
+		{`HEX$(70000)`, "Number not allowed in range in line 10"},
+	}
+
+	for _, tt := range tests {
+		got := testRun("10 PRINT "+tt.input, "")
+		if !strings.HasPrefix(got, tt.expected+"\n") {
+			t.Errorf("wrong output for %s, got %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestIntegerVariables(t *testing.T) {
+	tests := []struct {
+		program  string
+		input    string
+		expected string
+	}{
+		{`10 A% = 7 / 2: PRINT A%`, "", "3\n"},
+		{`10 A% := -7 / 2: PRINT A%`, "", "-3\n"},
+		{`10 A% = 32767: PRINT A%`, "", "32767\n"},
+		{`10 A% = 32768`, "", "Number too big in line 10\n"},
+		{`10 A% = -32769`, "", "Number too big in line 10\n"},
+		{`10 DIM A%(2)
+		  20 A%(1) = 2.9: PRINT A%(1)
+		  30 A%(2) = 99999`, "", "2\nNumber too big in line 30\n"},
+		{`10 READ A%, B
+		  20 PRINT A%; " "; B
+		  30 DATA 2.5, 2.5`, "", "2 2.5\n"},
+		{`10 READ A%
+		  20 DATA 40000`, "", "Number too big in line 10\n"},
+		{`10 INPUT A%: PRINT A%`, "9.9\n", "9.9\n9\n"},
+		{`10 INPUT A%`, "50000\n", "50000\nNumber too big in line 10\n"},
+		{`10 FOR I% := 1.5 TO 3
+		  20 PRINT I%
+		  30 NEXT I%`, "", "1\n2\n3\n"},
+		{`10 FOR I% := 32766 TO 32768
+		  20 NEXT I%`, "", "Number too big in line 20\n"},
+		{`10 TEST 2.7
+		  20 END
+		  30 PROCEDURE TEST N%
+		  40 PRINT N%
+		  50 ENDPROC`, "", "2\n"},
+		{`10 PRINT 255 AND 15; " "; 5 OR 2; " "; 6 XOR 3`, "", "15 7 5\n"},
+		{`10 PRINT 65535 AND 1; " "; -1 AND 65535; " "; NOT 0`, "", "1 -1 -1\n"},
+		{`10 PRINT 32767 OR 32768`, "", "-1\n"},
+		{`10 PRINT 65536 AND 1`, "", "Number not allowed in range in line 10\n"},
+	}
+
+	for _, tt := range tests {
+		got := testRun(tt.program, tt.input)
+		if !strings.HasPrefix(got, tt.expected) {
+			t.Errorf("wrong output for %q, got %q, want %q", tt.program, got, tt.expected)
+		}
+	}
+}
+
+func TestTrace(t *testing.T) {
+	tests := []struct {
+		program  string
+		expected string
+	}{
+		{`10 SET TRACE ON
+		  20 GOSUB 50
+		  30 SET TRACE OFF
+		  40 END
+		  50 PRINT "Sub"
+		  60 RETURN`, "[20] [50] Sub\n[60] [20] [30] "},
+		{`10 SET TRACE LVAR
+		  20 A% := 1: B$ = "x"
+		  30 DIM C(2): C(1) := 2.5`, "[20] [A% := 1] [B$ := \"x\"] [30] [C(1) := 2.5] "},
+		{`10 SET TRACE ON #20`, "Channel not open for output in line 10\n"},
+		{`10 SET TRACE UP`, "Wrong SET/ASK attribute\n"},
+		{`10 Off := 1: SET TRACE off
+		  20 PRINT Off`, "1\n"},
+	}
+
+	for _, tt := range tests {
+		got := testRun(tt.program, "")
+		if !strings.HasPrefix(got, tt.expected) {
+			t.Errorf("wrong output for %q, got %q, want %q", tt.program, got, tt.expected)
+		}
+	}
+}
+
+func TestTraceToChannel(t *testing.T) {
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(t.TempDir())
+	got := testRun(`10 CREATE #20, "TRACE"
+	                20 SET TRACE LVAR #20
+	                30 A := 1
+	                40 PRINT "Done"
+	                50 CLOSE #20`, "")
+	if got != "Done\n" {
+		t.Errorf("trace went to the screen, got %q", got)
+	}
+	trace, _ := ioutil.ReadFile("TRACE.BAS")
+	if expected := "[30]\n[A := 1]\n[40]\n[50]\n"; string(trace) != expected {
+		t.Errorf("wrong trace, got %q, want %q", trace, expected)
+	}
+}
+
+// testDirect evaluates a line typed at the prompt
+func testDirect(g *game.Game, env *object.Environment, input string) object.Object {
+	l := &lexer.Lexer{}
+	l.Scan(input)
+	p := parser.New(l, g)
+	line := p.ParseLine()
+	if line.Statements == nil {
+		env.Program.AddLine(line.LineNumber, line.LineString)
+		return nil
+	}
+	var obj object.Object
+	for _, stmt := range line.Statements {
+		if obj = Eval(g, stmt, env); isError(obj) {
+			break
+		}
+	}
+	return obj
+}
+
+func TestStopAndContinue(t *testing.T) {
+	var out bytes.Buffer
+	c := &breakingConsole{console.NewHeadless(strings.NewReader(""), &out), "Go"}
+	g := game.New(c)
+	env := testStore(g, `10 A := 1
+	                     20 GOSUB 100: STOP: PRINT A
+	                     30 PRINT "Go": PRINT "After break"
+	                     40 END
+	                     100 PRINT "Sub"
+	                     110 RETURN`)
+	expect := func(step, expected string) {
+		t.Helper()
+		if got := out.String(); got != expected {
+			t.Errorf("%s: got %q, want %q", step, got, expected)
+		}
+		out.Reset()
+	}
+	testDirect(g, env, "RUN")
+	expect("RUN", "Sub\nSTOP in line 20\n")
+	testDirect(g, env, "A := 5")
+	testDirect(g, env, "CONTINUE")
+	expect("CONTINUE after STOP", "5\nGo\nInterrupted by BREAK key in line 30\n")
+	c.SetBreak(false)
+	testDirect(g, env, "CONTINUE")
+	expect("CONTINUE after <BREAK>", "After break\n")
+	if obj := testDirect(g, env, "CONTINUE"); !isError(obj) {
+		t.Errorf("CONTINUE after END didn't fail")
+	}
+	testDirect(g, env, "RUN")
+	out.Reset()
+	testDirect(g, env, "25 PRINT \"New line\"")
+	if obj := testDirect(g, env, "CONTINUE"); !isError(obj) {
+		t.Errorf("CONTINUE after editing the program didn't fail")
+	}
+}
+
+func TestContinueInsideProcedure(t *testing.T) {
+	var out bytes.Buffer
+	c := &breakingConsole{console.NewHeadless(strings.NewReader(""), &out), "Go"}
+	g := game.New(c)
+	env := testStore(g, `10 Outer RECEIVE R
+	                     20 PRINT R
+	                     30 PRINT Twice(2)
+	                     40 END
+	                     100 PROCEDURE Outer RETURN Y
+	                     110 Inner 2 RECEIVE Y
+	                     120 PRINT "Outer done"
+	                     130 ENDPROC
+	                     200 PROCEDURE Inner N RETURN Z
+	                     210 Z := N * 10
+	                     220 STOP: PRINT "Go": Z := Z + N
+	                     230 ENDPROC
+	                     300 FUNCTION Twice(N)
+	                     310 STOP
+	                     320 RESULT N * 2`)
+	expect := func(step, expected string) {
+		t.Helper()
+		if got := out.String(); got != expected {
+			t.Errorf("%s: got %q, want %q", step, got, expected)
+		}
+		out.Reset()
+	}
+	testDirect(g, env, "RUN")
+	expect("RUN", "STOP in line 220\n")
+	testDirect(g, env, "CONTINUE")
+	expect("CONTINUE after STOP", "Go\nInterrupted by BREAK key in line 10\n")
+	c.SetBreak(false)
+	testDirect(g, env, "CONTINUE")
+	expect("CONTINUE after <BREAK>", "Outer done\n22\nSTOP in line 310\n")
+	if obj := testDirect(g, env, "CONTINUE"); !isError(obj) {
+		t.Errorf("CONTINUE after STOP inside a function didn't fail")
+	}
+}
+
+// testClock is a Clock that only moves when it's told to
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func TestClockFunctions(t *testing.T) {
+	tests := []struct {
+		program  string
+		expected string
+	}{
+		{`10 PRINT DATE$; " "; TIME$`, "25/12/85 09:30:15\n"},
+		{`10 PRINT TIME`, "1500\n"},
+		{`10 SET TIME "23:59:00"
+		  20 PRINT DATE$; " "; TIME$`, "25/12/85 23:59:00\n"},
+		{`10 SET DATE "01/02/1987": SET TIME "12:00"
+		  20 PRINT DATE$; " "; TIME$; " "; TIME`, "01/02/87 12:00:00 1500\n"},
+		{`10 SET DATE "31/02/87"`, "Invalid date or time in line 10\n"},
+		{`10 SET TIME 12`, "String expression needed in line 10\n"},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		g := game.New(console.NewHeadless(strings.NewReader(""), &out))
+		env := testStore(g, tt.program)
+		clock := &testClock{time.Date(1985, 12, 25, 9, 30, 0, 0, time.UTC)}
+		env.SetClock(clock)
+		clock.now = clock.now.Add(15 * time.Second)
+		Eval(g, &ast.RunStatement{}, env)
+		if got := out.String(); !strings.HasPrefix(got, tt.expected) {
+			t.Errorf("wrong output for %q, got %q, want %q", tt.program, got, tt.expected)
+		}
+	}
+}
+
+func benchmarkExample(b *testing.B, filename string) {
+	program, ok := examples.Get(filename)
+	if !ok {
+		b.Fatalf("no such example %q", filename)
+	}
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		g := game.New(console.NewHeadless(strings.NewReader(""), ioutil.Discard))
+		env := testStore(g, program)
+		b.StartTimer()
+		Eval(g, &ast.RunStatement{}, env)
+	}
+}
+
+func BenchmarkMandelbrot(b *testing.B) { benchmarkExample(b, "mandelbrot.BAS") }
+func BenchmarkMeltdown(b *testing.B)   { benchmarkExample(b, "meltdown.BAS") }
+
+func TestRenumberStatement(t *testing.T) {
+	var out bytes.Buffer
+	g := game.New(console.NewHeadless(strings.NewReader(""), &out))
+	env := testStore(g, `1 GOSUB 3
+	                     2 END
+	                     3 PRINT "Sub"
+	                     4 RETURN`)
+	if obj := testDirect(g, env, "RENUMBER 100, 5"); obj != nil {
+		t.Fatalf("RENUMBER 100, 5 failed: %v", obj.Inspect())
+	}
+	expected := []string{`100 GOSUB 110`, `105 END`, `110 PRINT "Sub"`, `115 RETURN`}
+	if got := env.Program.List(0, 0, false); strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("got %q, want %q", got, expected)
+	}
+	testDirect(g, env, "RUN")
+	if out.String() != "Sub\n" {
+		t.Errorf("renumbered program printed %q", out.String())
+	}
+	obj := testDirect(g, env, "RENUMBER 1, 100, 110")
+	if errorMsg, ok := obj.(*object.Error); !ok || errorMsg.Message != "RENUMBER would overlap other lines" {
+		t.Errorf("overlapping RENUMBER returned %v", obj)
+	}
+}
+
+func TestAuto(t *testing.T) {
+	tests := []struct {
+		command  string
+		input    string
+		expected []string
+	}{
+		{"AUTO", "PRINT 1\nPRINT 2\n\n", []string{`10 PRINT 1`, `20 PRINT 2`, `30 PRINT "Old"`}},
+		{"AUTO 100, 5", "PRINT 1\nPRINT 2\n\n", []string{`30 PRINT "Old"`, `100 PRINT 1`, `105 PRINT 2`}},
+		// Existing lines are offered for editing
+		{"AUTO 20", "PRINT 2\n: PRINT 3\n", []string{`20 PRINT 2`, `30 PRINT "Old" : PRINT 3`}},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		g := game.New(console.NewHeadless(strings.NewReader(tt.input), &out))
+		env := testStore(g, `30 PRINT "Old"`)
+		if obj := testDirect(g, env, tt.command); obj != nil {
+			t.Errorf("%s failed: %s", tt.command, obj.Inspect())
+			continue
+		}
+		if got := env.Program.List(0, 0, false); strings.Join(got, "\n") != strings.Join(tt.expected, "\n") {
+			t.Errorf("%s gave %q, want %q", tt.command, got, tt.expected)
+		}
+	}
+}
+
+func TestDelete(t *testing.T) {
+	short := "10 PRINT 1\n20 PRINT 2\n30 PRINT 3\n40 PRINT 4"
+	long := ""
+	for i := 1; i <= 12; i++ {
+		long += fmt.Sprintf("%d PRINT %d\n", i*10, i)
+	}
+	tests := []struct {
+		program  string
+		command  string
+		input    string
+		expected int
+	}{
+		{short, "DELETE 20", "", 3},
+		{short, "DELETE 20 TO 30", "", 2},
+		{short, "DELETE 20 TO", "", 1},
+		{short, "DELETE TO 30", "", 1},
+		{short, "DELETE 25", "", 4},
+		// Deleting many lines asks first
+		{long, "DELETE 10 TO", "N", 12},
+		{long, "DELETE 10 TO", "Y", 0},
+		{long, "DELETE 100 TO", "", 9},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		g := game.New(console.NewHeadless(strings.NewReader(tt.input), &out))
+		env := testStore(g, tt.program)
+		testDirect(g, env, tt.command)
+		if got := len(env.Program.List(0, 0, false)); got != tt.expected {
+			t.Errorf("%s left %d lines, want %d", tt.command, got, tt.expected)
+		}
+	}
+}
+
+func TestLvarAndProcs(t *testing.T) {
+	program := `10 GLOBAL Total
+20 Total := 5: Name$ := "Fred": Count% := 3
+30 DIM Grid(2, 3)
+40 END
+100 PROCEDURE Show A, B RETURN C
+110 C := A + B
+120 ENDPROC
+200 FUNCTION Sq(N)
+210 RESULT N * N
+220 ENDFUN
+300 SUBROUTINE Setup
+310 RETURN`
+	tests := []struct {
+		command  string
+		expected string
+	}{
+		{"LVAR", `Count%          Integer  Scope 0  3
+Grid            Array    Scope 0  (2, 3)
+Name$           String   Scope 0  "Fred"
+Total           Numeric  GLOBAL   5
+`},
+		{"PROCS", `100 PROCEDURE Show A, B RETURN C
+200 FUNCTION Sq(N)
+300 SUBROUTINE Setup
+`},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		g := game.New(console.NewHeadless(strings.NewReader(""), &out))
+		env := testStore(g, program)
+		Eval(g, &ast.RunStatement{}, env)
+		out.Reset()
+		if obj := testDirect(g, env, tt.command); obj != nil {
+			t.Errorf("%s failed: %s", tt.command, obj.Inspect())
+			continue
+		}
+		if got := out.String(); got != tt.expected {
+			t.Errorf("%s gave %q, want %q", tt.command, got, tt.expected)
+		}
+	}
+}
+
+func TestLvarInsideProcedures(t *testing.T) {
+	got := testRun(`10 GLOBAL Total
+	                20 Total := 1: A := 2: Outer
+	                30 END
+	                100 PROCEDURE Outer
+	                110 B := 3: LVAR: Inner
+	                120 ENDPROC
+	                200 PROCEDURE Inner
+	                210 C := 4: LVAR
+	                220 ENDPROC`, "")
+	expected := `B               Numeric  Scope 1  3
+Total           Numeric  GLOBAL   1
+C               Numeric  Scope 2  4
+Total           Numeric  GLOBAL   1
+`
+	if got != expected {
+		t.Errorf("wrong output, got %q, want %q", got, expected)
+	}
+}
+
+func TestPrintPaged(t *testing.T) {
+	var out bytes.Buffer
+	g := game.New(console.NewHeadless(strings.NewReader(" "), &out))
+	_, _, row1, _, row2 := g.AskWriting()
+	pageLength := row2 - row1
+	lines := make([]string, pageLength+1)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("Line %d", i)
+	}
+	printPaged(g, lines)
+	want := strings.Join(lines[:pageLength], "\n") + "\nPress any key for more\n" + lines[pageLength] + "\n"
+	if got := out.String(); got != want {
+		t.Errorf("printPaged gave %q, want %q", got, want)
+	}
+}
+
+func TestPrintPagedWithoutInput(t *testing.T) {
+	// Once the input runs out there's no key to wait for, so the rest is printed in one go
+	var out bytes.Buffer
+	g := game.New(console.NewHeadless(strings.NewReader(""), &out))
+	_, _, row1, _, row2 := g.AskWriting()
+	pageLength := row2 - row1
+	lines := make([]string, pageLength*3)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("Line %d", i)
+	}
+	done := make(chan bool)
+	go func() {
+		printPaged(g, lines)
+		done <- true
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("printPaged is still waiting for a key")
+	}
+	want := strings.Join(lines[:pageLength], "\n") + "\nPress any key for more\n" + strings.Join(lines[pageLength:], "\n") + "\n"
+	if got := out.String(); got != want {
+		t.Errorf("printPaged gave %q, want %q", got, want)
+	}
+}
+
+func TestEditor(t *testing.T) {
+	program := `10 PRINT 1
+20 PRINT 2
+30 PRINT 3`
+	typing := func(s string) []int {
+		keys := []int{}
+		for _, c := range s {
+			keys = append(keys, int(c))
+		}
+		return keys
+	}
+	keys := func(groups ...[]int) []int {
+		all := []int{}
+		for _, group := range groups {
+			all = append(all, group...)
+		}
+		return all
+	}
+	tests := []struct {
+		keys     []int
+		expected []string
+	}{
+		// Lines are committed when the cursor leaves them
+		{keys([]int{keycode.End}, typing(" + 1"), []int{keycode.Down, keycode.Escape}), []string{"10 PRINT 1 + 1", "20 PRINT 2", "30 PRINT 3"}},
+		{keys([]int{keycode.Down, keycode.Home, keycode.Delete, keycode.Delete, keycode.Delete, keycode.Delete, keycode.Delete, keycode.Delete, keycode.Delete}, typing("CLS"), []int{keycode.Escape}), []string{"10 PRINT 1", "20 CLS", "30 PRINT 3"}},
+		// A line with an error can't be left, and ESC twice throws it away
+		{keys([]int{keycode.End}, typing(" ("), []int{keycode.Down, keycode.Down, keycode.Escape, keycode.Escape}), []string{"10 PRINT 1", "20 PRINT 2", "30 PRINT 3"}},
+		// New lines
+		{keys([]int{keycode.Insert}, typing("CLS"), []int{keycode.Escape}), []string{"10 PRINT 1", "15 CLS", "20 PRINT 2", "30 PRINT 3"}},
+		{keys([]int{keycode.PageDown, keycode.Enter}, typing("END"), []int{keycode.Escape}), []string{"10 PRINT 1", "20 PRINT 2", "30 PRINT 3", "40 END"}},
+		{keys([]int{keycode.Insert, keycode.Escape}), []string{"10 PRINT 1", "20 PRINT 2", "30 PRINT 3"}},
+		// Blocks
+		{[]int{keycode.F1, keycode.Down, keycode.F1, keycode.Down, keycode.F2, keycode.Escape}, []string{"10 PRINT 1", "20 PRINT 2", "30 PRINT 3", "40 PRINT 1", "50 PRINT 2"}},
+		{[]int{keycode.F1, keycode.Down, keycode.F1, keycode.Down, keycode.F3, keycode.Escape}, []string{"30 PRINT 3", "40 PRINT 1", "50 PRINT 2"}},
+		{[]int{keycode.F1, keycode.Down, keycode.F1, keycode.F3, keycode.Escape}, []string{"10 PRINT 1", "20 PRINT 2", "30 PRINT 3"}},
+		{[]int{keycode.Down, keycode.F4, keycode.Escape}, []string{"10 PRINT 1", "30 PRINT 3"}},
+		{[]int{keycode.F1, keycode.Down, keycode.F1, keycode.F4, keycode.Escape}, []string{"30 PRINT 3"}},
+	}
+
+	for i, tt := range tests {
+		var out bytes.Buffer
+		g := game.New(console.NewHeadless(strings.NewReader(""), &out))
+		env := testStore(g, program)
+		e := newEditor(g, env)
+		for _, key := range tt.keys {
+			e.draw()
+			if e.handleKey(key) {
+				break
 			}
 		}
+		if got := env.Program.List(0, 0, false); strings.Join(got, "\n") != strings.Join(tt.expected, "\n") {
+			t.Errorf("test %d gave %q, want %q", i, got, tt.expected)
+		}
+	}
+}
+
+func TestEditorFind(t *testing.T) {
+	var out bytes.Buffer
+	g := game.New(console.NewHeadless(strings.NewReader(""), &out))
+	env := testStore(g, "10 PRINT 1\n20 CLS\n30 PRINT 3")
+	e := newEditor(g, env)
+	e.search = "print"
+	for _, expected := range []int{2, 0, 2} {
+		e.handleKey(keycode.F6)
+		if e.row != expected || e.col != 0 {
+			t.Errorf("find gave row %d col %d, want row %d col 0", e.row, e.col, expected)
+		}
+	}
+	e.search = "GOTO"
+	e.handleKey(keycode.F6)
+	if e.status == "" {
+		t.Errorf("find didn't say GOTO wasn't found")
+	}
+}
+
+func TestChaining(t *testing.T) {
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(t.TempDir())
+	files := map[string]string{
+		"FIRST.BAS": `10 GLOBAL Shared
+20 Shared := 42: Local := 7
+30 CREATE #11, "OUT"
+40 LOADGO "SECOND"
+50 PRINT "Not here"`,
+		"SECOND.BAS": `10 GLOBAL Shared
+20 PRINT Shared; " "; Local
+30 PRINT #11, "From second"
+40 CLOSE #11`,
+		"EXTRA.BAS": `20 PRINT "Merged"
+30 PRINT "Extra"`,
+	}
+	for name, program := range files {
+		ioutil.WriteFile(name, []byte(program), 0644)
+	}
+
+	var out bytes.Buffer
+	g := game.New(console.NewHeadless(strings.NewReader(""), &out))
+	env := object.NewEnvironment(object.NewEnvironment(nil))
+	testDirect(g, env, `LOADGO "FIRST"`)
+	// Only the GLOBAL variable is passed on
+	if got, expected := out.String(), "Warning: Variable without any value\n42 0\n"; got != expected {
+		t.Errorf("LOADGO printed %q, want %q", got, expected)
+	}
+	if got, _ := ioutil.ReadFile("OUT.BAS"); string(got) != "From second\n" {
+		t.Errorf("channel wasn't kept open when chaining, file has %q", got)
+	}
+
+	// MERGE overlays the lines onto the program in memory
+	out.Reset()
+	env = testStore(g, `10 PRINT "Kept"
+	                    20 PRINT "Replaced"`)
+	testDirect(g, env, `MERGE "EXTRA"`)
+	expected := []string{`10 PRINT "Kept"`, `20 PRINT "Merged"`, `30 PRINT "Extra"`}
+	if got := env.Program.List(0, 0, false); strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("MERGE gave %q, want %q", got, expected)
+	}
+	testDirect(g, env, `MERGEGO "EXTRA"`)
+	if got := out.String(); got != "Kept\nMerged\nExtra\n" {
+		t.Errorf("MERGEGO printed %q", got)
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	program := `10 Counter% := 1: Count := 2
+100 PROCEDURE Show_total A, B RETURN C
+110 C := A + B
+120 ENDPROC
+200 FUNCTION Square(N)
+210 RESULT N * N
+220 ENDFUN`
+	g := game.New(console.NewHeadless(strings.NewReader(""), &bytes.Buffer{}))
+	env := testStore(g, program)
+	Eval(g, &ast.RunStatement{}, env)
+	tests := []struct {
+		buffer           string
+		position         int
+		expected         string
+		expectedPosition int
+	}{
+		{"pri", 3, "PRINT", 5},
+		{"SET WRIT", 8, "SET WRIT", 8},
+		{"SET WRITI", 9, "SET WRITING", 11},
+		{"PRINT LEF", 9, "PRINT LEFT$", 11},
+		{"sho", 3, "Show_Total", 10},
+		{"PRINT squ", 9, "PRINT SQUA", 10},
+		{"PRINT squar", 11, "PRINT Square", 12},
+		{"PRINT Cou", 9, "PRINT Count", 11},
+		{"PRINT Counter", 13, "PRINT Counter%", 14},
+		{"pri 1", 3, "PRINT 1", 5},
+		{"PRINT 12", 8, "PRINT 12", 8},
+		{"PRINT Zzz", 9, "PRINT Zzz", 9},
+		{"PRINT ", 6, "PRINT ", 6},
+	}
+	for _, tt := range tests {
+		got, position := Complete(env, tt.buffer, tt.position)
+		if got != tt.expected || position != tt.expectedPosition {
+			t.Errorf("Complete(%q, %d) gave %q, %d, want %q, %d", tt.buffer, tt.position, got, position, tt.expected, tt.expectedPosition)
+		}
+	}
+}
+
+func TestSyntaxHint(t *testing.T) {
+	program := `100 PROCEDURE Show A, B RETURN C
+110 C := A + B
+120 ENDPROC
+200 FUNCTION Sq(N)
+210 RESULT N * N
+220 ENDFUN`
+	g := game.New(console.NewHeadless(strings.NewReader(""), &bytes.Buffer{}))
+	env := testStore(g, program)
+	Eval(g, &ast.RunStatement{}, env)
+	tests := []struct {
+		buffer   string
+		position int
+		expected string
+	}{
+		{"PRINT", 5, "PRINT [#e1,] [~e2,] [printList]"},
+		{"set writing 1", 3, "SET WRITING e1 [TO e2, e3; e4, e5]"},
+		{"SET WRITING 1", 9, "SET WRITING e1 [TO e2, e3; e4, e5]"},
+		{"PRINT MID$(A$", 9, "MID$(e$, start [, n])"},
+		{"Show 1, 2", 2, "PROCEDURE Show A, B RETURN C"},
+		{"show 1, 2", 4, "PROCEDURE Show A, B RETURN C"},
+		{"PRINT Sq(", 8, "FUNCTION Sq(N)"},
+		{"PRINT 1", 7, ""},
+		{"Unknown", 3, ""},
+	}
+	for _, tt := range tests {
+		if got := SyntaxHint(env, tt.buffer, tt.position); got != tt.expected {
+			t.Errorf("SyntaxHint(%q, %d) gave %q, want %q", tt.buffer, tt.position, got, tt.expected)
+		}
+	}
+}
+
+func TestBlockIf(t *testing.T) {
+	chain := `20 IF A = 1 THEN
+	          30 PRINT "One"
+	          40 ELSE IF A = 2 THEN
+	          50 PRINT "Two"
+	          60 ELSE IF A = 3 THEN
+	          70 PRINT "Three"
+	          80 ELSE
+	          90 PRINT "Other"
+	          100 ENDIF
+	          110 PRINT "Done"`
+	tests := []struct {
+		program  string
+		expected string
+	}{
+		{"10 A := 1\n" + chain, "One\nDone\n"},
+		{"10 A := 2\n" + chain, "Two\nDone\n"},
+		{"10 A := 3\n" + chain, "Three\nDone\n"},
+		{"10 A := 4\n" + chain, "Other\nDone\n"},
+		// Nested blocks, single-line IFs and loops inside the branches
+		{`10 FOR I := 1 TO 4
+		  20 IF I < 3 THEN
+		  30 IF I = 1 THEN
+		  40 PRINT "a"
+		  50 ELSE
+		  60 PRINT "b"
+		  70 ENDIF
+		  80 ELSE
+		  90 IF I = 3 THEN A$ := "c" ELSE A$ := "d"
+		  100 PRINT A$
+		  110 ENDIF
+		  120 NEXT I`, "a\nb\nc\nd\n"},
+		{`10 IF FALSE THEN
+		  20 IF TRUE THEN
+		  30 PRINT "Inner"
+		  40 ENDIF
+		  50 PRINT "Outer"
+		  60 ENDIF: PRINT "After"`, "After\n"},
+		{`10 IF TRUE THEN
+		  20 PRINT "Yes"
+		  30 ENDIF`, "Yes\n"},
+		{`10 A := -5: Sign A: Sign 0: Sign 5
+		  20 END
+		  100 PROCEDURE Sign N
+		  110 IF N < 0 THEN
+		  120 PRINT "Negative"
+		  130 ELSE IF N = 0 THEN
+		  140 PRINT "Zero"
+		  150 ELSE
+		  160 PRINT "Positive"
+		  170 ENDIF
+		  180 ENDPROC`, "Negative\nZero\nPositive\n"},
+		{`10 IF FALSE THEN
+		  20 PRINT "No"
+		  30 ENDIF`, ""},
+		{`10 IF TRUE THEN
+		  20 PRINT "No ENDIF"`, "IF without ENDIF in line 10\n"},
+		{`10 PRINT "A"
+		  20 ELSE
+		  30 PRINT "B"`, "ELSE without any IF in line 20\n"},
+		{`10 IF TRUE THEN
+		  20 ELSE
+		  30 ELSE IF TRUE THEN
+		  40 ENDIF`, "ELSE without any IF in line 30\n"},
+		{`10 IF TRUE THEN
+		  20 ENDIF
+		  30 ENDIF`, "ENDIF without any IF in line 30\n"},
+		{`10 IF TRUE THEN
+		  20 ELSE IF TRUE THEN PRINT 1
+		  30 ENDIF`, "End of instruction expected\n"},
+	}
+
+	for _, tt := range tests {
+		got := testRun(tt.program, "")
+		if !strings.HasPrefix(got, tt.expected) || (tt.expected == "" && got != "") {
+			t.Errorf("wrong output for %q, got %q, want %q", tt.program, got, tt.expected)
+		}
+	}
+}
+
+// getBreakingConsole is a headless console that makes a <BREAK> whenever a key is read
+type getBreakingConsole struct {
+	*console.Headless
+}
+
+func (c *getBreakingConsole) Get() int {
+	c.SetBreak(true)
+	return c.Headless.Get()
+}
+
+func TestBlockIfInterrupted(t *testing.T) {
+	// The <BREAK> comes after the IF has jumped to the ELSE IF but before it's reached, and
+	// the handler then goes through the first branch, so the ELSE IF just ends that branch
+	var out bytes.Buffer
+	g := game.New(&getBreakingConsole{console.NewHeadless(strings.NewReader(""), &out)})
+	env := testStore(g, `10 ON BREAK GOTO 100
+	                     20 IF GET(0) = 999 THEN
+	                     30 PRINT "One"
+	                     40 ELSE IF TRUE THEN
+	                     50 PRINT "Two"
+	                     60 ENDIF
+	                     70 END
+	                     100 ON BREAK
+	                     110 GOTO 30`)
+	Eval(g, &ast.RunStatement{}, env)
+	if got := out.String(); got != "One\n" {
+		t.Errorf("wrong output, got %q, want %q", got, "One\n")
+	}
+}
+
+func TestOnJump(t *testing.T) {
+	menu := `20 ON Choice% GOTO 100, 200, 300
+	         30 PRINT "Not here"
+	         100 PRINT "One": END
+	         200 PRINT "Two": END
+	         300 PRINT "Three"`
+	tests := []struct {
+		program  string
+		expected string
+	}{
+		{"10 Choice% := 1\n" + menu, "One\n"},
+		{"10 Choice% := 2\n" + menu, "Two\n"},
+		{"10 Choice% := 3\n" + menu, "Three\n"},
+		{"10 Choice% := 0\n" + menu, "ON value out of range in line 20\n"},
+		{"10 Choice% := 4\n" + menu, "ON value out of range in line 20\n"},
+		{`10 FOR I := 1 TO 3
+		  20 ON I GOSUB 100, Second, 300: PRINT "Back"
+		  30 NEXT I
+		  40 END
+		  100 PRINT "First": RETURN
+		  200 SUBROUTINE Second
+		  210 PRINT "Second": RETURN
+		  300 PRINT "Third"
+		  310 RETURN`, "First\nBack\nSecond\nBack\nThird\nBack\n"},
+		{`10 ON 1 + 1 GOSUB 100, 999
+		  100 RETURN`, "Line number does not exist in line 10\n"},
+		{`10 ON 1 GOSUB Missing`, "Specified line not found in line 10\n"},
+		{`10 ON "A" GOTO 10`, "Numeric expression needed in line 10\n"},
+		{`10 ON 1 GOTO Label`, "Line number/label needed\n"},
+		{`10 ON 1 PRINT 10`, "End of instruction expected\n"},
+	}
+
+	for _, tt := range tests {
+		got := testRun(tt.program, "")
+		if !strings.HasPrefix(got, tt.expected) {
+			t.Errorf("wrong output for %q, got %q, want %q", tt.program, got, tt.expected)
+		}
+	}
+}
+
+func TestForNext(t *testing.T) {
+	tests := []struct {
+		program  string
+		expected string
+	}{
+		{`10 FOR I := 10 TO 1 STEP -3
+		  20 PRINT I
+		  30 NEXT I`, "10\n7\n4\n1\n"},
+		{`10 FOR I := 1 TO 2 STEP 0.5: PRINT I: NEXT`, "1\n1.5\n2\n"},
+		// A loop with nothing to count is skipped, including any loops inside it
+		{`10 FOR I := 1 TO 0
+		  20 FOR J := 1 TO 3
+		  30 PRINT "Not here"
+		  40 NEXT J
+		  50 NEXT I
+		  60 PRINT "Done"`, "Done\n"},
+		{`10 FOR I := 1 TO 5 STEP -1: PRINT "Not here": NEXT I: PRINT "Done"`, "Done\n"},
+		{`10 FOR I := 1 TO 0
+		  20 PRINT "Not here"`, "FOR without NEXT in line 10\n10 FOR I := 1 TO 0\n"},
+		// NEXT with more than one variable
+		{`10 FOR I := 1 TO 2
+		  20 FOR J := 1 TO 2
+		  30 PRINT I; J
+		  40 NEXT J, I
+		  50 PRINT "Done"`, "11\n12\n21\n22\nDone\n"},
+		{`10 FOR I := 1 TO 2
+		  20 FOR J := 1 TO 0
+		  30 PRINT "Not here"
+		  40 NEXT J, I
+		  50 PRINT "Done"`, "Done\n"},
+		// NEXT for an outer loop drops inner loops that were left with GOTO
+		{`10 FOR I := 1 TO 3
+		  20 FOR J := 1 TO 3
+		  30 IF J = 2 THEN GOTO 50
+		  40 NEXT J
+		  50 NEXT I
+		  60 PRINT I; J
+		  70 NEXT`, "32\nNEXT without matching FOR in line 70\n70 NEXT\n"},
+		{`10 FOR I := 1 TO 2
+		  20 GOSUB 100
+		  30 NEXT I
+		  40 END
+		  100 NEXT I`, "NEXT without matching FOR in line 100\n100 NEXT >> I\n"},
+		{`10 NEXT I`, "NEXT without matching FOR in line 10\n10 NEXT >> I\n"},
+		{`10 FOR I := 1 TO 2 STEP 0: NEXT`, "Step value not large enough in line 10\n10 FOR >> I := 1 TO 2 STEP 0 : NEXT\n"},
+	}
+
+	for _, tt := range tests {
+		got := testRun(tt.program, "")
+		if got != tt.expected {
+			t.Errorf("wrong output for %q, got %q, want %q", tt.program, got, tt.expected)
+		}
+	}
+}
+
+func TestArrayParameters(t *testing.T) {
+	tests := []struct {
+		program  string
+		expected string
+	}{
+		{`10 GLOBAL A()
+		  20 DIM A(3)
+		  30 A(1) := 5
+		  40 Change
+		  50 PRINT A(1); A(2)
+		  60 END
+		  70 PROCEDURE Change
+		  80 GLOBAL A()
+		  90 A(2) := 7
+		  100 ENDPROC`, "57\n"},
+		{`10 DIM A(3)
+		  20 A(1) := 5
+		  30 Show A(), 2
+		  40 PRINT A(1)
+		  50 END
+		  60 PROCEDURE Show B(), C
+		  70 B(1) := 99
+		  80 PRINT B(1); C
+		  90 ENDPROC`, "992\n5\n"},
+		{`10 DIM A(3)
+		  20 Swap A() RECEIVE X()
+		  30 PRINT X(0); X(3); A(0)
+		  40 END
+		  50 PROCEDURE Swap B() RETURN B()
+		  60 B(0) := 3: B(3) := 1
+		  70 ENDPROC`, "310\n"},
+		{`10 DIM N%(2)
+		  20 N%(0) := 1
+		  30 N%(2) := 4
+		  40 PRINT Total(N%())
+		  50 END
+		  60 FUNCTION Total(V%())
+		  70 RESULT V%(0) + V%(1) + V%(2)
+		  80 ENDFUN`, "5\n"},
+		{`10 DIM A$(2)
+		  20 Show A$()
+		  30 END
+		  40 PROCEDURE Show B()
+		  50 ENDPROC`, "Wrong type of array in line 20\n"},
+		{`10 Show 5
+		  20 END
+		  30 PROCEDURE Show B()
+		  40 ENDPROC`, "Array needed in line 10\n"},
+		{`10 DIM A(2)
+		  20 Show A()
+		  30 END
+		  40 PROCEDURE Show B
+		  50 ENDPROC`, "Numeric expression needed in line 20\n"},
+		{`10 Show B()
+		  20 END
+		  30 PROCEDURE Show B()
+		  40 ENDPROC`, "Function/Array not found in line 10\n"},
+		{`10 Get RECEIVE X
+		  20 END
+		  30 PROCEDURE Get RETURN B()
+		  40 DIM B(1)
+		  50 ENDPROC`, "Array needed in line 10\n"},
+		{`10 Show
+		  20 END
+		  30 PROCEDURE Show B
+		  40 ENDPROC`, "Not enough parameters for Show in line 10\n"},
+		{`10 Show 1, 2
+		  20 END
+		  30 PROCEDURE Show B
+		  40 ENDPROC`, "Too many parameters for Show in line 10\n"},
+		{`10 GLOBAL A(5)`, "Closing bracket is needed\nGLOBAL A(>> 5)\n"},
+		{`10 X := 5: Foo RECEIVE X
+		  20 A$ := "x": Bar RECEIVE A$
+		  30 PRINT X; "["; A$; "]"
+		  40 END
+		  50 PROCEDURE Foo RETURN Y
+		  60 ENDPROC
+		  70 PROCEDURE Bar RETURN B$
+		  80 ENDPROC`, "0[]\n"},
+	}
+
+	for _, tt := range tests {
+		got := testRun(tt.program, "")
+		if !strings.HasPrefix(got, tt.expected) {
+			t.Errorf("wrong output for %q, got %q, want %q", tt.program, got, tt.expected)
+		}
+	}
+}
+
+func TestRecursion(t *testing.T) {
+	tests := []struct {
+		program  string
+		expected string
+	}{
+		{`10 PRINT Fact%(3)
+		  20 Count 2
+		  30 END
+		  40 FUNCTION Fact%(N%)
+		  50 IF N% <= 1 THEN RESULT 1
+		  60 RESULT N% * Fact%(N% - 1)
+		  70 ENDFUN
+		  80 PROCEDURE Count N
+		  90 IF N = 0 THEN LEAVE
+		  100 PRINT N
+		  110 Count N - 1
+		  120 PRINT N
+		  130 ENDPROC`, "6\n2\n1\n1\n2\n"},
+		{`10 Down 1
+		  20 END
+		  30 PROCEDURE Down N
+		  40 PRINT N
+		  50 Down N + 1
+		  60 ENDPROC`, "1\n2\n3\nFunction nesting too deep in line 50\n50 Down N + 1\nDown called from line 50\nDown called from line 50\nDown called from line 10\n"},
+		{`10 PRINT Twice(2)
+		  20 END
+		  30 FUNCTION Twice(N)
+		  40 RESULT Half(N) * 4
+		  50 ENDFUN
+		  60 FUNCTION Half(N)
+		  70 RESULT N + "A"
+		  80 ENDFUN`, "Invalid expression found (type mismatch: NUMERIC + STRING) in line 70\n70 RESULT N + \"A\"\nHalf called from line 40\nTwice called from line 10\n"},
+		{`10 PRINT Nothing(1)
+		  20 END
+		  30 FUNCTION Nothing(N)
+		  40 ENDPROC`, "Need RESULT to exit function in line 10\n"},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		g := game.New(console.NewHeadless(strings.NewReader(""), &out))
+		g.Config.MaxCallDepth = 3
+		env := testStore(g, tt.program)
+		Eval(g, &ast.RunStatement{}, env)
+		if !strings.HasPrefix(out.String(), tt.expected) {
+			t.Errorf("wrong output for %q, got %q, want %q", tt.program, out.String(), tt.expected)
+		}
+	}
+}
+
+func TestMaxCallDepth(t *testing.T) {
+	tests := []struct {
+		config   int
+		expected int
+	}{
+		{0, game.DefaultMaxCallDepth},
+		{3, 3},
+		{game.MaxCallDepthLimit, game.MaxCallDepthLimit},
+		{game.MaxCallDepthLimit + 1, game.MaxCallDepthLimit},
+	}
+
+	for _, tt := range tests {
+		g := game.New(console.NewHeadless(strings.NewReader(""), &bytes.Buffer{}))
+		g.Config.MaxCallDepth = tt.config
+		if got := maxCallDepth(g); got != tt.expected {
+			t.Errorf("maxcalldepth %d gave %d, want %d", tt.config, got, tt.expected)
+		}
 	}
 }
@@ -8,29 +8,31 @@ import (
 	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/game/examples/resources/images"
 )
 
-// WriteExamples writes the example programs and supporting files to the workspace path
-func WriteExamples(workspacePath string) {
-	examples := []struct {
-		filename string
-		program  string
-	}{
-		{
-			filename: "hello.BAS",
-			program: `10 REM Pretty much the simplest RM Basic program
+// Example is an example program and the filename it's saved as in the workspace
+type Example struct {
+	Filename string
+	Program  string
+}
+
+// Examples are the example programs that come bundled with RM BASICx64
+var Examples = []Example{
+	{
+		Filename: "hello.BAS",
+		Program: `10 REM Pretty much the simplest RM Basic program
 20 PRINT "Hello from RM BASICx64`,
-		},
-		{
-			filename: "datatest.BAS",
-			program: `10 FOR I% := 0 to 5
+	},
+	{
+		Filename: "datatest.BAS",
+		Program: `10 FOR I% := 0 to 5
 20   READ A%
 30   PRINT A%
 40   RESTORE 60
 50 NEXT I%
 60 DATA 1,2,3,4`,
-		},
-		{
-			filename: "subroutine.BAS",
-			program: `10 PRINT "This is how subroutines work in RM Basic."
+	},
+	{
+		Filename: "subroutine.BAS",
+		Program: `10 PRINT "This is how subroutines work in RM Basic."
 20 GOSUB Second_Bus
 30 GOSUB First_Bus
 40 GOTO 110
@@ -41,19 +43,19 @@ func WriteExamples(workspacePath string) {
 90 PRINT "This is the second bus"
 100 RETURN
 110 PRINT "Naturally, the second bus goes first lol."`,
-		},
-		{
-			filename: "function.BAS",
-			program: `10 PRINT "This is how functions work in RM Basic."
+	},
+	{
+		Filename: "function.BAS",
+		Program: `10 PRINT "This is how functions work in RM Basic."
 20 PRINT Add_Ten(110)
 30 END : REM Function definitions cannot be executed
 40 FUNCTION Add_Ten(Number%)
 50    RESULT Number% + 10
 60 ENDFUN`,
-		},
-		{
-			filename: "procedure.BAS",
-			program: `10 PRINT "This is how procedures work in RM Basic."
+	},
+	{
+		Filename: "procedure.BAS",
+		Program: `10 PRINT "This is how procedures work in RM Basic."
 20 Say_Hello
 30 Say_Goodbye
 40 Shout_Message "Hellooo!!!", 4, 2
@@ -74,10 +76,10 @@ func WriteExamples(workspacePath string) {
 190   PRINT "B: "; B
 200   C = A + B
 210 ENDPROC`,
-		},
-		{
-			filename: "hello2.BAS",
-			program: `10 REM A slightly more intereting way to say hello
+	},
+	{
+		Filename: "hello2.BAS",
+		Program: `10 REM A slightly more intereting way to say hello
 20 SET MODE 40
 30 SET BORDER 1 : SET PAPER 5 : CLS
 40 PLOT "Greetings!", 45, 150 SIZE 3 BRUSH 0
@@ -85,10 +87,10 @@ func WriteExamples(workspacePath string) {
 60 PLOT "Welcome to", 120, 120 BRUSH 14
 70 PLOT "RM BASICx64", 30, 50 SIZE 3, 4 BRUSH 0
 80 PLOT "RM BASICx64", 31, 51 SIZE 3, 4`,
-		},
-		{
-			filename: "mouse.BAS",
-			program: `10 REM A very, very simple drawing program
+	},
+	{
+		Filename: "mouse.BAS",
+		Program: `10 REM A very, very simple drawing program
 20 SET MODE 40
 30 PRINT "Click any mouse button to quit"
 40 SET MOUSE
@@ -96,10 +98,10 @@ func WriteExamples(workspacePath string) {
 60   ASK MOUSE Xpos%, Ypos%, Button%
 70   POINTS Xpos%, Ypos% BRUSH 13 STYLE 2
 80 UNTIL Button% > 0`,
-		},
-		{
-			filename: "meltdown.BAS",
-			program: `10 REM Write a flashing yellow warning message
+	},
+	{
+		Filename: "meltdown.BAS",
+		Program: `10 REM Write a flashing yellow warning message
 20 REM on a dark grey background in hi-res mode
 30 SET MODE 80
 40 SET COLOUR 0 TO 8
@@ -108,10 +110,10 @@ func WriteExamples(workspacePath string) {
 70 SET PEN 1 : PRINT "WARNING - Imminent meltdown!"
 80 SET PEN 2 : PRINT "Evacuate to at least 100 km distance immediately."
 90 SET PEN 3 : PRINT "Good luck and have a nice day."`,
-		},
-		{
-			filename: "mandelbrot.BAS",
-			program: `10 REM Render the Mandelbrot set
+	},
+	{
+		Filename: "mandelbrot.BAS",
+		Program: `10 REM Render the Mandelbrot set
 20 REM Adapted from https://rosettacode.org/wiki/Mandelbrot_set#BASIC
 30 SET MODE 40 : SET BORDER 1
 40 Maxiteration% := 150
@@ -135,10 +137,10 @@ func WriteExamples(workspacePath string) {
 220 NEXT X0
 230 PLOT "The Mandelbrot Set", 90, 2 BRUSH 1
 240 PLOT "The Mandelbrot Set", 91, 3 BRUSH 13`,
-		},
-		{
-			filename: "meme.BAS",
-			program: `10 REM RM Basic Meme Generator
+	},
+	{
+		Filename: "meme.BAS",
+		Program: `10 REM RM Basic Meme Generator
 20 SET MODE 40 : SET BORDER 15
 30 FETCH 0, "meme.jpg"
 40 WRITEBLOCK 0, 0, 0, -1
@@ -159,10 +161,10 @@ func WriteExamples(workspacePath string) {
 190   PLOT Text$, X%, Y% SIZE 2, 4 BRUSH 15 FONT 1
 200 ENDPROC
 `,
-		},
-		{
-			filename: "globals.BAS",
-			program: `10 GLOBAL Is_Global_Var%
+	},
+	{
+		Filename: "globals.BAS",
+		Program: `10 GLOBAL Is_Global_Var%
 20 Is_Global_Var% := 10
 30 Is_Local_Var% := 20
 40 PRINT "Main: Is_Global_Var% = "; Is_Global_Var%
@@ -180,10 +182,10 @@ func WriteExamples(workspacePath string) {
 170   PRINT "  Test_Globals: Is_Local_Var% = "; Is_Local_Var%
 180 ENDPROC
 `,
-		},
-		{
-			filename: "procrefs.BAS",
-			program: `10 DIM Test%(10)
+	},
+	{
+		Filename: "procrefs.BAS",
+		Program: `10 DIM Test%(10)
 11 Test%(1) := 1000
 15 Do_Stuff Test%()
 16 PRINT "Main: Test%(2) = "; Test%(2)
@@ -197,20 +199,20 @@ func WriteExamples(workspacePath string) {
 70   RESULT Stuff%(0) + 1000
 80 ENDFUN
 `,
-		},
-		{
-			filename: "funcrefs.BAS",
-			program: `10 DIM Test%(10)
+	},
+	{
+		Filename: "funcrefs.BAS",
+		Program: `10 DIM Test%(10)
 15 PRINT "Get_Stuff(Test%()) = "; Get_Stuff(Test%())
 20 END
 60 FUNCTION Get_Stuff(Stuff%())
 70   RESULT Stuff%(5) + 1000
 80 ENDFUN
 `,
-		},
-		{
-			filename: "music1.BAS",
-			program: `10 SET SOUND TRUE
+	},
+	{
+		Filename: "music1.BAS",
+		Program: `10 SET SOUND TRUE
 20 DIM First%(53), Second%(53)
 30 FOR I% = 0 TO 53
 40   READ First%(I%)
@@ -234,20 +236,20 @@ func WriteExamples(workspacePath string) {
 220   NOTE PITCH (0, Second%(K%)), Second%(K% + 1), Second%(K% + 2)
 230 NEXT K%
 `,
-		},
-		{
-			filename: "music2.BAS",
-			program: `10 SET SOUND TRUE
+	},
+	{
+		Filename: "music2.BAS",
+		Program: `10 SET SOUND TRUE
 20 SET ENVELOPE 5 TO 10, 15; 0, 15; 0, 15; 10 
 30 SET ENVELOPE 5
 40 FOR I% = 1 TO 30
 50   NOTE PITCH (RND(3), RND(11))
 60 NEXT I%
 `,
-		},
-		{
-			filename: "music3.BAS",
-			program: `10 SET SOUND TRUE
+	},
+	{
+		Filename: "music3.BAS",
+		Program: `10 SET SOUND TRUE
 20 SET SOUND TRUE
 30 DIM First%(35), Second%(35)
 40 FOR I% = 0 TO 35
@@ -271,10 +273,10 @@ func WriteExamples(workspacePath string) {
 220   NOTE PITCH(0, Second%(K%)) ENVELOPE Second%(K% + 1)
 230 NEXT K%
 `,
-		},
-		{
-			filename: "lathe.BAS",
-			program: `10 REM *****************************
+	},
+	{
+		Filename: "lathe.BAS",
+		Program: `10 REM *****************************
 20 REM *                           *
 30 REM * Lathe Simulation Program. *
 40 REM * 1986-1987 (C) Rob Baines. *
@@ -691,18 +693,30 @@ func WriteExamples(workspacePath string) {
 4110 Ra := Ro(N) - H1 + H
 4120 ENDPROC 
 `,
-		},
+	},
+}
+
+// Get returns the example program saved as filename
+func Get(filename string) (string, bool) {
+	for _, example := range Examples {
+		if example.Filename == filename {
+			return example.Program, true
+		}
 	}
+	return "", false
+}
 
-	for _, example := range examples {
-		fullpath := filepath.Join(workspacePath, example.filename)
+// WriteExamples writes the example programs and supporting files to the workspace path
+func WriteExamples(workspacePath string) {
+	for _, example := range Examples {
+		fullpath := filepath.Join(workspacePath, example.Filename)
 		file, err := os.Create(fullpath)
 		if err != nil {
-			log.Printf("Error creating example program %q - %e", example.filename, err)
+			log.Printf("Error creating example program %q - %e", example.Filename, err)
 			continue
 		}
 		defer file.Close()
-		file.WriteString(example.program)
+		file.WriteString(example.Program)
 	}
 
 	// Images
@@ -5,37 +5,63 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/console"
 	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/game/examples"
-	"github.com/adamstimb/rmbasicx64/pkg/nimgobus"
-	"github.com/hajimehoshi/ebiten/v2"
 	"gopkg.in/yaml.v3"
 )
 
 type AppConfig struct {
-	Boot bool
+	Boot         bool
+	MaxCallDepth int // Number of procedure and function calls that can be nested
 }
 
+// DefaultMaxCallDepth is used when the config doesn't set MaxCallDepth
+const DefaultMaxCallDepth = 10000
+
+// MaxCallDepthLimit is the most calls that can be nested whatever the config says.  Each
+// call nests the Go functions that evaluate it, which takes from about 2KB of Go's 1GB stack
+// for a procedure call to about 8KB for a function call buried in brackets, so this leaves
+// plenty of room before the stack runs out.
+const MaxCallDepthLimit = 50000
+
+// FileObj describes a file object and whether its for writing or reading
+type FileObj struct {
+	File    *os.File
+	Writing bool
+}
+
+// Game holds everything the interpreter needs from the outside world.  All screen,
+// keyboard, mouse and sound I/O goes through the embedded Console.
 type Game struct {
-	Count int
-	nimgobus.Nimbus
+	console.Console
 	Config AppConfig
 	//PrettyPrintIndent string
-	PaddingX      int
-	PaddingY      int
-	Scale         float64
 	WorkspacePath string
+	FileChannels  map[int]*FileObj // File channels and their objects are stored here when they're opened/created
+	History       []string         // Commands typed at the prompt, oldest first
 }
 
-func (g *Game) GetTPS() int {
-	return int(ebiten.CurrentTPS())
+// historyFile is the file in the workspace where the History is kept between sessions
+const historyFile = ".history"
+
+// maxHistory is the number of commands kept in the History
+const maxHistory = 500
+
+// New returns a Game that does its I/O through c
+func New(c console.Console) *Game {
+	return &Game{
+		Console:      c,
+		FileChannels: make(map[int]*FileObj),
+	}
 }
 
 // LoadConfig attempts to load settings from the config file.  If the file does not
 // exist or is unreadable it will be ignored and default settings will be used.
 func (g *Game) LoadConfig() {
 	// Default settings
-	g.Config = AppConfig{Boot: true}
+	g.Config = AppConfig{Boot: true, MaxCallDepth: DefaultMaxCallDepth}
 	// Attempt to load settings from config file
 	c, err := g.ReadConf()
 	if err == nil {
@@ -71,7 +97,7 @@ func (g *Game) ReadConf() (AppConfig, error) {
 		log.Fatalf("Error resolving directory of executable: %v", err)
 	}
 	// If the config file doesn't exist, create one with default settings
-	c := AppConfig{Boot: true}
+	c := AppConfig{Boot: true, MaxCallDepth: DefaultMaxCallDepth}
 	configPath := filepath.Join(exeDir, "rmbasicx64config.yaml")
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
 		g.WriteConf(c)
@@ -98,11 +124,50 @@ func (g *Game) ReadConf() (AppConfig, error) {
 			if ok {
 				c.Boot = bootVal
 			}
+		case "maxcalldepth":
+			depth, ok := v.(int)
+			if ok && depth > 0 {
+				c.MaxCallDepth = depth
+			}
 		}
 	}
 	return c, nil
 }
 
+// LoadHistory reads the History from the workspace.  If there isn't one yet the History is
+// left empty.
+func (g *Game) LoadHistory() {
+	data, err := ioutil.ReadFile(filepath.Join(g.WorkspacePath, historyFile))
+	if err != nil {
+		return
+	}
+	g.History = []string{}
+	for _, command := range strings.Split(string(data), "\n") {
+		if command != "" {
+			g.History = append(g.History, command)
+		}
+	}
+}
+
+// AddHistory adds a command to the History and saves it in the workspace.  Empty commands
+// and repeats of the last command aren't added.
+func (g *Game) AddHistory(command string) {
+	if command == "" || (len(g.History) > 0 && g.History[len(g.History)-1] == command) {
+		return
+	}
+	g.History = append(g.History, command)
+	if len(g.History) > maxHistory {
+		g.History = g.History[len(g.History)-maxHistory:]
+	}
+	if g.WorkspacePath == "" {
+		return
+	}
+	data := strings.Join(g.History, "\n") + "\n"
+	if err := ioutil.WriteFile(filepath.Join(g.WorkspacePath, historyFile), []byte(data), 0666); err != nil {
+		log.Printf("Error saving history: %v", err)
+	}
+}
+
 // EnsureWorkspace makes sure the workspace folder exists and sets its location
 func (g *Game) EnsureWorkspace() {
 	// Is workspace set in the env var?
@@ -131,49 +196,3 @@ func (g *Game) EnsureWorkspace() {
 		log.Fatalf("Error setting working directory to %q: %v", workspacePath, err)
 	}
 }
-
-func (g *Game) Update() error {
-	g.Nimbus.Update(g.PaddingX, g.PaddingY, g.Scale)
-	return nil
-}
-
-func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
-	return outsideWidth, outsideHeight
-}
-
-func (g *Game) Draw(screen *ebiten.Image) {
-	// Draw the Nimbus monitor on the screen and scale to current window size.
-	monitorWidth, monitorHeight := g.Monitor.Size()
-
-	// Get ebiten window size so we can scale the Nimbus screen up or down
-	// but if (0, 0) is returned we're not running on a desktop so don't do any scaling
-	windowWidth, windowHeight := ebiten.WindowSize()
-
-	// Calculate aspect ratios of Nimbus monitor and ebiten screen
-	monitorRatio := float64(monitorWidth) / float64(monitorHeight)
-	windowRatio := float64(windowWidth) / float64(windowHeight)
-
-	// If windowRatio > monitorRatio then clamp monitorHeight to windowHeight otherwise
-	// clamp monitorWidth to screenWidth
-	var scale, offsetX, offsetY float64
-	switch {
-	case windowRatio > monitorRatio:
-		scale = float64(windowHeight) / float64(monitorHeight)
-		offsetX = (float64(windowWidth) - float64(monitorWidth)*scale) / 2
-		offsetY = 0
-	case windowRatio <= monitorRatio:
-		scale = float64(windowWidth) / float64(monitorWidth)
-		offsetX = 0
-		offsetY = (float64(windowHeight) - float64(monitorHeight)*scale) / 2
-	}
-	g.PaddingX = int(offsetX)
-	g.PaddingY = int(offsetY)
-	g.Scale = scale
-
-	// Apply scale and centre monitor on screen
-	op := &ebiten.DrawImageOptions{}
-	op.GeoM.Scale(scale, scale)
-	op.Filter = ebiten.FilterLinear
-	op.GeoM.Translate(offsetX, offsetY)
-	screen.DrawImage(g.Monitor, op)
-}
@@ -0,0 +1,35 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/console"
+)
+
+func TestHistory(t *testing.T) {
+	g := New(console.NewHeadless(strings.NewReader(""), &strings.Builder{}))
+	g.WorkspacePath = t.TempDir()
+	for _, command := range []string{"LIST", "", "RUN", "RUN", "SET WRITING 1, 1, 1, 40, 10", "LIST"} {
+		g.AddHistory(command)
+	}
+	expected := []string{"LIST", "RUN", "SET WRITING 1, 1, 1, 40, 10", "LIST"}
+	if fmt.Sprint(g.History) != fmt.Sprint(expected) {
+		t.Errorf("history is %q, want %q", g.History, expected)
+	}
+	// The history is kept in the workspace for the next session
+	next := New(console.NewHeadless(strings.NewReader(""), &strings.Builder{}))
+	next.WorkspacePath = g.WorkspacePath
+	next.LoadHistory()
+	if fmt.Sprint(next.History) != fmt.Sprint(expected) {
+		t.Errorf("loaded history is %q, want %q", next.History, expected)
+	}
+	// Only the newest commands are kept
+	for i := 0; i < maxHistory+10; i++ {
+		next.AddHistory(fmt.Sprintf("PRINT %d", i))
+	}
+	if len(next.History) != maxHistory || next.History[0] != "PRINT 10" {
+		t.Errorf("history has %d commands starting with %q, want %d starting with %q", len(next.History), next.History[0], maxHistory, "PRINT 10")
+	}
+}
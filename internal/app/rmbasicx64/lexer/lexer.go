@@ -15,6 +15,7 @@ import (
 type Lexer struct {
 	Source               string        // source code string
 	Tokens               []token.Token // buffer of tokens created by Scan()
+	TokenEnds            []int         // position in the string just after each token
 	CurrentPosition      int           // position in the string
 	currentTokenPosition int           // position of the buffer
 }
@@ -72,6 +73,7 @@ func (s *Lexer) match(r rune) bool {
 func (s *Lexer) addToken(TokenType string, literal string) {
 	index := len(s.Tokens)
 	s.Tokens = append(s.Tokens, token.Token{TokenType: TokenType, Literal: literal, Index: index})
+	s.TokenEnds = append(s.TokenEnds, s.CurrentPosition)
 	//token.PrintToken(token.Token{TokenType: TokenType, Literal: literal, Index: index})
 }
 
@@ -166,25 +168,39 @@ func (s *Lexer) getNumber(firstRune rune) {
 
 // This is a bit hacky:
 var Builtins = map[string]string{
-	"LEN":    "LEN",
-	"ABS":    "ABS",
-	"ATN":    "ATN",
-	"COS":    "COS",
-	"EXP":    "EXP",
-	"INT":    "INT",
-	"LN":     "LN",
-	"LOG":    "LOG",
-	"RND":    "RND",
-	"SGN":    "SGN",
-	"SIN":    "SIN",
-	"SQR":    "SQR",
-	"TAN":    "TAN",
-	"GET":    "GET",
-	"LOOKUP": "LOOKUP",
-	"PATH$":  "PATH$",
-	"STR$":   "STR$",
-	"CHR$":   "CHR$",
-	"PITCH":  "PITCH",
+	"LEN":     "LEN",
+	"ABS":     "ABS",
+	"ATN":     "ATN",
+	"COS":     "COS",
+	"EXP":     "EXP",
+	"INT":     "INT",
+	"LN":      "LN",
+	"LOG":     "LOG",
+	"RND":     "RND",
+	"SGN":     "SGN",
+	"SIN":     "SIN",
+	"SQR":     "SQR",
+	"TAN":     "TAN",
+	"GET":     "GET",
+	"LOOKUP":  "LOOKUP",
+	"PATH$":   "PATH$",
+	"STR$":    "STR$",
+	"CHR$":    "CHR$",
+	"PITCH":   "PITCH",
+	"ERR":     "ERR",
+	"ERL":     "ERL",
+	"ERR$":    "ERR$",
+	"LEFT$":   "LEFT$",
+	"RIGHT$":  "RIGHT$",
+	"MID$":    "MID$",
+	"INSTR":   "INSTR",
+	"VAL":     "VAL",
+	"ASC":     "ASC",
+	"HEX$":    "HEX$",
+	"STRING$": "STRING$",
+	"DATE$":   "DATE$",
+	"TIME$":   "TIME$",
+	"TIME":    "TIME",
 }
 
 // getIdentifier extracts an identifier (keyword, variable, etc) from the source code
@@ -202,7 +218,11 @@ func (s *Lexer) getIdentifier(firstRune rune) {
 	// get the type, if any)
 	if token.IsKeyword(strings.ToUpper(string(stringVal))) {
 		// is a keyword but if it corresponds to a built-in function we have to
-		// bump it to identifier literal
+		// bump it to identifier literal.  Some string functions share their name
+		// with a keyword (e.g. ERR and ERR$) so check for a trailing $ first.
+		if _, ok := Builtins[strings.ToUpper(string(stringVal))+"$"]; ok && s.peek() == '$' {
+			stringVal = append(stringVal, s.advance())
+		}
 		_, ok := Builtins[strings.ToUpper(string(stringVal))]
 		if ok {
 			// is built-in
@@ -405,6 +425,7 @@ func (s *Lexer) scanToken() {
 func (s *Lexer) Scan(source string) []token.Token {
 	s.Source = source
 	s.Tokens = []token.Token{}
+	s.TokenEnds = []int{}
 	s.CurrentPosition = 0
 	// Handle special case of only whitespace as input
 	if strings.TrimSpace(s.Source) == "" {
@@ -38,7 +38,7 @@ func TestLexer(t *testing.T) {
 			Source: "Print \"So-called \"\"test\"\" this is\"",
 			ExpectedTokens: []token.Token{
 				{TokenType: token.PRINT, Literal: "PRINT", Index: 0},
-				{TokenType: token.StringLiteral, Literal: "So-called \"\"test\"\" this is", Index: 1},
+				{TokenType: token.StringLiteral, Literal: "So-called \"test\" this is", Index: 1},
 				{TokenType: token.EOF, Literal: token.EOF, Index: 2},
 			},
 		},
@@ -0,0 +1,80 @@
+package rmbasicx64
+
+import (
+	"github.com/adamstimb/rmbasicx64/pkg/nimgobus"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// nimbusConsole is the ebiten-backed console.Console.  It wraps a nimgobus.Nimbus and
+// implements ebiten.Game so it can be passed to ebiten.RunGame.
+type nimbusConsole struct {
+	nimgobus.Nimbus
+	PaddingX int
+	PaddingY int
+	Scale    float64
+}
+
+// AskMouse returns the mouse position and button press
+func (n *nimbusConsole) AskMouse() (x, y, button int) {
+	return n.MouseX, n.MouseY, n.MouseButton
+}
+
+// AskBreak returns true if the user has made a <BREAK>
+func (n *nimbusConsole) AskBreak() bool {
+	return n.BreakInterruptDetected
+}
+
+// SetBreak sets or clears the <BREAK> flag
+func (n *nimbusConsole) SetBreak(b bool) {
+	n.BreakInterruptDetected = b
+}
+
+func (n *nimbusConsole) GetTPS() int {
+	return int(ebiten.CurrentTPS())
+}
+
+func (n *nimbusConsole) Update() error {
+	n.Nimbus.Update(n.PaddingX, n.PaddingY, n.Scale)
+	return nil
+}
+
+func (n *nimbusConsole) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return outsideWidth, outsideHeight
+}
+
+func (n *nimbusConsole) Draw(screen *ebiten.Image) {
+	// Draw the Nimbus monitor on the screen and scale to current window size.
+	monitorWidth, monitorHeight := n.Monitor.Size()
+
+	// Get ebiten window size so we can scale the Nimbus screen up or down
+	// but if (0, 0) is returned we're not running on a desktop so don't do any scaling
+	windowWidth, windowHeight := ebiten.WindowSize()
+
+	// Calculate aspect ratios of Nimbus monitor and ebiten screen
+	monitorRatio := float64(monitorWidth) / float64(monitorHeight)
+	windowRatio := float64(windowWidth) / float64(windowHeight)
+
+	// If windowRatio > monitorRatio then clamp monitorHeight to windowHeight otherwise
+	// clamp monitorWidth to screenWidth
+	var scale, offsetX, offsetY float64
+	switch {
+	case windowRatio > monitorRatio:
+		scale = float64(windowHeight) / float64(monitorHeight)
+		offsetX = (float64(windowWidth) - float64(monitorWidth)*scale) / 2
+		offsetY = 0
+	case windowRatio <= monitorRatio:
+		scale = float64(windowWidth) / float64(monitorWidth)
+		offsetX = 0
+		offsetY = (float64(windowHeight) - float64(monitorHeight)*scale) / 2
+	}
+	n.PaddingX = int(offsetX)
+	n.PaddingY = int(offsetY)
+	n.Scale = scale
+
+	// Apply scale and centre monitor on screen
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(scale, scale)
+	op.Filter = ebiten.FilterLinear
+	op.GeoM.Translate(offsetX, offsetY)
+	screen.DrawImage(n.Monitor, op)
+}
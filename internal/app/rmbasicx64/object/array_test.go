@@ -8,12 +8,15 @@ func TestCalculateAddressFromArraySubscripts(t *testing.T) {
 		subscripts []int
 		expected   int
 	}{
-		{[]int{10}, []int{9}, 10},
-		{[]int{10}, []int{0}, 1},
-		{[]int{10}, []int{5}, 6},
+		{[]int{10}, []int{9}, 9},
+		{[]int{10}, []int{0}, 0},
+		{[]int{10}, []int{5}, 5},
 		{[]int{5, 2}, []int{0, 0}, 0},
-		{[]int{5, 2}, []int{4, 1}, 10},
-		{[]int{10, 2}, []int{9, 1}, 20},
+		{[]int{5, 2}, []int{4, 1}, 13},
+		{[]int{5, 2}, []int{5, 0}, 15},
+		{[]int{5, 2}, []int{5, 2}, 17},
+		{[]int{10, 2}, []int{9, 1}, 28},
+		{[]int{2, 3, 4}, []int{1, 2, 3}, 33},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,48 @@
+package object
+
+import "time"
+
+// Clock is where DATE$, TIME$ and TIME get the time from.  The system clock is used unless
+// another Clock is set, e.g. so that tests can control the time.
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is the host's clock
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// clockState is the clock used by a program along with the adjustment made by SET DATE and
+// SET TIME and the time the interpreter started
+type clockState struct {
+	clock   Clock
+	offset  time.Duration
+	started time.Time
+}
+
+// SetClock changes the clock and resets the start time used by TIME
+func (e *Environment) SetClock(c Clock) {
+	e.root().clock = clockState{clock: c, started: c.Now()}
+}
+
+// Now returns the date and time as set by SET DATE and SET TIME
+func (e *Environment) Now() time.Time {
+	c := e.root().clock
+	return c.clock.Now().Add(c.offset)
+}
+
+// SetNow adjusts the date and time returned by Now
+func (e *Environment) SetNow(t time.Time) {
+	c := &e.root().clock
+	c.offset = t.Sub(c.clock.Now())
+}
+
+// SinceStarted returns how long the interpreter has been running, which isn't affected by
+// SET DATE or SET TIME
+func (e *Environment) SinceStarted() time.Duration {
+	c := e.root().clock
+	return c.clock.Now().Sub(c.started)
+}
@@ -3,7 +3,9 @@ package object
 import (
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/ast"
 	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/lexer"
@@ -13,18 +15,28 @@ import (
 
 type program struct {
 	lines                  map[int]string
+	parsedLines            map[int]*ast.Line // Cache of lines that have already been parsed
 	sortedIndex            []int
+	lineIndex              map[int]int // Position of each line number in sortedIndex
 	curLineIndex           int
 	JumpToStatement        int
 	CurrentStatementNumber int
+	jumped                 bool
+	version                int  // Changes whenever the program is edited so a stopped program can't be CONTINUEd
+	elseIfPending          bool // True when JumpToElseIf has jumped to an ELSE IF that hasn't been reached yet
+	elseIfLineNumber       int
+	elseIfStatement        int
 }
 
 func (p *program) New() {
 	p.lines = make(map[int]string)
+	p.parsedLines = make(map[int]*ast.Line)
 	p.sortedIndex = []int{}
+	p.lineIndex = make(map[int]int)
 	p.curLineIndex = 0
 	p.JumpToStatement = 0
 	p.CurrentStatementNumber = 0
+	p.version++
 }
 func (p *program) Sort() {
 	keys := []int{}
@@ -33,40 +45,90 @@ func (p *program) Sort() {
 	}
 	sort.Ints(keys)
 	p.sortedIndex = keys
+	p.buildLineIndex()
+}
+
+// buildLineIndex maps each line number to its position in sortedIndex so that Jump doesn't
+// have to search for it
+func (p *program) buildLineIndex() {
+	p.lineIndex = make(map[int]int, len(p.sortedIndex))
+	for i, lineNumber := range p.sortedIndex {
+		p.lineIndex[lineNumber] = i
+	}
 }
 func (p *program) Start() {
 	p.curLineIndex = 0
 	p.JumpToStatement = 0
 	p.CurrentStatementNumber = 0
+	p.jumped = false
+	p.elseIfPending = false
 }
+
+// Next moves on to the next line.  If the move follows a Jump then JumpToStatement is kept so
+// that execution resumes from the statement that was jumped to.
 func (p *program) Next() {
 	p.curLineIndex += 1
-	p.JumpToStatement = 0
+	if !p.jumped {
+		p.JumpToStatement = 0
+	}
 	p.CurrentStatementNumber = 0
+	p.jumped = false
+}
+
+// Jumped returns true if there has been a Jump since the last call to Next, which means the
+// rest of the statements in the current line must not be executed.
+func (p *program) Jumped() bool {
+	return p.jumped
 }
 
 // Jump is used to resume program execution from a specific line number and statement within
 // that line.  Jump allows GOTO, GOSUB, FOR, WHILE and PROCEDURE/FUNCTION calls to be implemented.
 func (p *program) Jump(lineNumber int, statementIndex int) bool {
-	// Go to top of program and search for the required lineNumber and set JumpToStatement
-	// if found
-	currentLocation := p.curLineIndex
-	p.Start()
-	for !p.EndOfProgram() {
-		if p.GetLineNumber() == lineNumber {
-			// Found lineNumber, so back up the current location and we're done
-			p.curLineIndex -= 1
-			p.JumpToStatement = statementIndex
-			return true
-		} else {
-			// Try next line
-			p.Next()
-		}
+	// Look up the required lineNumber and set JumpToStatement if found
+	i, ok := p.lineIndex[lineNumber]
+	if !ok {
+		// Failed to find lineNumber so stay where we are and return false.
+		return false
 	}
-	// Failed to find lineNumber.  Return to original location and
-	// return false.
-	p.curLineIndex = currentLocation
-	return false
+	// Found lineNumber, so back up the current location and we're done
+	p.curLineIndex = i - 1
+	p.CurrentStatementNumber = 0
+	p.JumpToStatement = statementIndex
+	p.jumped = true
+	return true
+}
+
+// JumpToElseIf jumps to the ELSE IF at lineNumber and statementNumber when the condition of
+// the branch before it is false.  Reaching it tests its condition rather than ending the
+// branch before it.
+func (p *program) JumpToElseIf(lineNumber, statementNumber int) bool {
+	if !p.Jump(lineNumber, statementNumber) {
+		return false
+	}
+	p.elseIfPending = true
+	p.elseIfLineNumber = lineNumber
+	p.elseIfStatement = statementNumber
+	return true
+}
+
+// ReachedElseIf returns true if the current statement is the ELSE IF that JumpToElseIf
+// jumped to.  It only returns true once for each jump.
+func (p *program) ReachedElseIf() bool {
+	if !p.elseIfPending || p.GetLineNumber() != p.elseIfLineNumber || p.CurrentStatementNumber != p.elseIfStatement {
+		return false
+	}
+	p.elseIfPending = false
+	return true
+}
+
+// ClearElseIf forgets the ELSE IF that JumpToElseIf jumped to, for when it won't be reached
+func (p *program) ClearElseIf() {
+	p.elseIfPending = false
+}
+
+// LinesFromCurrent returns the line numbers from the current line to the end of the program
+func (p *program) LinesFromCurrent() []int {
+	return p.sortedIndex[p.curLineIndex:]
 }
 func (p *program) GetLineNumber() int {
 	if len(p.lines) > 0 {
@@ -82,6 +144,40 @@ func (p *program) GetLine() string {
 		return ""
 	}
 }
+
+// GetParsedLine returns the cached AST of the current line, if it has been parsed before
+func (p *program) GetParsedLine() (*ast.Line, bool) {
+	if len(p.lines) == 0 {
+		return nil, false
+	}
+	line, ok := p.parsedLines[p.sortedIndex[p.curLineIndex]]
+	return line, ok
+}
+
+// SetParsedLine caches the AST of the current line so it doesn't have to be parsed again
+func (p *program) SetParsedLine(line *ast.Line) {
+	if len(p.lines) == 0 {
+		return
+	}
+	if p.parsedLines == nil {
+		p.parsedLines = make(map[int]*ast.Line)
+	}
+	p.parsedLines[p.sortedIndex[p.curLineIndex]] = line
+}
+
+// GetParsedLineNumber returns the cached AST of any line, if it has been parsed before
+func (p *program) GetParsedLineNumber(lineNumber int) (*ast.Line, bool) {
+	line, ok := p.parsedLines[lineNumber]
+	return line, ok
+}
+
+// SetParsedLineNumber caches the AST of any line so it doesn't have to be parsed again
+func (p *program) SetParsedLineNumber(lineNumber int, line *ast.Line) {
+	if p.parsedLines == nil {
+		p.parsedLines = make(map[int]*ast.Line)
+	}
+	p.parsedLines[lineNumber] = line
+}
 func (p *program) GetLineForEditing(lineNumber int) (string, bool) {
 	if p.Jump(lineNumber, 0) {
 		return p.lines[p.sortedIndex[p.curLineIndex+1]], true
@@ -90,6 +186,10 @@ func (p *program) GetLineForEditing(lineNumber int) (string, bool) {
 	}
 }
 func (p *program) AddLine(lineNumber int, line string) {
+	// Whatever happens to this line, any cached AST is now stale and a stopped program can't
+	// be continued
+	delete(p.parsedLines, lineNumber)
+	p.version++
 	if line == "" {
 		// delete line if it exists
 		delete(p.lines, lineNumber)
@@ -100,6 +200,20 @@ func (p *program) AddLine(lineNumber int, line string) {
 	}
 	p.Indent()
 }
+
+// Delete deletes the lines from fromLineNumber to toLineNumber.  Either can be 0 to leave that
+// end of the range open, as with List.
+func (p *program) Delete(fromLineNumber, toLineNumber int) {
+	for lineNumber := range p.lines {
+		if lineNumber >= fromLineNumber && (toLineNumber == 0 || lineNumber <= toLineNumber) {
+			delete(p.lines, lineNumber)
+			delete(p.parsedLines, lineNumber)
+		}
+	}
+	p.version++
+	p.Sort()
+	p.Indent()
+}
 func (p *program) EndOfProgram() bool {
 	if p.curLineIndex >= len(p.lines) {
 		// end of program
@@ -143,24 +257,104 @@ func (p *program) List(fromLinenumber, toLinenumber int, fromLineOnly bool) []st
 	}
 	return listing
 }
-func (p *program) Renumber() {
-	// TODO: Handle line numbers in GOTO statements
-	// TODO: Handle optional params
-	firstLineNumber := 10
-	increment := 10
+
+// lineNumberKeywords are the keywords that can be followed by a line number, which RENUMBER
+// has to update.  THEN and ELSE count because IF X THEN 30 ELSE 40 jumps to those lines.
+var lineNumberKeywords = map[string]bool{
+	token.GOTO:    true,
+	token.GOSUB:   true,
+	token.RESTORE: true,
+	token.RUN:     true,
+	token.EDIT:    true,
+	token.RESUME:  true,
+	token.THEN:    true,
+	token.ELSE:    true,
+}
+
+// Renumber renumbers the lines from fromLineNumber to toLineNumber (or to the end of the
+// program if toLineNumber is 0), starting at firstLineNumber in steps of increment, and updates
+// every reference to them.  Nothing is changed and false is returned if the new line numbers
+// would collide with lines outside the range.
+func (p *program) Renumber(firstLineNumber, increment, fromLineNumber, toLineNumber int) bool {
 	p.Sort()
-	p.Start()
+	newLineNumbers := make(map[int]int)
 	newLineNumber := firstLineNumber
+	lastLineNumber := firstLineNumber
+	for _, lineNumber := range p.sortedIndex {
+		if lineNumber < fromLineNumber || (toLineNumber != 0 && lineNumber > toLineNumber) {
+			continue
+		}
+		newLineNumbers[lineNumber] = newLineNumber
+		lastLineNumber = newLineNumber
+		newLineNumber += increment
+	}
+	if len(newLineNumbers) == 0 {
+		return true
+	}
+	// The renumbered lines have to stay between the lines either side of the range
+	for _, lineNumber := range p.sortedIndex {
+		if _, ok := newLineNumbers[lineNumber]; ok {
+			continue
+		}
+		if lineNumber < fromLineNumber && lineNumber >= firstLineNumber {
+			return false
+		}
+		if lineNumber > fromLineNumber && lineNumber <= lastLineNumber {
+			return false
+		}
+	}
 	// populate new line map then replace the old line map
 	newLines := make(map[int]string)
-	for !p.EndOfProgram() {
-		newLines[newLineNumber] = p.GetLine()
-		newLineNumber += increment
-		p.Next()
+	for lineNumber, line := range p.lines {
+		if n, ok := newLineNumbers[lineNumber]; ok {
+			lineNumber = n
+		}
+		newLines[lineNumber] = renumberReferences(line, newLineNumbers)
 	}
-	p.lines = make(map[int]string)
 	p.lines = newLines
+	p.parsedLines = make(map[int]*ast.Line)
+	p.version++
 	p.Sort()
+	return true
+}
+
+// renumberReferences returns the line with each line number that follows GOTO, GOSUB, etc.
+// replaced by its new line number
+func renumberReferences(line string, newLineNumbers map[int]int) string {
+	l := &lexer.Lexer{}
+	tokens := l.Scan(line)
+	// Work backwards so that replacing a line number doesn't move the ones before it
+	for i := len(tokens) - 1; i > 0; i-- {
+		if tokens[i].TokenType != token.NumericLiteral || !isLineNumberReference(tokens, i) {
+			continue
+		}
+		lineNumber, err := strconv.Atoi(tokens[i].Literal)
+		if err != nil {
+			continue
+		}
+		if n, ok := newLineNumbers[lineNumber]; ok {
+			end := l.TokenEnds[i]
+			start := end - len(tokens[i].Literal)
+			line = line[:start] + strconv.Itoa(n) + line[end:]
+		}
+	}
+	return line
+}
+
+// isLineNumberReference returns true if the numeric literal tokens[i] follows one of the
+// lineNumberKeywords, either directly or in a list such as ON X GOTO 100, 200
+func isLineNumberReference(tokens []token.Token, i int) bool {
+	for i > 0 {
+		if lineNumberKeywords[tokens[i-1].TokenType] {
+			return true
+		}
+		// Step back over the previous line number in the list
+		if i < 2 || tokens[i-1].TokenType != token.Comma || tokens[i-2].TokenType != token.NumericLiteral {
+			return false
+		}
+		i -= 2
+	}
+	return false
 }
 
 // Indent is used to tidy the code and make it easier to read
@@ -173,7 +367,17 @@ func (p *program) Indent() {
 		line := p.lines[p.sortedIndex[i]]
 		l := &lexer.Lexer{}
 		tokens := l.Scan(line)
-		for _, toke := range tokens {
+		if len(tokens) > 0 && tokens[0].TokenType == token.ELSE {
+			// ELSE and ELSE IF line up with the IF of their block
+			indents[i] = strings.TrimPrefix(indents[i], "  ")
+		}
+		if isBlockIf(tokens) {
+			// Increment the indentation level from the next line to the end
+			for j := i + 1; j < len(p.lines); j++ {
+				indents[j] = indents[i] + "  "
+			}
+		}
+		for k, toke := range tokens {
 			tokenType := toke.TokenType
 			switch tokenType {
 			case token.FOR, token.PROCEDURE, token.FUNCTION, token.REPEAT:
@@ -181,10 +385,19 @@ func (p *program) Indent() {
 				for j := i + 1; j < len(p.lines); j++ {
 					indents[j] = indents[i] + "  "
 				}
-			case token.NEXT, token.ENDPROC, token.ENDFUN, token.UNTIL:
-				// Decrement the indentation level from this line to the end
+			case token.NEXT, token.ENDPROC, token.ENDFUN, token.UNTIL, token.ENDIF:
+				// Decrement the indentation level from this line to the end, once for each loop
+				// that NEXT J, I ends
+				levels := 1
+				for n := k + 1; tokenType == token.NEXT && n < len(tokens) && tokens[n].TokenType != token.Colon; n++ {
+					if tokens[n].TokenType == token.Comma {
+						levels++
+					}
+				}
 				for j := i; j < len(p.lines); j++ {
-					indents[j] = strings.TrimPrefix(indents[j], "  ")
+					for level := 0; level < levels; level++ {
+						indents[j] = strings.TrimPrefix(indents[j], "  ")
+					}
 				}
 			}
 		}
@@ -197,6 +410,16 @@ func (p *program) Indent() {
 	p.lines = newProg
 }
 
+// isBlockIf returns true if the tokens of a line start a block IF, i.e. the line starts with IF
+// and ends with THEN
+func isBlockIf(tokens []token.Token) bool {
+	last := len(tokens) - 1
+	for last >= 0 && (tokens[last].TokenType == token.EOF || tokens[last].TokenType == token.NewLine) {
+		last--
+	}
+	return last > 0 && tokens[0].TokenType == token.IF && tokens[last].TokenType == token.THEN
+}
+
 // Dump and Copy are used to transfer the program from one env to another
 func (p *program) Dump() (sortedIndex []int, lines map[int]string) {
 	sortedIndex = p.sortedIndex
@@ -206,6 +429,7 @@ func (p *program) Dump() (sortedIndex []int, lines map[int]string) {
 func (p *program) Copy(sortedIndex []int, lines map[int]string) {
 	p.lines = lines
 	p.sortedIndex = sortedIndex
+	p.buildLineIndex()
 }
 
 // JumpStack is used to store all the return points and parameters for loops and function/procedure calls
@@ -226,6 +450,16 @@ func (j *jumpStack) Peek() interface{} {
 func (j *jumpStack) Push(item interface{}) {
 	j.items = append(j.items, item)
 }
+
+// Len returns the number of items on the stack
+func (j *jumpStack) Len() int {
+	return len(j.items)
+}
+
+// Item returns the item that is depth places below the top of the stack
+func (j *jumpStack) Item(depth int) interface{} {
+	return j.items[len(j.items)-1-depth]
+}
 func (j *jumpStack) Pop() interface{} {
 	if len(j.items) > 0 {
 		item := j.items[len(j.items)-1]
@@ -235,6 +469,59 @@ func (j *jumpStack) Pop() interface{} {
 	return nil
 }
 
+// errorTrap holds the ON ERROR handler and the details of the last error that was trapped
+type errorTrap struct {
+	handler         ast.Statement // GOTO or GOSUB to execute when an error occurs, or nil
+	handling        bool          // True from when an error is trapped until RESUME
+	depth           int           // Number of unfinished procedure and function calls when the trap was set
+	unwinding       *Error        // Error being passed back to the call that set the trap
+	code            int
+	lineNumber      int
+	statementNumber int
+	message         string
+}
+
+// breakTrap holds the ON BREAK handler
+type breakTrap struct {
+	handler  ast.Statement // GOTO or GOSUB to execute when <BREAK> is pressed, or nil
+	handling bool          // True while an ON BREAK GOSUB handler is running
+}
+
+// traceState holds the settings made by SET TRACE
+type traceState struct {
+	on        bool
+	variables bool // Trace assignments to variables as well as lines
+	channel   int  // File channel to write the trace to, or 0 for the screen
+}
+
+// stopState holds where CONTINUE carries on from after the program was stopped by STOP or
+// <BREAK>
+type stopState struct {
+	canContinue     bool
+	version         int // Version of the program when it was stopped
+	lineNumber      int
+	statementNumber int
+	calls           []SuspendedCall // Procedure calls left unfinished, outermost first
+	resuming        []SuspendedCall // Calls that CONTINUE hasn't got back into yet
+}
+
+// SuspendedCall is a procedure call that was left unfinished when the program was stopped.
+// CONTINUE finishes the procedure before carrying on after the call.
+type SuspendedCall struct {
+	Caller                *Environment // Environment the call was made from
+	CallerLineNumber      int          // Line the call was made from
+	CallerStatementNumber int          // Statement in the line the call was made from
+	Callee                *Environment // Environment the procedure was running in
+	LineNumber            int          // Line the procedure carries on from
+	StatementNumber       int          // Statement in the line the procedure carries on from
+}
+
+// CallFrame describes a procedure or function call that hasn't finished yet
+type CallFrame struct {
+	Name       string // Name of the procedure or function
+	LineNumber int    // Line the call was made from
+}
+
 type storeKey struct {
 	Scope  int
 	Name   string
@@ -257,6 +544,13 @@ type Environment struct {
 	procedures          []*ast.ProcedureDeclaration
 	LeaveFunctionSignal bool
 	EndProgramSignal    bool
+	ErrorSignal         bool // Set when a RUN stops because of an error or <BREAK>
+	errorTrap           errorTrap
+	breakTrap           breakTrap
+	clock               clockState
+	trace               traceState
+	callFrames          []CallFrame
+	stop                stopState
 	ReturnVals          []Object
 }
 
@@ -303,6 +597,211 @@ func (e *Environment) IsBaseScope() bool {
 		return false
 	}
 }
+
+// root returns the environment that holds state shared by the whole program, which is the
+// global env if there is one
+func (e *Environment) root() *Environment {
+	if e.GlobalEnv != nil {
+		return e.GlobalEnv
+	}
+	return e
+}
+
+// SetErrorHandler sets the GOTO or GOSUB statement to execute when an error occurs.  Passing
+// nil turns error trapping off.
+func (e *Environment) SetErrorHandler(handler ast.Statement) {
+	r := e.root()
+	r.errorTrap.handler = handler
+	r.errorTrap.depth = len(r.callFrames)
+}
+
+// ErrorHandler returns the statement set by ON ERROR, or nil if errors aren't being trapped
+func (e *Environment) ErrorHandler() ast.Statement {
+	return e.root().errorTrap.handler
+}
+
+// TrapError records the details of an error that is about to be handled
+func (e *Environment) TrapError(code, lineNumber, statementNumber int, message string) {
+	t := &e.root().errorTrap
+	t.handling = true
+	t.code = code
+	t.lineNumber = lineNumber
+	t.statementNumber = statementNumber
+	t.message = message
+}
+
+// LastError returns the details of the last error that was trapped
+func (e *Environment) LastError() (code, lineNumber, statementNumber int, message string) {
+	t := e.root().errorTrap
+	return t.code, t.lineNumber, t.statementNumber, t.message
+}
+
+// HandlingError returns true if an error has been trapped and the handler hasn't resumed yet
+func (e *Environment) HandlingError() bool {
+	return e.root().errorTrap.handling
+}
+
+// ResumeFromError marks the end of error handling
+func (e *Environment) ResumeFromError() {
+	e.root().errorTrap.handling = false
+}
+
+// ClearErrorTrap turns error trapping off and forgets the last error
+func (e *Environment) ClearErrorTrap() {
+	e.root().errorTrap = errorTrap{}
+}
+
+// ErrorTrapDepth returns the number of procedure and function calls that were unfinished
+// when ON ERROR set the handler
+func (e *Environment) ErrorTrapDepth() int {
+	return e.root().errorTrap.depth
+}
+
+// SetUnwindingError records an error that is being passed back out of procedure and
+// function calls to the one that set the ON ERROR handler.  Passing nil marks the end of
+// the unwinding.
+func (e *Environment) SetUnwindingError(err *Error) {
+	e.root().errorTrap.unwinding = err
+}
+
+// UnwindingError returns the error set by SetUnwindingError, or nil if no error is being
+// passed back
+func (e *Environment) UnwindingError() *Error {
+	return e.root().errorTrap.unwinding
+}
+
+// SetBreakHandler sets the GOTO or GOSUB statement to execute when <BREAK> is pressed.
+// Passing nil turns break trapping off.
+func (e *Environment) SetBreakHandler(handler ast.Statement) {
+	t := &e.root().breakTrap
+	t.handler = handler
+	t.handling = false
+}
+
+// BreakHandler returns the statement set by ON BREAK, or nil if <BREAK> isn't being trapped
+func (e *Environment) BreakHandler() ast.Statement {
+	return e.root().breakTrap.handler
+}
+
+// TrapBreak marks the start of break handling
+func (e *Environment) TrapBreak() {
+	e.root().breakTrap.handling = true
+}
+
+// HandlingBreak returns true if an ON BREAK GOSUB handler is running
+func (e *Environment) HandlingBreak() bool {
+	return e.root().breakTrap.handling
+}
+
+// ResumeFromBreak marks the end of break handling
+func (e *Environment) ResumeFromBreak() {
+	e.root().breakTrap.handling = false
+}
+
+// ClearBreakTrap turns break trapping off
+func (e *Environment) ClearBreakTrap() {
+	e.root().breakTrap = breakTrap{}
+}
+
+// SetTrace turns tracing on or off.  If channel is non-zero the trace is written to that
+// file channel instead of the screen.
+func (e *Environment) SetTrace(on, variables bool, channel int) {
+	e.root().trace = traceState{on: on, variables: on && variables, channel: channel}
+}
+
+// Trace returns the settings made by SetTrace
+func (e *Environment) Trace() (on, variables bool, channel int) {
+	t := e.root().trace
+	return t.on, t.variables, t.channel
+}
+
+// SetContinuePoint records the line and statement that CONTINUE will resume from
+func (e *Environment) SetContinuePoint(lineNumber, statementNumber int) {
+	s := &e.root().stop
+	s.canContinue = true
+	s.version = e.Program.version
+	s.lineNumber = lineNumber
+	s.statementNumber = statementNumber
+	s.calls = nil
+}
+
+// ContinuePoint returns the line and statement set by SetContinuePoint.  ok is false if the
+// program hasn't been stopped or has been changed since.
+func (e *Environment) ContinuePoint() (lineNumber, statementNumber int, ok bool) {
+	s := e.root().stop
+	return s.lineNumber, s.statementNumber, s.canContinue && s.version == e.Program.version
+}
+
+// ClearContinuePoint stops the program from being continued
+func (e *Environment) ClearContinuePoint() {
+	s := &e.root().stop
+	s.canContinue = false
+	s.calls = nil
+}
+
+// SuspendCall records that the procedure call made from the current statement of e was
+// stopped while running in callee.  The continue point moves out to the call, so CONTINUE
+// gets back into the procedure by evaluating the call again.
+func (e *Environment) SuspendCall(callee *Environment) {
+	s := &e.root().stop
+	call := SuspendedCall{
+		Caller:                e,
+		CallerLineNumber:      e.Program.GetLineNumber(),
+		CallerStatementNumber: e.Program.CurrentStatementNumber,
+		Callee:                callee,
+		LineNumber:            s.lineNumber,
+		StatementNumber:       s.statementNumber,
+	}
+	s.lineNumber = call.CallerLineNumber
+	s.statementNumber = call.CallerStatementNumber
+	s.calls = append([]SuspendedCall{call}, s.calls...)
+}
+
+// ResumeSuspendedCalls starts or stops CONTINUE getting back into the procedure calls that
+// were left unfinished when the program was stopped
+func (e *Environment) ResumeSuspendedCalls(resume bool) {
+	s := &e.root().stop
+	if resume {
+		s.resuming = s.calls
+	} else {
+		s.resuming = nil
+	}
+}
+
+// ResumeCall returns the suspended call made from the current statement of e if CONTINUE is
+// getting back into it
+func (e *Environment) ResumeCall() (SuspendedCall, bool) {
+	s := &e.root().stop
+	if len(s.resuming) == 0 {
+		return SuspendedCall{}, false
+	}
+	call := s.resuming[0]
+	if call.Caller != e || call.CallerLineNumber != e.Program.GetLineNumber() || call.CallerStatementNumber != e.Program.CurrentStatementNumber {
+		return SuspendedCall{}, false
+	}
+	s.resuming = s.resuming[1:]
+	return call, true
+}
+
+// PushCallFrame records the start of a procedure or function call
+func (e *Environment) PushCallFrame(frame CallFrame) {
+	r := e.root()
+	r.callFrames = append(r.callFrames, frame)
+}
+
+// PopCallFrame records the end of the most recent procedure or function call
+func (e *Environment) PopCallFrame() {
+	r := e.root()
+	if len(r.callFrames) > 0 {
+		r.callFrames = r.callFrames[:len(r.callFrames)-1]
+	}
+}
+
+// CallFrames returns the procedure and function calls that haven't finished yet, with the
+// most recent call last
+func (e *Environment) CallFrames() []CallFrame {
+	return e.root().callFrames
+}
 func (e *Environment) EndProgram() {
 	e.EndProgramSignal = true
 }
@@ -327,7 +826,7 @@ func (e *Environment) KillScope() {
 }
 
 func (e *Environment) Global(name string) bool {
-	key := storeKey{Scope: e.scope, Name: name}
+	key := storeKey{Scope: 0, Name: name}
 	if _, ok := e.store[key]; ok {
 		// variable already defined in this scope
 		return false
@@ -347,7 +846,32 @@ func (e *Environment) IsGlobal(name string) bool {
 	return false
 }
 
-// TODO: Globalize arrays
+// Variable describes a variable or array in the store
+type Variable struct {
+	Name   string
+	Value  Object
+	Scope  int // Number of procedure and function calls the variable is local to, 0 in the main program
+	Global bool
+}
+
+// Variables returns every variable and array that has a value, sorted by name.  The local
+// variables are the ones of the procedure or function call that e belongs to.
+func (e *Environment) Variables() []Variable {
+	vars := []Variable{}
+	depth := len(e.CallFrames())
+	for k, v := range e.store {
+		vars = append(vars, Variable{Name: k.Name, Value: v, Scope: depth})
+	}
+	if e.GlobalEnv != nil {
+		for k, v := range e.GlobalEnv.store {
+			vars = append(vars, Variable{Name: k.Name, Value: v, Global: true})
+		}
+	}
+	sort.Slice(vars, func(i, j int) bool {
+		return vars[i].Name < vars[j].Name
+	})
+	return vars
+}
 
 func (e *Environment) PushData(obj Object) {
 	e.dataItems = append(e.dataItems, obj)
@@ -367,6 +891,13 @@ func (e *Environment) DeleteData() {
 	e.dataItems = []Object{}
 }
 
+// DeleteLocals empties e's own store.  GLOBAL variables survive because their values are held
+// in GlobalEnv and e keeps its list of global names, so it must be called on an env that has a
+// GlobalEnv, such as the main program env, and not on the global env itself.
+func (e *Environment) DeleteLocals() {
+	e.store = make(map[storeKey]Object)
+}
+
 func (e *Environment) DeleteStore() {
 	e.store = make(map[storeKey]Object)
 	e.globals = []string{}
@@ -386,6 +917,11 @@ func (e *Environment) GetSubroutine(name string) (*ast.SubroutineStatement, bool
 	return nil, false
 }
 
+// Subroutines returns the subroutines registered by the last prerun
+func (e *Environment) Subroutines() []*ast.SubroutineStatement {
+	return e.subroutines
+}
+
 func (e *Environment) DeleteSubroutines() {
 	e.subroutines = []*ast.SubroutineStatement{}
 }
@@ -403,6 +939,11 @@ func (e *Environment) GetFunction(name string) (*ast.FunctionDeclaration, bool)
 	return nil, false
 }
 
+// Functions returns the functions registered by the last prerun
+func (e *Environment) Functions() []*ast.FunctionDeclaration {
+	return e.functions
+}
+
 func (e *Environment) DeleteFunctions() {
 	e.functions = []*ast.FunctionDeclaration{}
 }
@@ -420,58 +961,50 @@ func (e *Environment) GetProcedure(name string) (*ast.ProcedureDeclaration, bool
 	return nil, false
 }
 
+// Procedures returns the procedures registered by the last prerun
+func (e *Environment) Procedures() []*ast.ProcedureDeclaration {
+	return e.procedures
+}
+
 func (e *Environment) DeleteProcedures() {
 	e.procedures = []*ast.ProcedureDeclaration{}
 }
 
+// calculateAddressFromArraySubscripts returns the index in an array's items of the element at
+// subscripts.  Each dimension runs from 0 to its bound and the items are stored in row-major order.
 func calculateAddressFromArraySubscripts(bounds []int, subscripts []int) int {
-	// Special case of 1D array
-	if len(bounds) == 1 {
-		return subscripts[0] //+ 1
-	}
-	// Use method for row-major calculation: https://www.geeksforgeeks.org/calculating-the-address-of-an-element-in-an-n-dimensional-array/
-	internalSequence := []int{}
-	subOffset := 0
-	boundsOffset := 1
-	for i := 0; i < len(subscripts)-1; i++ {
-		if i == 0 {
-			en := subscripts[i] - subOffset
-			snp1 := bounds[i+1] - boundsOffset
-			enp1 := subscripts[i+1] - subOffset
-			internalSequence = append(internalSequence, (en*snp1)+(enp1))
-		} else {
-			prev := internalSequence[len(internalSequence)-1]
-			snp1 := bounds[i+1] - boundsOffset
-			enp1 := subscripts[i+1] - subOffset
-			internalSequence = append(internalSequence, (prev*snp1)+enp1)
-		}
-	}
-	w := len(bounds)
-	addr := w * internalSequence[len(internalSequence)-1]
+	addr := 0
+	for i := 0; i < len(bounds); i++ {
+		addr = addr*(bounds[i]+1) + subscripts[i]
+	}
 	return addr
 }
 
 func (e *Environment) NewArray(name string, subscripts []int) (Object, bool) {
 	//_, ok := e.store[storeKey{Name: name, Scope: e.scope}]
 	//if ok {
-	//	return &Error{Message: syntaxerror.ErrorMessage(syntaxerror.ArrayAlreadyDimensioned), ErrorTokenIndex: 0}, false
+	//	return &Error{Code: syntaxerror.ArrayAlreadyDimensioned, Message: syntaxerror.ErrorMessage(syntaxerror.ArrayAlreadyDimensioned), ErrorTokenIndex: 0}, false
 	//}
 	key := storeKey{Scope: 0, Name: name}
 	// Don't redimension existing arrays
 	if e.IsGlobal(name) {
 		if _, ok := e.GlobalEnv.store[key]; ok {
-			return &Error{Message: syntaxerror.ErrorMessage(syntaxerror.ArrayAlreadyDimensioned), ErrorTokenIndex: 0}, false
+			return &Error{Code: syntaxerror.ArrayAlreadyDimensioned, Message: syntaxerror.ErrorMessage(syntaxerror.ArrayAlreadyDimensioned), ErrorTokenIndex: 0}, false
 		}
 	} else {
 		if _, ok := e.store[key]; ok {
-			return &Error{Message: syntaxerror.ErrorMessage(syntaxerror.ArrayAlreadyDimensioned), ErrorTokenIndex: 0}, false
+			return &Error{Code: syntaxerror.ArrayAlreadyDimensioned, Message: syntaxerror.ErrorMessage(syntaxerror.ArrayAlreadyDimensioned), ErrorTokenIndex: 0}, false
 		}
 	}
 	// Go ahead and dimension the array
 	maxIndex := calculateAddressFromArraySubscripts(subscripts, subscripts) + 1
 	// initialize items according to type
 	items := make([]Object, maxIndex)
-	if name[len(name)-1:] != "$" {
+	if name[len(name)-1:] == "%" {
+		for i := 0; i < len(items); i++ {
+			items[i] = &Integer{Value: 0}
+		}
+	} else if name[len(name)-1:] != "$" {
 		for i := 0; i < len(items); i++ {
 			items[i] = &Numeric{Value: 0}
 		}
@@ -507,7 +1040,7 @@ func (e *Environment) GetArray(name string, subscripts []int) (Object, bool) {
 		arr, ok = e.store[key].(*Array)
 	}
 	if !ok {
-		return &Error{Message: syntaxerror.ErrorMessage(syntaxerror.FunctionArrayNotFound), ErrorTokenIndex: 0}, false
+		return &Error{Code: syntaxerror.FunctionArrayNotFound, Message: syntaxerror.ErrorMessage(syntaxerror.FunctionArrayNotFound), ErrorTokenIndex: 0}, false
 	}
 	//arr, ok := objArray.(*Array)
 	//if !ok {
@@ -516,12 +1049,12 @@ func (e *Environment) GetArray(name string, subscripts []int) (Object, bool) {
 	// Validate subscripts
 	if len(subscripts) != len(arr.Subscripts) {
 		// Wrong number of subscripts error
-		return &Error{Message: syntaxerror.ErrorMessage(syntaxerror.WrongNumberOfSubscripts), ErrorTokenIndex: 0}, false
+		return &Error{Code: syntaxerror.WrongNumberOfSubscripts, Message: syntaxerror.ErrorMessage(syntaxerror.WrongNumberOfSubscripts), ErrorTokenIndex: 0}, false
 	}
 	for i := 0; i < len(subscripts); i++ {
 		if subscripts[i] > arr.Subscripts[i] || subscripts[i] < 0 {
 			// Subscript out of range error
-			return &Error{Message: syntaxerror.ErrorMessage(syntaxerror.ArraySubscriptIsWrong), ErrorTokenIndex: 0}, false
+			return &Error{Code: syntaxerror.ArraySubscriptIsWrong, Message: syntaxerror.ErrorMessage(syntaxerror.ArraySubscriptIsWrong), ErrorTokenIndex: 0}, false
 		}
 	}
 	index := calculateAddressFromArraySubscripts(arr.Subscripts, subscripts)
@@ -539,30 +1072,22 @@ func (e *Environment) SetArray(name string, subscripts []int, val Object) (Objec
 		arr, ok = e.store[key].(*Array)
 	}
 	if !ok {
-		return &Error{Message: syntaxerror.ErrorMessage(syntaxerror.FunctionArrayNotFound), ErrorTokenIndex: 0}, false
+		return &Error{Code: syntaxerror.FunctionArrayNotFound, Message: syntaxerror.ErrorMessage(syntaxerror.FunctionArrayNotFound), ErrorTokenIndex: 0}, false
 	}
-	// Don't allow string val to bind to numeric variable
-	if val.Type() == STRING_OBJ && name[len(name)-1:] != "$" {
-		return &Error{Message: "Numeric expression needed"}, false
-	}
-	// Don't allow numeric val to bind to string variable
-	if val.Type() != STRING_OBJ && name[len(name)-1:] == "$" {
-		return &Error{Message: "String expression needed"}, false
-	}
-	// If a float value is bound to an integer variable (name ends with %) it is rounded-down first (manual 3.7)
-	if val.Type() == NUMERIC_OBJ && name[len(name)-1:] == "%" {
-		val = &Numeric{Value: float64(int64(val.(*Numeric).Value))}
+	val = CastToVariableType(name, val)
+	if val.Type() == ERROR_OBJ {
+		return val, false
 	}
 	//arr, _ := objArray.(*Array)
 	// Validate subscripts
 	if len(subscripts) != len(arr.Subscripts) {
 		// Wrong number of subscripts error
-		return &Error{Message: syntaxerror.ErrorMessage(syntaxerror.WrongNumberOfSubscripts), ErrorTokenIndex: 0}, false
+		return &Error{Code: syntaxerror.WrongNumberOfSubscripts, Message: syntaxerror.ErrorMessage(syntaxerror.WrongNumberOfSubscripts), ErrorTokenIndex: 0}, false
 	}
 	for i := 0; i < len(subscripts); i++ {
 		if subscripts[i] > arr.Subscripts[i] || subscripts[i] < 0 {
 			// Subscript out of range error
-			return &Error{Message: syntaxerror.ErrorMessage(syntaxerror.ArraySubscriptIsWrong), ErrorTokenIndex: 0}, false
+			return &Error{Code: syntaxerror.ArraySubscriptIsWrong, Message: syntaxerror.ErrorMessage(syntaxerror.ArraySubscriptIsWrong), ErrorTokenIndex: 0}, false
 		}
 	}
 	index := calculateAddressFromArraySubscripts(arr.Subscripts, subscripts)
@@ -578,6 +1103,47 @@ func (e *Environment) SetArray(name string, subscripts []int, val Object) (Objec
 	return arr, true
 }
 
+// GetArrayReference returns the whole of the named array
+func (e *Environment) GetArrayReference(name string) (*Array, bool) {
+	key := storeKey{Scope: 0, Name: name}
+	var arr *Array
+	var ok bool
+	if e.IsGlobal(name) {
+		arr, ok = e.GlobalEnv.store[key].(*Array)
+	} else {
+		arr, ok = e.store[key].(*Array)
+	}
+	return arr, ok
+}
+
+// SetArrayReference stores a copy of arr as the named array, replacing the array if it
+// already exists.  An Error is returned if arr holds a different type of value to the one
+// the name calls for.
+func (e *Environment) SetArrayReference(name string, arr *Array) Object {
+	itemType := ObjectType(NUMERIC_OBJ)
+	switch name[len(name)-1:] {
+	case "%":
+		itemType = INTEGER_OBJ
+	case "$":
+		itemType = STRING_OBJ
+	}
+	if len(arr.Items) > 0 && arr.Items[0].Type() != itemType {
+		return &Error{Code: syntaxerror.WrongTypeOfArray, Message: syntaxerror.ErrorMessage(syntaxerror.WrongTypeOfArray)}
+	}
+	items := make([]Object, len(arr.Items))
+	copy(items, arr.Items)
+	subscripts := make([]int, len(arr.Subscripts))
+	copy(subscripts, arr.Subscripts)
+	newArray := &Array{Items: items, Subscripts: subscripts}
+	key := storeKey{Scope: 0, Name: name}
+	if e.IsGlobal(name) {
+		e.GlobalEnv.store[key] = newArray
+	} else {
+		e.store[key] = newArray
+	}
+	return newArray
+}
+
 func (e *Environment) Get(name string) (Object, bool) {
 
 	// Use current scope if local or global scope if global
@@ -591,18 +1157,38 @@ func (e *Environment) Get(name string) (Object, bool) {
 	}
 }
 
-func (e *Environment) Set(name string, val Object) Object {
+// CastToVariableType returns val as the type of value held by the named variable, so that
+// integer (%) variables hold Integers and other numeric variables hold Numerics.  An Error is
+// returned if val can't be held by the variable.
+func CastToVariableType(name string, val Object) Object {
 	// Don't allow string val to bind to numeric variable
 	if val.Type() == STRING_OBJ && name[len(name)-1:] != "$" {
-		return &Error{Message: "Numeric expression needed"}
+		return &Error{Code: syntaxerror.NumericExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded)}
 	}
 	// Don't allow numeric val to bind to string variable
 	if val.Type() != STRING_OBJ && name[len(name)-1:] == "$" {
-		return &Error{Message: "String expression needed"}
+		return &Error{Code: syntaxerror.StringExpressionNeeded, Message: syntaxerror.ErrorMessage(syntaxerror.StringExpressionNeeded)}
 	}
-	// If a float value is bound to an integer variable (name ends with %) it is rounded-down first (manual 3.7)
-	if val.Type() == NUMERIC_OBJ && name[len(name)-1:] == "%" {
-		val = &Numeric{Value: float64(int64(val.(*Numeric).Value))}
+	switch val := val.(type) {
+	case *Numeric:
+		if name[len(name)-1:] == "%" {
+			if i, ok := NewInteger(val.Value); ok {
+				return i
+			}
+			return &Error{Code: syntaxerror.NumberTooBig, Message: syntaxerror.ErrorMessage(syntaxerror.NumberTooBig)}
+		}
+	case *Integer:
+		if name[len(name)-1:] != "%" {
+			return &Numeric{Value: float64(val.Value)}
+		}
+	}
+	return val
+}
+
+func (e *Environment) Set(name string, val Object) Object {
+	val = CastToVariableType(name, val)
+	if val.Type() == ERROR_OBJ {
+		return val
 	}
 	// Use current scope if local or global scope if global
 	key := storeKey{Name: name, Scope: 0}
@@ -637,6 +1223,7 @@ func NewEnvironment(GlobalEnv *Environment) *Environment {
 		JumpStack: *j,
 		dataItems: []Object{},
 		scope:     0,
+		clock:     clockState{clock: systemClock{}, started: time.Now()},
 	}
 }
 
@@ -0,0 +1,210 @@
+package object
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/ast"
+	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/game/examples"
+)
+
+func TestParsedLineCache(t *testing.T) {
+	p := &program{}
+	p.New()
+	p.AddLine(10, "PRINT 1")
+	p.AddLine(20, "PRINT 2")
+	p.Start()
+	if _, ok := p.GetParsedLine(); ok {
+		t.Errorf("line 10 was cached before being parsed")
+	}
+	line := &ast.Line{}
+	p.SetParsedLine(line)
+	p.Start()
+	if cached, ok := p.GetParsedLine(); !ok || cached != line {
+		t.Errorf("line 10 was not cached")
+	}
+	// Editing another line leaves the cache alone but editing this one drops it
+	p.AddLine(20, "PRINT 3")
+	p.Start()
+	if _, ok := p.GetParsedLine(); !ok {
+		t.Errorf("editing line 20 dropped line 10 from the cache")
+	}
+	p.AddLine(10, "PRINT 4")
+	p.Start()
+	if _, ok := p.GetParsedLine(); ok {
+		t.Errorf("editing line 10 didn't drop it from the cache")
+	}
+}
+
+func TestJump(t *testing.T) {
+	p := &program{}
+	p.New()
+	p.AddLine(10, "PRINT 1")
+	p.AddLine(30, "PRINT 3")
+	p.AddLine(20, "PRINT 2")
+	p.Start()
+	p.Next()
+	if !p.Jump(30, 1) {
+		t.Fatalf("couldn't jump to line 30")
+	}
+	if !p.Jumped() {
+		t.Errorf("Jumped() is false after a jump")
+	}
+	p.Next()
+	if p.GetLineNumber() != 30 || p.JumpToStatement != 1 {
+		t.Errorf("jumped to line %d statement %d, expected line 30 statement 1", p.GetLineNumber(), p.JumpToStatement)
+	}
+	if p.Jump(25, 0) {
+		t.Errorf("jumped to line 25 which doesn't exist")
+	}
+	if p.GetLineNumber() != 30 {
+		t.Errorf("failed jump moved to line %d, expected to stay on 30", p.GetLineNumber())
+	}
+	p.Jump(10, 1)
+	p.Next()
+	p.Next()
+	if p.Jumped() || p.JumpToStatement != 0 {
+		t.Errorf("JumpToStatement wasn't cleared by the next line")
+	}
+	p.AddLine(30, "")
+	if p.Jump(30, 0) {
+		t.Errorf("jumped to line 30 after it was deleted")
+	}
+}
+
+func TestRenumber(t *testing.T) {
+	tests := []struct {
+		params   []int
+		expected []string
+		ok       bool
+	}{
+		{[]int{10, 10, 0, 0}, []string{
+			`10 GOSUB 40: GOTO 30`,
+			`20 PRINT "GOTO 5"`,
+			`30 ON X GOTO 10, 20, 99: RESTORE 50`,
+			`40 IF X = 1 THEN 10 ELSE 50: RETURN`,
+			`50 DATA 1: RUN 10: EDIT 20: REM GOTO 5`,
+		}, true},
+		{[]int{100, 5, 7, 0}, []string{
+			`5 GOSUB 110: GOTO 105`,
+			`100 PRINT "GOTO 5"`,
+			`105 ON X GOTO 5, 100, 99: RESTORE 115`,
+			`110 IF X = 1 THEN 5 ELSE 115: RETURN`,
+			`115 DATA 1: RUN 5: EDIT 100: REM GOTO 5`,
+		}, true},
+		{[]int{1, 1, 0, 0}, []string{
+			`1 GOSUB 4: GOTO 3`,
+			`2 PRINT "GOTO 5"`,
+			`3 ON X GOTO 1, 2, 99: RESTORE 5`,
+			`4 IF X = 1 THEN 1 ELSE 5: RETURN`,
+			`5 DATA 1: RUN 1: EDIT 2: REM GOTO 5`,
+		}, true},
+		// Renumbering the middle of the program mustn't overlap the lines either side
+		{[]int{1, 10, 7, 15}, nil, false},
+		{[]int{8, 10, 7, 15}, nil, false},
+	}
+
+	for _, tt := range tests {
+		p := &program{}
+		p.New()
+		p.AddLine(5, "GOSUB 15: GOTO 12")
+		p.AddLine(7, `PRINT "GOTO 5"`)
+		p.AddLine(12, "ON X GOTO 5, 7, 99: RESTORE 20")
+		p.AddLine(15, "IF X = 1 THEN 5 ELSE 20: RETURN")
+		p.AddLine(20, "DATA 1: RUN 5: EDIT 7: REM GOTO 5")
+		if ok := p.Renumber(tt.params[0], tt.params[1], tt.params[2], tt.params[3]); ok != tt.ok {
+			t.Errorf("RENUMBER %v returned %t, expected %t", tt.params, ok, tt.ok)
+			continue
+		}
+		if !tt.ok {
+			if p.lines[7] == "" || p.lines[15] == "" {
+				t.Errorf("RENUMBER %v changed the program when it failed", tt.params)
+			}
+			continue
+		}
+		if got := p.List(0, 0, false); strings.Join(got, "\n") != strings.Join(tt.expected, "\n") {
+			t.Errorf("RENUMBER %v gave %q, expected %q", tt.params, got, tt.expected)
+		}
+	}
+}
+
+// BenchmarkJump jumps to every line of the lathe example in turn
+func BenchmarkJump(b *testing.B) {
+	lathe, _ := examples.Get("lathe.BAS")
+	p := &program{}
+	p.New()
+	lineNumbers := []int{}
+	for _, rawLine := range strings.Split(lathe, "\n") {
+		fields := strings.SplitN(rawLine, " ", 2)
+		if len(fields) < 2 {
+			continue
+		}
+		lineNumber, _ := strconv.Atoi(fields[0])
+		lineNumbers = append(lineNumbers, lineNumber)
+		p.lines[lineNumber] = fields[1]
+	}
+	p.Sort()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, lineNumber := range lineNumbers {
+			p.Jump(lineNumber, 0)
+		}
+	}
+}
+
+func TestIndentBlockIf(t *testing.T) {
+	p := &program{}
+	p.New()
+	for i, line := range []string{
+		"IF A = 1 THEN",
+		`PRINT "One"`,
+		"IF B = 1 THEN PRINT 1 ELSE PRINT 2",
+		"ELSE IF A = 2 THEN",
+		"FOR I := 1 TO 2",
+		"PRINT I",
+		"NEXT I",
+		"ELSE",
+		`PRINT "Other"`,
+		"ENDIF",
+		"END",
+	} {
+		p.AddLine((i+1)*10, line)
+	}
+	expected := []string{
+		`10 IF A = 1 THEN`,
+		`20   PRINT "One"`,
+		`30   IF B = 1 THEN PRINT 1 ELSE PRINT 2`,
+		`40 ELSE IF A = 2 THEN`,
+		`50   FOR I := 1 TO 2`,
+		`60     PRINT I`,
+		`70   NEXT I`,
+		`80 ELSE`,
+		`90   PRINT "Other"`,
+		`100 ENDIF`,
+		`110 END`,
+	}
+	if got := p.List(0, 0, false); strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("indented program is %q, expected %q", got, expected)
+	}
+}
+
+func TestIndentNextWithVariables(t *testing.T) {
+	p := &program{}
+	p.New()
+	p.AddLine(10, "FOR I := 1 TO 2")
+	p.AddLine(20, "FOR J := 1 TO 2")
+	p.AddLine(30, "PRINT I; J")
+	p.AddLine(40, "NEXT J, I")
+	p.AddLine(50, "END")
+	expected := []string{
+		`10 FOR I := 1 TO 2`,
+		`20   FOR J := 1 TO 2`,
+		`30     PRINT I; J`,
+		`40 NEXT J, I`,
+		`50 END`,
+	}
+	if got := p.List(0, 0, false); strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("indented program is %q, expected %q", got, expected)
+	}
+}
@@ -3,6 +3,7 @@ package object
 import (
 	"bytes"
 	"fmt"
+	"math"
 	"strings"
 
 	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/ast"
@@ -11,6 +12,7 @@ import (
 
 const (
 	NUMERIC_OBJ      = "NUMERIC"
+	INTEGER_OBJ      = "INTEGER"
 	BOOLEAN_OBJ      = "BOOLEAN"
 	ARRAY_OBJ        = "ARRAY"
 	NULL_OBJ         = "NULL" // RM Basic didn't have null...I think it just created a new var with zero or "" value...maybe?
@@ -57,7 +59,8 @@ func (f *Function) Inspect() string {
 type BuiltinFunction func(env *Environment, g *game.Game, args []Object) Object
 
 type Builtin struct {
-	Fn BuiltinFunction
+	Syntax string // One-line summary of how the function is used, for syntax hints
+	Fn     BuiltinFunction
 }
 
 func (b *Builtin) Type() ObjectType {
@@ -78,6 +81,28 @@ func (i *Numeric) Type() ObjectType {
 	return NUMERIC_OBJ
 }
 
+// Integer is the value of an integer (%) variable.  RM Basic integers are 16-bit.
+type Integer struct {
+	Value int16
+}
+
+func (i *Integer) Inspect() string {
+	return fmt.Sprintf("%d", i.Value)
+}
+func (i *Integer) Type() ObjectType {
+	return INTEGER_OBJ
+}
+
+// NewInteger returns an Integer with the fractional part of val discarded (manual 3.7), or
+// false if val is out of the integer range
+func NewInteger(val float64) (*Integer, bool) {
+	val = math.Trunc(val)
+	if val < math.MinInt16 || val > math.MaxInt16 {
+		return nil, false
+	}
+	return &Integer{Value: int16(val)}, true
+}
+
 type Boolean struct {
 	Value bool
 }
@@ -129,6 +154,7 @@ func (s *String) Inspect() string {
 }
 
 type Error struct {
+	Code            int // syntaxerror code, reported by ERR
 	Message         string
 	ErrorTokenIndex int
 }
@@ -20,6 +20,7 @@ const (
 	SUM         // +
 	PRODUCT     // *
 	PREFIX      // - or NOT
+	POWER       // ^
 	LOGICAL     // AND OR XOR
 	CALL        // MyFunction(X)
 )
@@ -29,13 +30,18 @@ var precedences = map[string]int{
 	token.LessThan:            LESSGREATER,
 	token.GreaterThan:         LESSGREATER,
 	token.LessThanEqualTo1:    LESSGREATER,
+	token.LessThanEqualTo2:    LESSGREATER,
 	token.GreaterThanEqualTo1: LESSGREATER,
+	token.GreaterThanEqualTo2: LESSGREATER,
 	token.Inequality1:         LESSGREATER,
+	token.Inequality2:         LESSGREATER,
 	token.Plus:                SUM,
 	token.Minus:               SUM,
 	token.Star:                PRODUCT,
 	token.ForwardSlash:        PRODUCT,
 	token.MOD:                 PRODUCT,
+	token.BackSlash:           PRODUCT,
+	token.Exponential:         POWER,
 	token.LeftParen:           CALL,
 	token.AND:                 LOGICAL,
 	token.OR:                  LOGICAL,
@@ -59,6 +65,7 @@ type Parser struct {
 	ErrorTokenIndex int      // the index of the token where an error occured
 	inBindStatement bool     // Flag to prevent binding of variables withinin a bind statement
 	inConditional   bool     // Flag to help parse conditional statements correctly
+	ifNesting       int      // How many IF statements are being parsed, so ParseLine knows if an ELSE belongs to one
 	g               *game.Game
 }
 
@@ -133,6 +140,8 @@ func New(l *lexer.Lexer, g *game.Game) *Parser {
 	p.registerInfix(token.XOR, p.parseInfixExpression)
 	p.registerInfix(token.InterestinglyEqual, p.parseInfixExpression)
 	p.registerInfix(token.MOD, p.parseInfixExpression)
+	p.registerInfix(token.BackSlash, p.parseInfixExpression)
+	p.registerInfix(token.Exponential, p.parseInfixExpression)
 
 	return p
 }
@@ -293,6 +302,21 @@ func (p *Parser) requireClosingBracket() bool {
 	return true
 }
 
+// parseArrayReference sets ident.IsArrayReference if the current token starts the empty
+// brackets that follow an array name in a parameter list, e.g. the () in A(), and
+// consumes them.  It returns false if the brackets aren't empty.
+func (p *Parser) parseArrayReference(ident *ast.Identifier) bool {
+	if !p.curTokenIs(token.LeftParen) {
+		return true
+	}
+	p.nextToken()
+	if !p.requireClosingBracket() {
+		return false
+	}
+	ident.IsArrayReference = true
+	return true
+}
+
 func (p *Parser) requireTo() bool {
 	if !p.curTokenIs(token.TO) {
 		p.errorMsg = syntaxerror.ErrorMessage(syntaxerror.ToIsNeededBeforeValue)
@@ -355,6 +379,8 @@ func (p *Parser) parseIfStatement() ast.Statement {
 		Token: p.curToken,
 	}
 
+	p.ifNesting++
+	defer func() { p.ifNesting-- }()
 	p.nextToken() // consume IF
 	p.inConditional = true
 	p.inBindStatement = false
@@ -365,8 +391,14 @@ func (p *Parser) parseIfStatement() ast.Statement {
 		p.ErrorTokenIndex = p.curToken.Index + 1
 		return nil
 	}
-	p.nextToken() // consume THEN
 	p.inConditional = false
+	// THEN at the end of the line starts a block IF, unless this IF is inside another one
+	if p.ifNesting == 1 && (p.peekTokenIs(token.NewLine) || p.peekTokenIs(token.EOF)) {
+		stmt.Block = true
+		stmt.Consequence = &ast.Line{}
+		return stmt
+	}
+	p.nextToken() // consume THEN
 	stmt.Consequence = p.ParseLine()
 	if p.curTokenIs(token.ELSE) {
 		p.nextToken()
@@ -375,6 +407,43 @@ func (p *Parser) parseIfStatement() ast.Statement {
 	return stmt
 }
 
+func (p *Parser) parseElseStatement() ast.Statement {
+	stmt := &ast.ElseStatement{
+		Token: p.curToken,
+	}
+
+	if !p.peekTokenIs(token.IF) {
+		return stmt
+	}
+	p.nextToken() // consume ELSE
+	p.nextToken() // consume IF
+	p.inConditional = true
+	p.inBindStatement = false
+	stmt.Condition = p.parseExpression(LOWEST)
+	p.nextToken()
+	if !p.curTokenIs(token.THEN) {
+		p.errorMsg = syntaxerror.ErrorMessage(syntaxerror.ThenExpected)
+		p.ErrorTokenIndex = p.curToken.Index + 1
+		return nil
+	}
+	p.inConditional = false
+	// ELSE IF only starts a branch of a block IF so THEN must end the line
+	if !(p.peekTokenIs(token.NewLine) || p.peekTokenIs(token.EOF)) {
+		p.errorMsg = syntaxerror.ErrorMessage(syntaxerror.EndOfInstructionExpected)
+		p.ErrorTokenIndex = p.curToken.Index + 1
+		return nil
+	}
+	return stmt
+}
+
+func (p *Parser) parseEndifStatement() *ast.EndifStatement {
+	stmt := &ast.EndifStatement{Token: p.curToken}
+	if p.endOfInstruction() {
+		return stmt
+	}
+	return nil
+}
+
 func (p *Parser) parseUntilStatement() ast.Statement {
 	stmt := &ast.UntilStatement{
 		Token: p.curToken,
@@ -427,6 +496,38 @@ func (p *Parser) parseEndStatement() *ast.EndStatement {
 	return nil
 }
 
+func (p *Parser) parseStopStatement() *ast.StopStatement {
+	stmt := &ast.StopStatement{Token: p.curToken}
+	if p.endOfInstruction() {
+		return stmt
+	}
+	return nil
+}
+
+func (p *Parser) parseContinueStatement() *ast.ContinueStatement {
+	stmt := &ast.ContinueStatement{Token: p.curToken}
+	if p.endOfInstruction() {
+		return stmt
+	}
+	return nil
+}
+
+func (p *Parser) parseLvarStatement() *ast.LvarStatement {
+	stmt := &ast.LvarStatement{Token: p.curToken}
+	if p.endOfInstruction() {
+		return stmt
+	}
+	return nil
+}
+
+func (p *Parser) parseProcsStatement() *ast.ProcsStatement {
+	stmt := &ast.ProcsStatement{Token: p.curToken}
+	if p.endOfInstruction() {
+		return stmt
+	}
+	return nil
+}
+
 func (p *Parser) parseListStatement() *ast.ListStatement {
 	stmt := &ast.ListStatement{Token: p.curToken}
 	p.nextToken() // consume LIST
@@ -759,10 +860,26 @@ func (p *Parser) parseClearblockStatement() *ast.ClearblockStatement {
 
 func (p *Parser) parseRenumberStatement() *ast.RenumberStatement {
 	stmt := &ast.RenumberStatement{Token: p.curToken}
-	if p.endOfInstruction() {
-		return stmt
+	p.nextToken()
+	// RENUMBER [start [, increment [, from [, to]]]]
+	params := []*ast.Expression{&stmt.Start, &stmt.Increment, &stmt.From, &stmt.To}
+	for i, param := range params {
+		if p.onEndOfInstruction() {
+			return stmt
+		}
+		if i > 0 && !p.requireComma() {
+			return nil
+		}
+		val, ok := p.requireExpression()
+		if !ok {
+			return nil
+		}
+		*param = val
 	}
-	return nil
+	if !p.requireEndOfInstruction() {
+		return nil
+	}
+	return stmt
 }
 
 func (p *Parser) parseClsStatement() *ast.ClsStatement {
@@ -1847,8 +1964,8 @@ func (p *Parser) parseForStatement() *ast.ForStatement {
 		}
 		stmt.Step = val
 	}
-	// Require end of instruction
-	if p.endOfInstruction() {
+	// Require end of instruction, which the expression has already been consumed up to
+	if p.requireEndOfInstruction() {
 		return stmt
 	}
 	return nil
@@ -1861,14 +1978,21 @@ func (p *Parser) parseNextStatement() *ast.NextStatement {
 	if p.onEndOfInstruction() {
 		return stmt
 	}
-	// NEXT var
-	// Require variable name
-	if !p.curTokenIs(token.IdentifierLiteral) {
-		p.ErrorTokenIndex = p.curToken.Index
-		p.errorMsg = syntaxerror.ErrorMessage(syntaxerror.VariableNameIsNeeded)
-		return nil
+	// NEXT var [, var ...]
+	for {
+		// Require variable name
+		if !p.curTokenIs(token.IdentifierLiteral) {
+			p.ErrorTokenIndex = p.curToken.Index
+			p.errorMsg = syntaxerror.ErrorMessage(syntaxerror.VariableNameIsNeeded)
+			return nil
+		}
+		stmt.Names = append(stmt.Names, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+		if !p.peekTokenIs(token.Comma) {
+			break
+		}
+		p.nextToken() // consume variable name
+		p.nextToken() // consume comma
 	}
-	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 	// Require end of instruction
 	if p.endOfInstruction() {
 		return stmt
@@ -1889,15 +2013,8 @@ func (p *Parser) parseGlobalStatement() *ast.GlobalStatement {
 		name := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 		// Catch array reference
 		p.nextToken()
-		if p.curTokenIs(token.LeftParen) {
-			p.nextToken()
-			if p.curTokenIs(token.RightParen) {
-				// is array reference without subscripts
-				name.IsArrayReference = true
-				if !p.requireClosingBracket() {
-					return nil
-				}
-			}
+		if !p.parseArrayReference(name) {
+			return nil
 		}
 		stmt.Names = append(stmt.Names, name)
 		if p.curTokenIs(token.Colon) || p.curTokenIs(token.NewLine) || p.curTokenIs(token.EOF) {
@@ -1946,7 +2063,115 @@ func (p *Parser) parseGosubStatement() *ast.GosubStatement {
 			return nil
 		}
 	}
+	// Require end of instruction
+	if p.endOfInstruction() {
+		return stmt
+	}
+	return nil
+}
+
+// parseOnBranch parses the GOTO or GOSUB that follows ON ERROR or ON BREAK, leaving the
+// branch nil if the trap is being turned off.  It returns false if there was an error.
+func (p *Parser) parseOnBranch() (ast.Statement, bool) {
+	p.nextToken() // consume ON
+	// ON ERROR or ON BREAK on its own turns the trap off
+	if p.peekTokenIs(token.Colon) || p.peekTokenIs(token.NewLine) || p.peekTokenIs(token.EOF) {
+		return nil, true
+	}
+	p.nextToken() // consume ERROR or BREAK
+	switch p.curToken.TokenType {
+	case token.GOTO:
+		branch := p.parseGotoStatement()
+		if branch == nil {
+			return nil, false
+		}
+		// So does GOTO 0
+		if val, _ := strconv.ParseFloat(branch.Linenumber.Literal, 64); val == 0 {
+			return nil, true
+		}
+		return branch, true
+	case token.GOSUB:
+		branch := p.parseGosubStatement()
+		if branch == nil {
+			return nil, false
+		}
+		return branch, true
+	}
+	p.ErrorTokenIndex = p.curToken.Index
+	p.errorMsg = syntaxerror.ErrorMessage(syntaxerror.EndOfInstructionExpected)
+	return nil, false
+}
+
+func (p *Parser) parseOnErrorStatement() *ast.OnErrorStatement {
+	stmt := &ast.OnErrorStatement{Token: p.curToken}
+	branch, ok := p.parseOnBranch()
+	if !ok {
+		return nil
+	}
+	stmt.Branch = branch
+	return stmt
+}
+
+func (p *Parser) parseOnBreakStatement() *ast.OnBreakStatement {
+	stmt := &ast.OnBreakStatement{Token: p.curToken}
+	branch, ok := p.parseOnBranch()
+	if !ok {
+		return nil
+	}
+	stmt.Branch = branch
+	return stmt
+}
+
+func (p *Parser) parseOnJumpStatement() *ast.OnJumpStatement {
+	stmt := &ast.OnJumpStatement{Token: p.curToken}
+	p.nextToken() // consume ON
+	p.inConditional = true
+	p.inBindStatement = false
+	stmt.Selector = p.parseExpression(LOWEST)
+	p.inConditional = false
 	p.nextToken()
+	if !(p.curTokenIs(token.GOTO) || p.curTokenIs(token.GOSUB)) {
+		p.ErrorTokenIndex = p.curToken.Index
+		p.errorMsg = syntaxerror.ErrorMessage(syntaxerror.EndOfInstructionExpected)
+		return nil
+	}
+	jumpToken := p.curToken
+	for {
+		p.nextToken() // consume GOTO, GOSUB or comma
+		switch {
+		case p.curTokenIs(token.NumericLiteral) && jumpToken.TokenType == token.GOTO:
+			stmt.Branches = append(stmt.Branches, &ast.GotoStatement{Token: jumpToken, Linenumber: p.curToken})
+		case p.curTokenIs(token.NumericLiteral):
+			stmt.Branches = append(stmt.Branches, &ast.GosubStatement{Token: jumpToken, Name: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}})
+		case p.curTokenIs(token.IdentifierLiteral) && jumpToken.TokenType == token.GOSUB:
+			stmt.Branches = append(stmt.Branches, &ast.GosubStatement{Token: jumpToken, Name: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}, IsLabel: true})
+		default:
+			p.ErrorTokenIndex = p.curToken.Index
+			p.errorMsg = syntaxerror.ErrorMessage(syntaxerror.LineNumberLabelNeeded)
+			return nil
+		}
+		if !p.peekTokenIs(token.Comma) {
+			break
+		}
+		p.nextToken()
+	}
+	// Require end of instruction
+	if p.endOfInstruction() {
+		return stmt
+	}
+	return nil
+}
+
+func (p *Parser) parseResumeStatement() *ast.ResumeStatement {
+	stmt := &ast.ResumeStatement{Token: p.curToken}
+	// Optional NEXT or line number
+	if p.peekTokenIs(token.NEXT) {
+		p.nextToken()
+		stmt.Next = true
+	} else if p.peekTokenIs(token.NumericLiteral) {
+		p.nextToken()
+		stmt.Linenumber = p.curToken
+	}
 	// Require end of instruction
 	if p.endOfInstruction() {
 		return stmt
@@ -1994,15 +2219,8 @@ func (p *Parser) parseFunctionDeclaration() *ast.FunctionDeclaration {
 			ident := ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 			p.nextToken()
 			// Catch array reference
-			if p.curTokenIs(token.LeftParen) {
-				p.nextToken()
-				if p.curTokenIs(token.RightParen) {
-					// is array reference without subscripts
-					ident.IsArrayReference = true
-					if !p.requireClosingBracket() {
-						return nil
-					}
-				}
+			if !p.parseArrayReference(&ident) {
+				return nil
 			}
 			stmt.ReceiveArgs = append(stmt.ReceiveArgs, &ident)
 		}
@@ -2124,16 +2342,8 @@ func (p *Parser) parseProcedureDeclaration() *ast.ProcedureDeclaration {
 			ident := ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 			p.nextToken()
 			// Catch array reference
-			if p.curTokenIs(token.LeftParen) {
-				p.nextToken()
-				if p.curTokenIs(token.RightParen) {
-					// is array reference without subscripts
-					ident.IsArrayReference = true
-					if !p.requireClosingBracket() {
-						return nil
-					}
-				}
-				p.nextToken()
+			if !p.parseArrayReference(&ident) {
+				return nil
 			}
 			stmt.ReceiveArgs = append(stmt.ReceiveArgs, &ident)
 		}
@@ -2163,8 +2373,12 @@ func (p *Parser) parseProcedureDeclaration() *ast.ProcedureDeclaration {
 				p.errorMsg = syntaxerror.ErrorMessage(syntaxerror.VariableNameIsNeeded)
 				return nil
 			} else {
-				stmt.ReturnArgs = append(stmt.ReturnArgs, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+				ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 				p.nextToken()
+				if !p.parseArrayReference(ident) {
+					return nil
+				}
+				stmt.ReturnArgs = append(stmt.ReturnArgs, ident)
 			}
 			// Require comma or end of instruction
 			if p.curTokenIs(token.Comma) {
@@ -2235,8 +2449,12 @@ func (p *Parser) parseProcedureCallStatement() *ast.ProcedureCallStatement {
 				p.errorMsg = syntaxerror.ErrorMessage(syntaxerror.VariableNameIsNeeded)
 				return nil
 			} else {
-				stmt.ReceiveArgs = append(stmt.ReceiveArgs, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+				ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 				p.nextToken()
+				if !p.parseArrayReference(ident) {
+					return nil
+				}
+				stmt.ReceiveArgs = append(stmt.ReceiveArgs, ident)
 			}
 			// Require comma or end of instruction
 			if p.curTokenIs(token.Comma) {
@@ -2764,6 +2982,75 @@ func (p *Parser) parseSetModeStatement() *ast.SetModeStatement {
 	return nil
 }
 
+func (p *Parser) parseSetDateStatement() *ast.SetDateStatement {
+	stmt := &ast.SetDateStatement{Token: p.curToken}
+	if p.peekTokenIs(token.Colon) || p.peekTokenIs(token.NewLine) || p.peekTokenIs(token.EOF) {
+		p.errorMsg = syntaxerror.ErrorMessage(syntaxerror.StringExpressionNeeded)
+		p.ErrorTokenIndex = p.curToken.Index + 1
+		return nil
+	}
+	p.nextToken()
+	stmt.Value = p.parseExpression(LOWEST)
+	if p.endOfInstruction() {
+		return stmt
+	}
+	return nil
+}
+
+func (p *Parser) parseSetTimeStatement() *ast.SetTimeStatement {
+	stmt := &ast.SetTimeStatement{Token: p.curToken}
+	if p.peekTokenIs(token.Colon) || p.peekTokenIs(token.NewLine) || p.peekTokenIs(token.EOF) {
+		p.errorMsg = syntaxerror.ErrorMessage(syntaxerror.StringExpressionNeeded)
+		p.ErrorTokenIndex = p.curToken.Index + 1
+		return nil
+	}
+	p.nextToken()
+	stmt.Value = p.parseExpression(LOWEST)
+	if p.endOfInstruction() {
+		return stmt
+	}
+	return nil
+}
+
+func (p *Parser) parseSetTraceStatement() *ast.SetTraceStatement {
+	stmt := &ast.SetTraceStatement{Token: p.curToken}
+	p.nextToken()
+	// OFF isn't a keyword so that it can still be used as a variable name
+	if p.curTokenIs(token.IdentifierLiteral) && strings.ToUpper(p.curToken.Literal) == "OFF" {
+		if p.endOfInstruction() {
+			return stmt
+		}
+		return nil
+	}
+	switch p.curToken.TokenType {
+	case token.ON:
+		stmt.On = true
+	case token.LVAR:
+		// Trace variables as well as lines
+		stmt.On = true
+		stmt.Variables = true
+	default:
+		p.errorMsg = syntaxerror.ErrorMessage(syntaxerror.WrongSetAskAttribute)
+		p.ErrorTokenIndex = p.curToken.Index
+		return nil
+	}
+	// Handle optional #e for Channel
+	if p.peekTokenIs(token.Hash) {
+		p.nextToken()
+		if p.peekTokenIs(token.Colon) || p.peekTokenIs(token.NewLine) || p.peekTokenIs(token.EOF) {
+			p.errorMsg = syntaxerror.ErrorMessage(syntaxerror.NumericExpressionNeeded)
+			p.ErrorTokenIndex = p.curToken.Index + 1
+			return nil
+		}
+		p.nextToken()
+		stmt.Channel = p.parseExpression(LOWEST)
+	}
+	if p.endOfInstruction() {
+		return stmt
+	}
+	return nil
+}
+
 func (p *Parser) parseSetPaperStatement() *ast.SetPaperStatement {
 	stmt := &ast.SetPaperStatement{Token: p.curToken}
 	if p.peekTokenIs(token.Colon) || p.peekTokenIs(token.NewLine) || p.peekTokenIs(token.EOF) {
@@ -3316,10 +3603,87 @@ func (p *Parser) parseGotoStatement() *ast.GotoStatement {
 	return stmt
 }
 
+func (p *Parser) parseDeleteStatement() *ast.DeleteStatement {
+	stmt := &ast.DeleteStatement{Token: p.curToken}
+	p.nextToken() // consume DELETE
+	if p.curTokenIs(token.TO) {
+		// DELETE TO lineNumber
+		p.nextToken() // consume TO
+		if !p.curTokenIs(token.NumericLiteral) {
+			p.ErrorTokenIndex = p.curToken.Index
+			p.errorMsg = syntaxerror.ErrorMessage(syntaxerror.LineNumberLabelNeeded)
+			return nil
+		}
+		stmt.ToLinenumber = p.curToken
+		if p.endOfInstruction() {
+			return stmt
+		}
+		return nil
+	}
+	// DELETE lineNumber
+	if !p.curTokenIs(token.NumericLiteral) {
+		p.ErrorTokenIndex = p.curToken.Index
+		p.errorMsg = syntaxerror.ErrorMessage(syntaxerror.LineNumberLabelNeeded)
+		return nil
+	}
+	stmt.FromLinenumber = p.curToken
+	p.nextToken()
+	if p.onEndOfInstruction() {
+		stmt.FromLineOnly = true
+		return stmt
+	}
+	// DELETE lineNumber TO
+	if !p.requireTo() {
+		return nil
+	}
+	if p.onEndOfInstruction() {
+		return stmt
+	}
+	// DELETE lineNumber TO lineNumber
+	if !p.curTokenIs(token.NumericLiteral) {
+		p.ErrorTokenIndex = p.curToken.Index
+		p.errorMsg = syntaxerror.ErrorMessage(syntaxerror.LineNumberLabelNeeded)
+		return nil
+	}
+	stmt.ToLinenumber = p.curToken
+	if p.endOfInstruction() {
+		return stmt
+	}
+	return nil
+}
+
+func (p *Parser) parseAutoStatement() *ast.AutoStatement {
+	stmt := &ast.AutoStatement{Token: p.curToken}
+	p.nextToken()
+	// AUTO [start [, step]]
+	if p.onEndOfInstruction() {
+		return stmt
+	}
+	val, ok := p.requireExpression()
+	if !ok {
+		return nil
+	}
+	stmt.Start = val
+	if p.onEndOfInstruction() {
+		return stmt
+	}
+	if !p.requireComma() {
+		return nil
+	}
+	val, ok = p.requireExpression()
+	if !ok {
+		return nil
+	}
+	stmt.Step = val
+	if !p.requireEndOfInstruction() {
+		return nil
+	}
+	return stmt
+}
+
 func (p *Parser) parseEditStatement() *ast.EditStatement {
 	stmt := &ast.EditStatement{Token: p.curToken}
 	p.nextToken()
-	// TODO: No line number passed so try to get line number of last error
 	if p.onEndOfInstruction() {
 		return stmt
 	}
@@ -3500,6 +3864,7 @@ func (p *Parser) parseBindArrayStatement() *ast.BindStatement {
 	if p.peekTokenIs(token.Colon) || p.peekTokenIs(token.NewLine) {
 		p.nextToken()
 	}
+	p.inBindStatement = false
 	return stmt
 }
 
@@ -3584,6 +3949,10 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 		Left:     left,
 	}
 	precedence := p.curPrecedence()
+	// ^ is right-associative so 2^3^2 is 2^(3^2)
+	if expression.Operator == token.Exponential {
+		precedence--
+	}
 	p.nextToken()
 	expression.Right = p.parseExpression(precedence)
 	return expression
@@ -3749,8 +4118,8 @@ func (p *Parser) ParseLine() *ast.Line {
 		return &ast.Line{Statements: nil, LineNumber: lineNumber, LineString: lineString}
 	}
 	for !(p.curTokenIs(token.EOF) || p.curTokenIs(token.NewLine)) {
-		// Catch ELSE
-		if p.curTokenIs(token.ELSE) {
+		// Catch ELSE of a single-line IF
+		if p.curTokenIs(token.ELSE) && p.ifNesting > 0 {
 			break
 		}
 		statements = append(statements, p.parseStatement())
@@ -3787,7 +4156,9 @@ func (p *Parser) parseIfConsequence() *ast.Line {
 func (p *Parser) parseStatement() ast.Statement {
 	switch p.curToken.TokenType {
 	case token.ELSE:
-		return nil // really?
+		return p.parseElseStatement()
+	case token.ENDIF:
+		return p.parseEndifStatement()
 	case token.REM:
 		return p.parseRemStatement()
 	case token.BYE:
@@ -3796,6 +4167,14 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseClgStatement()
 	case token.END:
 		return p.parseEndStatement()
+	case token.STOP:
+		return p.parseStopStatement()
+	case token.CONTINUE:
+		return p.parseContinueStatement()
+	case token.LVAR:
+		return p.parseLvarStatement()
+	case token.PROCS:
+		return p.parseProcsStatement()
 	case token.LIST:
 		return p.parseListStatement()
 	case token.NOTE:
@@ -3824,12 +4203,16 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseRenameStatement()
 	case token.SAVE:
 		return p.parseSaveStatement()
-	case token.LOAD:
+	case token.LOAD, token.LOADGO, token.MERGE, token.MERGEGO:
 		return p.parseLoadStatement()
 	case token.GOTO:
 		return p.parseGotoStatement()
 	case token.EDIT:
 		return p.parseEditStatement()
+	case token.AUTO:
+		return p.parseAutoStatement()
+	case token.DELETE:
+		return p.parseDeleteStatement()
 	case token.RENUMBER:
 		return p.parseRenumberStatement()
 	case token.REPEAT:
@@ -3860,6 +4243,17 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseLeaveStatement()
 	case token.DIM:
 		return p.parseDimStatement()
+	case token.ON:
+		switch p.peekToken.TokenType {
+		case token.ERROR:
+			return p.parseOnErrorStatement()
+		case token.BREAK:
+			return p.parseOnBreakStatement()
+		default:
+			return p.parseOnJumpStatement()
+		}
+	case token.RESUME:
+		return p.parseResumeStatement()
 	case token.ASK:
 		p.nextToken()
 		switch p.curToken.TokenType {
@@ -3915,6 +4309,18 @@ func (p *Parser) parseStatement() ast.Statement {
 			return p.parseSetWritingStatement()
 		case token.DRAWING:
 			return p.parseSetDrawingStatement()
+		case token.DATE:
+			return p.parseSetDateStatement()
+		case token.TRACE:
+			return p.parseSetTraceStatement()
+		case token.IdentifierLiteral:
+			// TIME is a builtin function so it doesn't come through as a keyword
+			if p.curToken.Literal == token.TIME {
+				return p.parseSetTimeStatement()
+			}
+			p.errorMsg = syntaxerror.ErrorMessage((syntaxerror.WrongSetAskAttribute))
+			p.ErrorTokenIndex = p.curToken.Index
+			return nil
 		default:
 			p.errorMsg = syntaxerror.ErrorMessage((syntaxerror.WrongSetAskAttribute))
 			p.ErrorTokenIndex = p.curToken.Index
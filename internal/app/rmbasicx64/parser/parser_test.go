@@ -21,9 +21,9 @@ func TestLine(t *testing.T) {
 	}{
 		{"let x := 5\n", 1, "LET X := 5"},
 		{"let x := 5 : let x := 5\n", 2, "LET X := 5 : LET X := 5"},
-		{"let x := 5 : let x := 5 : \n", 3, "LET X := 5 : LET X := 5 : "},
-		{"let x := 5 : let y := 5 + x: \n", 3, "LET X := 5 : LET Y := (5 + X) : "},
-		{"let x := 5 : y := 5 + x: \n", 3, "LET X := 5 : Y := (5 + X) : "},
+		{"let x := 5 : let x := 5 : \n", 2, "LET X := 5 : LET X := 5"},
+		{"let x := 5 : let y := 5 + x: \n", 2, "LET X := 5 : LET Y := (5 + X)"},
+		{"let x := 5 : y := 5 + x: \n", 2, "LET X := 5 : Y := (5 + X)"},
 		{"let x = 5 : y =5 + x\n", 2, "LET X = 5 : Y = (5 + X)"},
 	}
 
@@ -117,6 +117,31 @@ func TestBindStatements(t *testing.T) {
 	}
 }
 
+func TestBindArrayStatements(t *testing.T) {
+	input := "a(1) := 1: b(2, 3) := 2: c := 3"
+	l := &lexer.Lexer{}
+	l.Scan(input)
+	p := New(l, &game.Game{})
+	line := p.ParseLine()
+	checkParserErrors(t, p)
+	if msg, hasError := p.GetError(); hasError {
+		t.Fatalf("%q gave error %q", input, msg)
+	}
+	expected := []string{"A", "B", "C"}
+	if len(line.Statements) != len(expected) {
+		t.Fatalf("%q does not contain %d statements. got=%d", input, len(expected), len(line.Statements))
+	}
+	for i, name := range expected {
+		stmt, ok := line.Statements[i].(*ast.BindStatement)
+		if !ok {
+			t.Fatalf("statement %d is not *ast.BindStatement. got=%T", i, line.Statements[i])
+		}
+		if stmt.Name.Value != name {
+			t.Errorf("statement %d binds %q, want %q", i, stmt.Name.Value, name)
+		}
+	}
+}
+
 func TestLetStatements(t *testing.T) {
 
 	tests := []struct {
@@ -125,7 +150,7 @@ func TestLetStatements(t *testing.T) {
 		expectedValue      interface{}
 	}{
 		{"let x := 5\n", "X", 5},
-		{"let y = true\n", "Y", true},
+		{"let y = true\n", "Y", -1.0},
 		{"let foobar := y\n", "Foobar", "Y"},
 	}
 
@@ -134,16 +159,16 @@ func TestLetStatements(t *testing.T) {
 		l.Scan(tt.input)
 		p := New(l, &game.Game{})
 
-		program := p.ParseProgram()
+		line := p.ParseLine()
 		checkParserErrors(t, p)
-		if program == nil {
-			t.Fatalf("ParseProgram() returned nil")
+		if line == nil {
+			t.Fatalf("ParseLine() returned nil")
 		}
-		if len(program.Statements) != 1 {
-			t.Fatalf("program.Statements does not contain 1 statements, got %d", len(program.Statements))
+		if len(line.Statements) != 1 {
+			t.Fatalf("line.Statements does not contain 1 statements, got %d", len(line.Statements))
 		}
 
-		stmt := program.Statements[0]
+		stmt := line.Statements[0]
 		if !testLetStatement(t, stmt, tt.expectedIdentifier) {
 			return
 		}
@@ -190,29 +215,20 @@ result 993322
 // -- Identifier Expression
 
 func TestIdentifierExpression(t *testing.T) {
-	input := `foobar
-`
+	// A name on its own as a statement is a procedure call, so parse it as an expression
+	input := `foobar`
 
 	l := &lexer.Lexer{}
 	l.Scan(input)
 	p := New(l, &game.Game{})
 
-	program := p.ParseProgram()
+	exp := p.parseExpression(LOWEST)
 
 	checkParserErrors(t, p)
 
-	if len(program.Statements) != 1 {
-		t.Fatalf("program has not enough statements, got %d", len(program.Statements))
-	}
-
-	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
-	if !ok {
-		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement, got %T", program.Statements[0])
-	}
-
-	ident, ok := stmt.Expression.(*ast.Identifier)
+	ident, ok := exp.(*ast.Identifier)
 	if !ok {
-		t.Fatalf("expression not *ast.Identifier, got %T", stmt.Expression)
+		t.Fatalf("expression not *ast.Identifier, got %T", exp)
 	}
 
 	if ident.Value != "Foobar" {
@@ -517,19 +533,19 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 		},
 		{
 			"true",
-			"TRUE",
+			"-1.0",
 		},
 		{
 			"false",
-			"FALSE",
+			"0",
 		},
 		{
 			"3 > 5 = false",
-			"((3 > 5) = FALSE)",
+			"((3 > 5) = 0)",
 		},
 		{
 			"3 < 5 = true",
-			"((3 < 5) = TRUE)",
+			"((3 < 5) = -1.0)",
 		},
 		{
 			"1 + (2 + 3) + 4",
@@ -549,7 +565,7 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 		//}
 		{
 			"NOT(true = true)",
-			"(NOT(TRUE = TRUE))",
+			"(NOT(-1.0 = -1.0))",
 		},
 		{
 			"a + add(b * c) + d",
@@ -566,11 +582,11 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 		l.Scan(tt.input)
 		p := New(l, &game.Game{})
 
-		program := p.ParseProgram()
+		exp := p.parseExpression(LOWEST)
 
 		checkParserErrors(t, p)
 
-		actual := program.String()
+		actual := exp.String()
 
 		if actual != tt.expected {
 			t.Errorf("expected %q got %q", tt.expected, actual)
@@ -694,42 +710,133 @@ func TestIfElseExpression(t *testing.T) {
 
 }
 
+func TestBlockIfStatements(t *testing.T) {
+	tests := []struct {
+		input     string
+		block     bool
+		condition bool
+	}{
+		{"if x < y then", true, false},
+		{"if x < y then x = 5", false, false},
+		{"if x < y then if b < 0 then", false, false},
+		{"else", false, false},
+		{"else if x > y then", false, true},
+		{"endif", false, false},
+	}
+
+	for _, tt := range tests {
+		l := &lexer.Lexer{}
+		l.Scan(tt.input)
+		p := New(l, &game.Game{})
+		line := p.ParseLine()
+		checkParserErrors(t, p)
+		if len(line.Statements) != 1 {
+			t.Fatalf("%q does not contain 1 statement. got=%d", tt.input, len(line.Statements))
+		}
+		switch stmt := line.Statements[0].(type) {
+		case *ast.IfStatement:
+			if stmt.Block != tt.block {
+				t.Errorf("%q Block is %t, want %t", tt.input, stmt.Block, tt.block)
+			}
+		case *ast.ElseStatement:
+			if (stmt.Condition != nil) != tt.condition {
+				t.Errorf("%q has condition %v", tt.input, stmt.Condition)
+			}
+		case *ast.EndifStatement:
+		default:
+			t.Errorf("%q gave %T", tt.input, stmt)
+		}
+	}
+}
+
+func TestGosubStatements(t *testing.T) {
+	tests := []struct {
+		input      string
+		statements int
+		name       string
+		isLabel    bool
+	}{
+		{"gosub 100", 1, "100", false},
+		{"gosub Sub", 1, "Sub", true},
+		{"gosub 100: print a", 2, "100", false},
+		{"gosub Sub: print a", 2, "Sub", true},
+	}
+
+	for _, tt := range tests {
+		l := &lexer.Lexer{}
+		l.Scan(tt.input)
+		p := New(l, &game.Game{})
+		line := p.ParseLine()
+		checkParserErrors(t, p)
+		if len(line.Statements) != tt.statements {
+			t.Fatalf("%q does not contain %d statements. got=%d", tt.input, tt.statements, len(line.Statements))
+		}
+		stmt, ok := line.Statements[0].(*ast.GosubStatement)
+		if !ok {
+			t.Fatalf("%q gave %T", tt.input, line.Statements[0])
+		}
+		if stmt.Name.Value != tt.name || stmt.IsLabel != tt.isLabel {
+			t.Errorf("%q gave %q (label %t), want %q (label %t)", tt.input, stmt.Name.Value, stmt.IsLabel, tt.name, tt.isLabel)
+		}
+	}
+}
+
+func TestSetTraceStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"set trace on", "SET TRACE ON"},
+		{"set trace lvar", "SET TRACE LVAR"},
+		{"set trace on #20", "SET TRACE ON #20"},
+		{"set trace lvar #Channel%", "SET TRACE LVAR #Channel%"},
+		{"set trace off", "SET TRACE OFF"},
+	}
+
+	for _, tt := range tests {
+		l := &lexer.Lexer{}
+		l.Scan(tt.input)
+		p := New(l, &game.Game{})
+		line := p.ParseLine()
+		checkParserErrors(t, p)
+		if len(line.Statements) != 1 {
+			t.Fatalf("%q does not contain 1 statement. got=%d", tt.input, len(line.Statements))
+		}
+		if got := line.Statements[0].String(); got != tt.expected {
+			t.Errorf("%q gave %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
 // -------------------------------------------------------------------------
 // -- Call expression
 
 func TestCallExpressionParsing(t *testing.T) {
+	// Function calls and array elements look the same so both are parsed as an identifier
+	// with subscripts, and the evaluator decides which one it is
 	input := "add(1, 2 * 3, 4 + 5)"
 	l := &lexer.Lexer{}
 	l.Scan(input)
 	p := New(l, &game.Game{})
-	program := p.ParseProgram()
+	exp := p.parseExpression(LOWEST)
 	checkParserErrors(t, p)
 
-	if len(program.Statements) != 1 {
-		t.Fatalf("program.Statements does not contain %d statements, got %d", 1, len(program.Statements))
-	}
-
-	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
-	if !ok {
-		t.Fatalf("stmt is not ast.ExpressionStatement, got %T", program.Statements[0])
-	}
-
-	exp, ok := stmt.Expression.(*ast.CallExpression)
+	ident, ok := exp.(*ast.Identifier)
 	if !ok {
-		t.Fatalf("stmt.Expression is not ast.CallExpression, got %T", stmt.Expression)
+		t.Fatalf("exp is not ast.Identifier, got %T", exp)
 	}
 
-	if !testIdentifier(t, exp.Function, "Add") {
-		return
+	if ident.Value != "Add" {
+		t.Fatalf("ident.Value not %s, got %s", "Add", ident.Value)
 	}
 
-	if len(exp.Arguments) != 3 {
-		t.Fatalf("wrong lengt of arguments, got %d", len(exp.Arguments))
+	if len(ident.Subscripts) != 3 {
+		t.Fatalf("wrong length of arguments, got %d", len(ident.Subscripts))
 	}
 
-	testLiteralExpression(t, exp.Arguments[0], 1)
-	testInfixExpression(t, exp.Arguments[1], 2, "*", 3)
-	testInfixExpression(t, exp.Arguments[2], 4, "+", 5)
+	testLiteralExpression(t, ident.Subscripts[0], 1)
+	testInfixExpression(t, ident.Subscripts[1], 2, "*", 3)
+	testInfixExpression(t, ident.Subscripts[2], 4, "+", 5)
 }
 
 func TestStringLiteralExpression(t *testing.T) {
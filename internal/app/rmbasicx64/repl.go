@@ -34,7 +34,7 @@ func welcomeScreen(g *game.Game) {
 		Brush: 3,
 		Over:  -1,
 	}
-	g.Area(areaOpts, []nimgobus.XyCoord{{0, 225}, {300, 225}, {300, 249}, {0, 249}, {0, 225}})
+	g.Area(areaOpts, []nimgobus.XyCoord{{X: 0, Y: 225}, {X: 300, Y: 225}, {X: 300, Y: 249}, {X: 0, Y: 249}, {X: 0, Y: 225}})
 	g.PlonkLogo(1, 227)
 	g.SetCurpos(1, 4)
 	g.Print("This is a tribute project and is in no way linked to or endorsed by RM plc.")
@@ -55,9 +55,17 @@ func repl(g *game.Game) {
 	env := object.NewEnvironment(globalEnv)
 	for {
 		g.Print(":")
+		g.SetInputHistory(g.History)
+		g.SetInputCompletion(
+			func(buffer string, position int) (string, int) { return evaluator.Complete(env, buffer, position) },
+			func(buffer string, position int) string { return evaluator.SyntaxHint(env, buffer, position) },
+		)
 		rawInput := g.Input("")
+		g.SetInputHistory(nil)
+		g.SetInputCompletion(nil, nil)
 		code := strings.TrimSpace(rawInput)
-		if !g.BreakInterruptDetected {
+		if !g.AskBreak() {
+			g.AddHistory(code)
 			// Don't execute if break detected
 			l.Scan(code)
 			p := parser.New(l, g)
@@ -114,7 +122,7 @@ func repl(g *game.Game) {
 			time.Sleep(150 * time.Millisecond)
 		}
 		// Reset break flag
-		g.BreakInterruptDetected = false
+		g.SetBreak(false)
 	}
 }
 
@@ -0,0 +1,57 @@
+package rmbasicx64
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/ast"
+	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/console"
+	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/evaluator"
+	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/game"
+	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/object"
+)
+
+// RunFile loads the program at path and runs it without opening a window.  PRINT output
+// goes to out and INPUT is read from in.  If the program can't be loaded, or any of its
+// lines can't be understood, the reason goes to errOut and the program isn't run.  The
+// program's directory is used as the workspace so that anything it loads or opens is found
+// alongside it.  The return value is the exit status: 0 if the program ran to the end, 1 if
+// it couldn't be loaded or stopped with an error.
+func RunFile(path string, in io.Reader, out, errOut io.Writer) int {
+	fullpath, err := filepath.Abs(path)
+	if err != nil {
+		fmt.Fprintln(errOut, err)
+		return 1
+	}
+	// LOAD only prints anything if some of the file can't be read, so keep it apart from the
+	// program's own output
+	var loadOut strings.Builder
+	h := console.NewHeadless(in, &loadOut)
+	h.EchoInput = false
+	g := game.New(h)
+	g.WorkspacePath = filepath.Dir(fullpath)
+	globalEnv := object.NewEnvironment(nil)
+	env := object.NewEnvironment(globalEnv)
+	// LOAD and RUN the program just as if it had been keyed in
+	obj := evaluator.Eval(g, &ast.LoadStatement{Value: &ast.StringLiteral{Value: filepath.Base(fullpath)}}, env)
+	if errorMsg, ok := obj.(*object.Error); ok {
+		fmt.Fprintf(errOut, "%s: %s\n", errorMsg.Message, path)
+		return 1
+	}
+	if loadOut.Len() > 0 {
+		fmt.Fprint(errOut, loadOut.String())
+		return 1
+	}
+	h.SetOutput(errOut)
+	if !evaluator.CheckProgram(g, env) {
+		return 1
+	}
+	h.SetOutput(out)
+	evaluator.Eval(g, &ast.RunStatement{}, env)
+	if env.ErrorSignal {
+		return 1
+	}
+	return 0
+}
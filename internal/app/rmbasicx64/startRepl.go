@@ -7,17 +7,19 @@ import (
 	"log"
 	"os"
 
+	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/console"
 	"github.com/adamstimb/rmbasicx64/internal/app/rmbasicx64/game"
 	"github.com/adamstimb/rmbasicx64/pkg/nimgobus/resources/icon"
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
-func NewGame() *game.Game {
-	g := &game.Game{}
-	g.Init()
+// NewGame returns a new game that does its I/O through c, with config loaded and
+// the workspace ready to use
+func NewGame(c console.Console) *game.Game {
+	g := game.New(c)
 	g.LoadConfig()
 	g.EnsureWorkspace()
-	go App(g)
+	g.LoadHistory()
 	return g
 }
 
@@ -37,9 +39,11 @@ func StartRepl() {
 		log.Printf("Failed to read application icon - using default GLFW icon instead")
 	}
 	ebiten.SetWindowIcon([]image.Image{iconImg})
-	// Create a new game and pass it to RunGame method
-	game := NewGame()
-	if err := ebiten.RunGame(game); err != nil {
+	// Create a new Nimbus and game then pass the Nimbus to RunGame method
+	n := &nimbusConsole{}
+	n.Init()
+	go App(NewGame(n))
+	if err := ebiten.RunGame(n); err != nil {
 		log.Fatal(err)
 	}
 }
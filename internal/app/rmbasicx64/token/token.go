@@ -131,6 +131,7 @@ const (
 	IF         = "IF"
 	THEN       = "THEN"
 	ELSE       = "ELSE"
+	ENDIF      = "ENDIF"
 	INPUT      = "INPUT"
 	INSTR      = "INSTR"
 	INT        = "INT"
@@ -152,7 +153,7 @@ const (
 	LVAR       = "LVAR"
 	MEM        = "MEM"
 	MERGE      = "MERGE"
-	MERGEGO    = "MEREGO"
+	MERGEGO    = "MERGEGO"
 	MIDstr     = "MID$"
 	MIX        = "MIX"
 	MKDIR      = "MKDIR"
@@ -252,202 +253,205 @@ const (
 	RECEIVE    = "RECEIVE"
 )
 
+// keywords are the reserved words of RM Basic
+var keywords = []string{
+	ABS,
+	AND,
+	AREA,
+	ASC,
+	ASK,
+	ATN,
+	AUTO,
+	BLOCK,
+	COPY,
+	READ,
+	WRITE,
+	BORDER,
+	BOUNDS,
+	BRUSH,
+	BUTTONS,
+	BYE,
+	CHAROVER,
+	CHARSET,
+	CHDIR,
+	CHRstr,
+	CIRCLE,
+	CLEAR,
+	CLG,
+	CLL,
+	CLOSE,
+	CLS,
+	COLOUR,
+	CONTINUE,
+	COS,
+	CREATE,
+	MOVE,
+	CURPOS,
+	CURSOR,
+	DATA,
+	DATE,
+	DATEstr,
+	DEFINED,
+	DEG,
+	DELETE,
+	DIM,
+	DIR,
+	DRAWING,
+	EDIT,
+	END,
+	ENVELOPE,
+	ERASE,
+	ERL,
+	ERR,
+	ERRstr,
+	EXP,
+	FALSE,
+	FKEY,
+	FLOOD,
+	EDGE,
+	FLUSH,
+	FOR,
+	NEXT,
+	FREE,
+	FSPACE,
+	FUNCTION,
+	ENDFUN,
+	GET,
+	GETstr,
+	GLOBAL,
+	GOSUB,
+	SUBROUTINE,
+	GOTO,
+	HEXstr,
+	HOLD,
+	HOME,
+	IF,
+	THEN,
+	ELSE,
+	ENDIF,
+	INPUT,
+	INSTR,
+	INT,
+	JOYSTICK,
+	JOYX,
+	JOYY,
+	KEYREP,
+	LEAVE,
+	LEFTstr,
+	LEN,
+	LET,
+	LINE,
+	LIST,
+	LN,
+	LOAD,
+	LOADGO,
+	LOG,
+	LOOKUP,
+	LVAR,
+	MEM,
+	MERGE,
+	MERGEGO,
+	MIDstr,
+	MIX,
+	MKDIR,
+	MOD,
+	MODE,
+	MOUSE,
+	NEW,
+	NOISE,
+	NOT,
+	NOTE,
+	ON,
+	BREAK,
+	EOF,
+	ERROR,
+	OPEN,
+	OR,
+	ORIGIN,
+	OVER,
+	PAPER,
+	PATHstr,
+	PATTERN,
+	PEN,
+	PI,
+	PITCH,
+	PLOT,
+	DIRECTION,
+	FONT,
+	CHAR,
+	SIZE,
+	POINTS,
+	POS,
+	POSX,
+	POSY,
+	PRINT,
+	PROCEDURE,
+	ENDPROC,
+	PROCS,
+	PSAVE,
+	PUT,
+	QUEUE,
+	RAD,
+	REM,
+	RENAME,
+	TO,
+	RENUMBER,
+	REPEAT,
+	UNTIL,
+	RESTORE,
+	RESULT,
+	RESUME,
+	RETURN,
+	RIGHTstr,
+	RMDIR,
+	RND,
+	RPOINT,
+	RUN,
+	SAVE,
+	SGN,
+	SIN,
+	SLICE,
+	SOUND,
+	SPC,
+	SQR,
+	STOP,
+	STRINGstr,
+	STRstr,
+	STYLE,
+	TAB,
+	TAN,
+	TIME,
+	TIMEstr,
+	TONE,
+	TRACE,
+	TRUE,
+	UNDERLINE,
+	VAL,
+	VERSION,
+	VOICE,
+	WARN,
+	WIDTH,
+	WRITING,
+	XOR,
+	SET,
+	STEP,
+	CONFIG,
+	BOOT,
+	FETCH,
+	WRITEBLOCK,
+	SQUASH,
+	CLEARBLOCK,
+	DELBLOCK,
+	KEEP,
+	READBLOCK,
+	COPYBLOCK,
+	BLOCKSIZE,
+	FILL,
+	RECEIVE,
+}
+
 // IsKeyword returns true if a TokenType represents a keyword
 func IsKeyword(testString string) bool {
-	keywords := []string{
-		ABS,
-		AND,
-		AREA,
-		ASC,
-		ASK,
-		ATN,
-		AUTO,
-		BLOCK,
-		COPY,
-		READ,
-		WRITE,
-		BORDER,
-		BOUNDS,
-		BRUSH,
-		BUTTONS,
-		BYE,
-		CHAROVER,
-		CHARSET,
-		CHDIR,
-		CHRstr,
-		CIRCLE,
-		CLEAR,
-		CLG,
-		CLL,
-		CLOSE,
-		CLS,
-		COLOUR,
-		CONTINUE,
-		COS,
-		CREATE,
-		MOVE,
-		CURPOS,
-		CURSOR,
-		DATA,
-		DATE,
-		DATEstr,
-		DEFINED,
-		DEG,
-		DELETE,
-		DIM,
-		DIR,
-		DRAWING,
-		EDIT,
-		END,
-		ENVELOPE,
-		ERASE,
-		ERL,
-		ERR,
-		ERRstr,
-		EXP,
-		FALSE,
-		FKEY,
-		FLOOD,
-		EDGE,
-		FLUSH,
-		FOR,
-		NEXT,
-		FREE,
-		FSPACE,
-		FUNCTION,
-		ENDFUN,
-		GET,
-		GETstr,
-		GLOBAL,
-		GOSUB,
-		SUBROUTINE,
-		GOTO,
-		HEXstr,
-		HOLD,
-		HOME,
-		IF,
-		THEN,
-		ELSE,
-		INPUT,
-		INSTR,
-		INT,
-		JOYSTICK,
-		JOYX,
-		JOYY,
-		KEYREP,
-		LEAVE,
-		LEFTstr,
-		LEN,
-		LET,
-		LINE,
-		LIST,
-		LN,
-		LOAD,
-		LOADGO,
-		LOG,
-		LOOKUP,
-		LVAR,
-		MEM,
-		MERGE,
-		MERGEGO,
-		MIDstr,
-		MIX,
-		MKDIR,
-		MOD,
-		MODE,
-		MOUSE,
-		NEW,
-		NOISE,
-		NOT,
-		NOTE,
-		ON,
-		BREAK,
-		EOF,
-		ERROR,
-		OPEN,
-		OR,
-		ORIGIN,
-		OVER,
-		PAPER,
-		PATHstr,
-		PATTERN,
-		PEN,
-		PI,
-		PITCH,
-		PLOT,
-		DIRECTION,
-		FONT,
-		CHAR,
-		SIZE,
-		POINTS,
-		POS,
-		POSX,
-		POSY,
-		PRINT,
-		PROCEDURE,
-		ENDPROC,
-		PROCS,
-		PSAVE,
-		PUT,
-		QUEUE,
-		RAD,
-		REM,
-		RENAME,
-		TO,
-		RENUMBER,
-		REPEAT,
-		UNTIL,
-		RESTORE,
-		RESULT,
-		RESUME,
-		RETURN,
-		RIGHTstr,
-		RMDIR,
-		RND,
-		RPOINT,
-		RUN,
-		SAVE,
-		SGN,
-		SIN,
-		SLICE,
-		SOUND,
-		SPC,
-		SQR,
-		STOP,
-		STRINGstr,
-		STRstr,
-		STYLE,
-		TAB,
-		TAN,
-		TIME,
-		TIMEstr,
-		TONE,
-		TRACE,
-		TRUE,
-		UNDERLINE,
-		VAL,
-		VERSION,
-		VOICE,
-		WARN,
-		WIDTH,
-		WRITING,
-		XOR,
-		SET,
-		STEP,
-		CONFIG,
-		BOOT,
-		FETCH,
-		WRITEBLOCK,
-		SQUASH,
-		CLEARBLOCK,
-		DELBLOCK,
-		KEEP,
-		READBLOCK,
-		COPYBLOCK,
-		BLOCKSIZE,
-		FILL,
-		RECEIVE,
-	}
 	for _, keyword := range keywords {
 		if testString == keyword {
 			return true
@@ -456,6 +460,11 @@ func IsKeyword(testString string) bool {
 	return false
 }
 
+// Keywords returns all the keywords, e.g. for completing a partly typed keyword
+func Keywords() []string {
+	return append([]string{}, keywords...)
+}
+
 // IsOperator receives a token and returns true if the token represents an operator
 // otherwise false
 func IsOperator(t Token) bool {
@@ -144,21 +144,21 @@ func drawBackground(n *Nimbus) {
 		Brush: 2,
 		Over:  -1,
 	}
-	n.Area(areaOpts, []XyCoord{{0, 0}, {639, 0}, {639, 249}, {0, 249}, {0, 0}})
+	n.Area(areaOpts, []XyCoord{{X: 0, Y: 0}, {X: 639, Y: 0}, {X: 639, Y: 249}, {X: 0, Y: 249}, {X: 0, Y: 0}})
 	areaOpts.Brush = 1
-	n.Area(areaOpts, []XyCoord{{3, 2}, {636, 2}, {636, 247}, {3, 247}, {3, 2}})
+	n.Area(areaOpts, []XyCoord{{X: 3, Y: 2}, {X: 636, Y: 2}, {X: 636, Y: 247}, {X: 3, Y: 247}, {X: 3, Y: 2}})
 	xl := 10
 	yl := 212
 	areaOpts = AreaOptions{
 		Brush: 3,
 		Over:  -1,
 	}
-	n.Area(areaOpts, []XyCoord{{xl, yl}, {xl + 304, yl}, {xl + 304, yl + 32}, {xl, yl + 32}, {xl, yl}})
+	n.Area(areaOpts, []XyCoord{{X: xl, Y: yl}, {X: xl + 304, Y: yl}, {X: xl + 304, Y: yl + 32}, {X: xl, Y: yl + 32}, {X: xl, Y: yl}})
 	lineOpts := LineOptions{
 		Brush: 2,
 		Over:  -1,
 	}
-	n.Line(lineOpts, []XyCoord{{xl, yl}, {xl + 304, yl}, {xl + 304, yl + 32}, {xl, yl + 32}, {xl, yl}})
+	n.Line(lineOpts, []XyCoord{{X: xl, Y: yl}, {X: xl + 304, Y: yl}, {X: xl + 304, Y: yl + 32}, {X: xl, Y: yl + 32}, {X: xl, Y: yl}})
 	plotOpts := PlotOptions{
 		SizeX: 3, SizeY: 3, Font: 1, Direction: 0, Over: -1,
 	}
@@ -172,10 +172,10 @@ func drawBackground(n *Nimbus) {
 	// Firmware version and serial number
 	areaOpts.Brush = 2
 	//n.Area(areaOpts, 393, 4, 632, 4, 632, 30, 393, 30, 393, 4)
-	n.Area(areaOpts, []XyCoord{{393, 4}, {632, 4}, {632, 30}, {393, 30}, {393, 4}})
+	n.Area(areaOpts, []XyCoord{{X: 393, Y: 4}, {X: 632, Y: 4}, {X: 632, Y: 30}, {X: 393, Y: 30}, {X: 393, Y: 4}})
 	areaOpts.Brush = 3
 	//n.Area(areaOpts, 395, 5, 629, 5, 629, 29, 395, 29, 395, 5)
-	n.Area(areaOpts, []XyCoord{{395, 5}, {629, 5}, {629, 29}, {395, 29}, {395, 5}})
+	n.Area(areaOpts, []XyCoord{{X: 395, Y: 5}, {X: 629, Y: 5}, {X: 629, Y: 29}, {X: 395, Y: 29}, {X: 395, Y: 5}})
 	plotOpts.Brush = 0
 	plotOpts.SizeX = 1
 	plotOpts.SizeY = 1
@@ -29,6 +29,52 @@ func (n *Nimbus) drawCursor() {
 	n.muCursorFlash.Unlock()
 }
 
+// drawInputHint draws the hint set by Input in the status row, which is the bottom row of the
+// screen unless the cursor is there, in which case it's the top row.  The hint is drawn on the
+// overlay in reverse video so that it doesn't disturb anything on the screen.
+func (n *Nimbus) drawInputHint() {
+	n.muInputHintText.Lock()
+	hint := n.inputHintText
+	n.muInputHintText.Unlock()
+	if hint == "" {
+		return
+	}
+	box := n.textBoxes[n.selectedTextBox]
+	row := 25
+	if n.cursorPosition.row+box.row1-1 == row {
+		row = 1
+	}
+	for col := 1; col <= n.mode; col++ {
+		c := 32
+		if col <= len(hint) {
+			c = int(hint[col-1])
+		}
+		if c < 0 || c > 255 {
+			c = 32
+		}
+		charPixels := n.charImages0[c]
+		pixels := make2dArray(8, 10)
+		for x := 0; x < 8; x++ {
+			for y := 0; y < 10; y++ {
+				if charPixels[y][x] == 1 {
+					pixels[y][x] = n.paperColour
+				} else {
+					pixels[y][x] = n.penColour
+				}
+			}
+		}
+		x, y := n.convertColRow(colRow{col, row})
+		n.writeSpriteToOverlay(Sprite{pixels: pixels, x: x, y: y, colour: -1, over: true})
+	}
+}
+
+// setInputHintText sets the hint drawn by drawInputHint
+func (n *Nimbus) setInputHintText(hint string) {
+	n.muInputHintText.Lock()
+	n.inputHintText = hint
+	n.muInputHintText.Unlock()
+}
+
 // AdvanceCursor moves the cursor forward and handles line feeds and carriage returns
 func (n *Nimbus) AdvanceCursor(forceCarriageReturn bool) {
 
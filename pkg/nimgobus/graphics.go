@@ -11,6 +11,7 @@ import (
 	"log"
 	"os"
 
+	"github.com/adamstimb/rmbasicx64/pkg/nimgobus/options"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 )
 
@@ -56,14 +57,7 @@ func (n *Nimbus) PlonkLogo(x, y int) {
 	n.drawSprite(Sprite{pixels: n.logoImage, x: x + 1, y: y, colour: -1, over: true})
 }
 
-type PlotOptions struct {
-	Brush     int
-	Font      int
-	Direction int
-	SizeX     int
-	SizeY     int
-	Over      int
-}
+type PlotOptions = options.PlotOptions
 
 // Plot draws a string of characters on the paper at a given location
 // with the colour, size and orientation of your choice.
@@ -245,19 +239,9 @@ func (n *Nimbus) drawLine(img [][]int, x1, y1, x2, y2 int) [][]int {
 	return img
 }
 
-type XyCoord struct {
-	X int
-	Y int
-}
+type XyCoord = options.XyCoord
 
-type LineOptions struct {
-	Brush     int
-	Font      int
-	Direction int
-	SizeX     int
-	SizeY     int
-	Over      int
-}
+type LineOptions = options.LineOptions
 
 // Line draws a list of coordinates on the screen connected by lines
 func (n *Nimbus) Line(opt LineOptions, coordList []XyCoord) {
@@ -343,11 +327,7 @@ func (n *Nimbus) drawCircle(r int, img [][]int) [][]int {
 	return img
 }
 
-type CircleOptions struct {
-	Brush     int
-	Over      int
-	FillStyle FillStyle
-}
+type CircleOptions = options.CircleOptions
 
 // Circle draws a a filled circle
 func (n *Nimbus) Circle(opt CircleOptions, r, x, y int) {
@@ -437,11 +417,7 @@ func (n *Nimbus) Circle(opt CircleOptions, r, x, y int) {
 	}
 }
 
-type AreaOptions struct {
-	Brush     int
-	Over      int
-	FillStyle FillStyle
-}
+type AreaOptions = options.AreaOptions
 
 // Area draws a filled polygon of coordinates on the screen
 func (n *Nimbus) Area(opt AreaOptions, coordList []XyCoord) {
@@ -497,7 +473,7 @@ func (n *Nimbus) Area(opt AreaOptions, coordList []XyCoord) {
 	// draw lines *and close the shape if required*
 	if coordList[0].X != coordList[len(coordList)-1].X || coordList[0].Y != coordList[len(coordList)-1].Y {
 		// shape if open so we need to close it
-		coordList = append(coordList, XyCoord{coordList[0].X, coordList[0].Y})
+		coordList = append(coordList, XyCoord{X: coordList[0].X, Y: coordList[0].Y})
 	}
 	for i := 0; i < len(coordList)-1; i++ {
 		//log.Printf("i=%d minXY=(%d, %d) line=(%d, %d)-(%d-%d)", i, minX, minY, coordList[i].X, coordList[i].Y, coordList[i+1].X, coordList[i+1].Y)
@@ -556,11 +532,7 @@ func (n *Nimbus) Area(opt AreaOptions, coordList []XyCoord) {
 	}
 }
 
-type PointsOptions struct {
-	Style int
-	Brush int
-	Over  int
-}
+type PointsOptions = options.PointsOptions
 
 // Points draws points at some given coordinates on the screen
 func (n *Nimbus) Points(opt PointsOptions, coordList []XyCoord) {
@@ -618,7 +590,7 @@ func (n *Nimbus) floodFill(x, y, color int, useEdgeColour bool, edgeColour int,
 	srcColor := n.GetPixel(x, y)
 	hits := [250][640]bool{}
 	queue := []XyCoord{}
-	queue = append(queue, XyCoord{x, y})
+	queue = append(queue, XyCoord{X: x, Y: y})
 	n.muVideoMemory.Lock()
 	for len(queue) > 0 {
 		p := queue[0]
@@ -626,23 +598,18 @@ func (n *Nimbus) floodFill(x, y, color int, useEdgeColour bool, edgeColour int,
 		result := n.floodFillDo(maxX, hits, p.X, p.Y, srcColor, color, useEdgeColour, edgeColour, fillStyle)
 		if result {
 			hits[p.Y][p.X] = true
-			queue = append(queue, XyCoord{p.X + 1, p.Y + 1})
-			queue = append(queue, XyCoord{p.X - 1, p.Y - 1})
-			queue = append(queue, XyCoord{p.X + 1, p.Y - 1})
-			queue = append(queue, XyCoord{p.X - 1, p.Y + 1})
-			queue = append(queue, XyCoord{p.X - 1, p.Y})
-			queue = append(queue, XyCoord{p.X + 1, p.Y})
+			queue = append(queue, XyCoord{X: p.X + 1, Y: p.Y + 1})
+			queue = append(queue, XyCoord{X: p.X - 1, Y: p.Y - 1})
+			queue = append(queue, XyCoord{X: p.X + 1, Y: p.Y - 1})
+			queue = append(queue, XyCoord{X: p.X - 1, Y: p.Y + 1})
+			queue = append(queue, XyCoord{X: p.X - 1, Y: p.Y})
+			queue = append(queue, XyCoord{X: p.X + 1, Y: p.Y})
 		}
 	}
 	n.muVideoMemory.Unlock()
 }
 
-type FloodOptions struct {
-	Brush         int
-	UseEdgeColour bool
-	EdgeColour    int
-	FillStyle     FillStyle
-}
+type FloodOptions = options.FloodOptions
 
 // Flood seeds a boundary fill at x, y
 func (n *Nimbus) Flood(opt FloodOptions, coord XyCoord) {
@@ -0,0 +1,30 @@
+/*
+Package keycode defines the codes that Nimgobus's Get returns for control keys.  Printable
+keys return their character code and nothing pressed returns -1.  The codes are kept apart
+from the nimgobus package itself so that code which only reads the keyboard doesn't have to
+import Ebiten.
+*/
+package keycode
+
+const (
+	Backspace = -10
+	Enter     = -11
+	Left      = -12
+	Right     = -13
+	Up        = -14
+	Down      = -15
+	Home      = -16
+	End       = -17
+	PageUp    = -18
+	PageDown  = -19
+	Delete    = -20
+	Insert    = -21
+	Escape    = -22
+	F1        = -23
+	F2        = -24
+	F3        = -25
+	F4        = -26
+	F5        = -27
+	F6        = -28
+	Tab       = -29
+)
@@ -11,6 +11,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/adamstimb/rmbasicx64/pkg/nimgobus/keycode"
+	"github.com/adamstimb/rmbasicx64/pkg/nimgobus/options"
 	"github.com/adamstimb/rmbasicx64/pkg/nimgobus/resources/font"
 	"github.com/adamstimb/rmbasicx64/pkg/nimgobus/resources/logo"
 	"github.com/hajimehoshi/ebiten/v2"
@@ -52,6 +54,13 @@ type Sprite struct {
 	fillStyle FillStyle
 }
 
+// completeFunc returns buffer with the word that ends at position completed, and the new
+// position of the cursor
+type completeFunc func(buffer string, position int) (string, int)
+
+// hintFunc returns a one-line hint about the word at position in buffer, or "" if there isn't one
+type hintFunc func(buffer string, position int) string
+
 // repeatingChar is used to store and count repeating chars for dynamically limiting repeating key presses
 type repeatingChar struct {
 	char    int
@@ -72,11 +81,7 @@ type imageBlock struct {
 }
 
 // FillStyle describes the fill settings for AREA, FLOOD, CIRCLE, and SLICE
-type FillStyle struct {
-	Style    int // 1 for solid/dithered, 2 for hatched, 3 for hollow (edge)
-	Hatching int // Hatching type if Style==2
-	Colour2  int // 2nd hatching colour if Style==2
-}
+type FillStyle = options.FillStyle
 
 // FileObj describes a file object and whether its for writing or reading
 type FileObj struct {
@@ -143,6 +148,11 @@ type Nimbus struct {
 	colourFlash            int                  // The colour flash counter
 	deleteMode             bool                 // true if delete mode selected
 	deleteModeCursorImage  [][]int              // The special cursor for delete mode
+	inputHistory           []string             // Previous inputs that Input can recall, newest last
+	inputComplete          completeFunc         // Completes the word before the cursor when TAB is pressed in Input
+	inputHint              hintFunc             // Gives a hint about the word under the cursor in Input
+	muInputHintText        sync.Mutex           //
+	inputHintText          string               // The hint being shown in the status row
 	muKeyBuffer            sync.Mutex           //
 	keyBuffer              []int                // Nimgobus needs it's own key buffer since ebiten's only deals with printable chars
 	charRepeat             repeatingChar        // Used by the keyBuffer to dynamically limit key presses
@@ -318,65 +328,84 @@ func (n *Nimbus) updateKeyBuffer() {
 		return
 	}
 	if ebiten.IsKeyPressed(ebiten.KeyEnter) || ebiten.IsKeyPressed(ebiten.KeyKPEnter) {
-		acceptRepeatingChar(-11)
+		acceptRepeatingChar(keycode.Enter)
 		n.muKeyBuffer.Unlock()
 		return
 	}
 	if ebiten.IsKeyPressed(ebiten.KeyBackspace) {
-		acceptRepeatingChar(-10)
+		acceptRepeatingChar(keycode.Backspace)
 		n.muKeyBuffer.Unlock()
 		return
 	}
 	if ebiten.IsKeyPressed(ebiten.KeyLeft) {
-		acceptRepeatingChar(-12)
+		acceptRepeatingChar(keycode.Left)
 		n.muKeyBuffer.Unlock()
 		return
 	}
 	if ebiten.IsKeyPressed(ebiten.KeyRight) {
-		acceptRepeatingChar(-13)
+		acceptRepeatingChar(keycode.Right)
 		n.muKeyBuffer.Unlock()
 		return
 	}
 	if ebiten.IsKeyPressed(ebiten.KeyUp) {
-		acceptRepeatingChar(-14)
+		acceptRepeatingChar(keycode.Up)
 		n.muKeyBuffer.Unlock()
 		return
 	}
 	if ebiten.IsKeyPressed(ebiten.KeyDown) {
-		acceptRepeatingChar(-15)
+		acceptRepeatingChar(keycode.Down)
 		n.muKeyBuffer.Unlock()
 		return
 	}
 	if ebiten.IsKeyPressed(ebiten.KeyHome) {
-		acceptRepeatingChar(-16)
+		acceptRepeatingChar(keycode.Home)
 		n.muKeyBuffer.Unlock()
 		return
 	}
 	if ebiten.IsKeyPressed(ebiten.KeyEnd) {
-		acceptRepeatingChar(-17)
+		acceptRepeatingChar(keycode.End)
 		n.muKeyBuffer.Unlock()
 		return
 	}
 	if ebiten.IsKeyPressed(ebiten.KeyPageUp) {
-		acceptRepeatingChar(-18)
+		acceptRepeatingChar(keycode.PageUp)
 		n.muKeyBuffer.Unlock()
 		return
 	}
 	if ebiten.IsKeyPressed(ebiten.KeyPageDown) {
-		acceptRepeatingChar(-19)
+		acceptRepeatingChar(keycode.PageDown)
 		n.muKeyBuffer.Unlock()
 		return
 	}
 	if ebiten.IsKeyPressed(ebiten.KeyDelete) {
-		acceptRepeatingChar(-20)
+		acceptRepeatingChar(keycode.Delete)
 		n.muKeyBuffer.Unlock()
 		return
 	}
 	if ebiten.IsKeyPressed(ebiten.KeyInsert) {
-		acceptRepeatingChar(-21)
+		acceptRepeatingChar(keycode.Insert)
+		n.muKeyBuffer.Unlock()
+		return
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyEscape) {
+		acceptRepeatingChar(keycode.Escape)
 		n.muKeyBuffer.Unlock()
 		return
 	}
+	if ebiten.IsKeyPressed(ebiten.KeyTab) {
+		acceptRepeatingChar(keycode.Tab)
+		n.muKeyBuffer.Unlock()
+		return
+	}
+	// Function keys F1 to F6 are keycode.F1 to keycode.F6
+	functionKeys := []ebiten.Key{ebiten.KeyF1, ebiten.KeyF2, ebiten.KeyF3, ebiten.KeyF4, ebiten.KeyF5, ebiten.KeyF6}
+	for i, key := range functionKeys {
+		if ebiten.IsKeyPressed(key) {
+			acceptRepeatingChar(keycode.F1 - i)
+			n.muKeyBuffer.Unlock()
+			return
+		}
+	}
 	n.charRepeat.char = 0
 	n.charRepeat.counter = 0
 	n.muKeyBuffer.Unlock()
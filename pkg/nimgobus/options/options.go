@@ -0,0 +1,63 @@
+/*
+Package options defines the plain value types that are passed to the Nimgobus drawing
+commands.  They are kept apart from the nimgobus package itself so that code which only
+needs to describe a drawing operation doesn't have to import Ebiten.  The nimgobus package
+re-exports every type here under the same name.
+*/
+package options
+
+// XyCoord is an x, y coordinate on the screen
+type XyCoord struct {
+	X int
+	Y int
+}
+
+// FillStyle describes the fill settings for AREA, FLOOD, CIRCLE, and SLICE
+type FillStyle struct {
+	Style    int // 1 for solid/dithered, 2 for hatched, 3 for hollow (edge)
+	Hatching int // Hatching type if Style==2
+	Colour2  int // 2nd hatching colour if Style==2
+}
+
+type PlotOptions struct {
+	Brush     int
+	Font      int
+	Direction int
+	SizeX     int
+	SizeY     int
+	Over      int
+}
+
+type LineOptions struct {
+	Brush     int
+	Font      int
+	Direction int
+	SizeX     int
+	SizeY     int
+	Over      int
+}
+
+type CircleOptions struct {
+	Brush     int
+	Over      int
+	FillStyle FillStyle
+}
+
+type AreaOptions struct {
+	Brush     int
+	Over      int
+	FillStyle FillStyle
+}
+
+type PointsOptions struct {
+	Style int
+	Brush int
+	Over  int
+}
+
+type FloodOptions struct {
+	Brush         int
+	UseEdgeColour bool
+	EdgeColour    int
+	FillStyle     FillStyle
+}
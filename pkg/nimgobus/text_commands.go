@@ -1,8 +1,10 @@
 package nimgobus
 
 import (
+	"strings"
 	"time"
 
+	"github.com/adamstimb/rmbasicx64/pkg/nimgobus/keycode"
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
@@ -214,8 +216,8 @@ func (n *Nimbus) convertColRowEbiten(cr colRow) (x, y int) {
 func (n *Nimbus) SetCurpos(col, row int) {
 	// Pick the textbox
 	box := n.textBoxes[n.selectedTextBox]
-	width := box.col2 - box.col1
-	height := box.row2 - box.row1
+	width := box.col2 - box.col1 + 1
+	height := box.row2 - box.row1 + 1
 	// If both col and row are outside textbox, go to home position
 	if (col > width || col < 1) && (row > height || row < 1) {
 		n.cursorPosition = colRow{1, 1}
@@ -337,6 +339,22 @@ func (n *Nimbus) Get() int {
 	return n.popKeyBuffer()
 }
 
+// SetInputHistory sets the previous inputs that Input can recall, newest last.  While a
+// history is set the up and down arrow keys step through it and F5 searches back through
+// it for the text typed so far.  Passing nil turns recall off.
+func (n *Nimbus) SetInputHistory(history []string) {
+	n.inputHistory = history
+}
+
+// SetInputCompletion sets the functions Input uses to help with typing.  When TAB is pressed
+// complete is called with the input so far and the cursor position, and returns the input with
+// the word before the cursor completed and the new cursor position.  After every key press hint
+// is called the same way and the hint it returns is shown in the status row.  Either can be nil.
+func (n *Nimbus) SetInputCompletion(complete func(buffer string, position int) (string, int), hint func(buffer string, position int) string) {
+	n.inputComplete = complete
+	n.inputHint = hint
+}
+
 // Input receives keyboard input into a string of up to 256 chars and returns
 // the string when ENTER is pressed.
 // The user can edit the string using the delete key and left and right arrow
@@ -439,8 +457,59 @@ func (n *Nimbus) Input(prepopulateBuffer string) string {
 		}
 	}
 
+	// bufferString returns the buffer as a string
+	bufferString := func() string {
+		var s string
+		for _, c := range buffer {
+			s += string(rune(c))
+		}
+		return s
+	}
+
+	// replaceBuffer replaces everything in the buffer with s, e.g. an input
+	// recalled from the history
+	replaceBuffer := func(s string) {
+		for bufferPosition > 0 {
+			moveCursorBack(false)
+			bufferPosition--
+		}
+		oldLength := len(buffer)
+		buffer = []int{}
+		for _, c := range s {
+			buffer = append(buffer, int(c))
+		}
+		echoBuffer(buffer, 0)
+		// rub out whatever is left of the old buffer
+		if extra := oldLength - len(buffer); extra > 0 {
+			tempCursorPosition := n.cursorPosition
+			for i := 0; i < extra; i++ {
+				n.Put(32)
+			}
+			n.cursorPosition = tempCursorPosition
+		}
+		bufferPosition = len(buffer)
+	}
+
+	// historyIndex is the position in the history of the input being shown, or
+	// len(history) if it's the new input.  draft keeps the new input while
+	// stepping through the history and searchText is the text F5 looks for.
+	history := n.inputHistory
+	historyIndex := len(history)
+	draft := prepopulateBuffer
+	searchText := ""
+	searching := false
+
+	// showHint updates the hint in the status row, if hints are wanted
+	showHint := func() {
+		if n.inputHint != nil {
+			n.setInputHintText(n.inputHint(bufferString(), bufferPosition))
+		}
+	}
+	defer n.setInputHintText("")
+
 	// Print the buffer before looping to get user input
 	echoBuffer(buffer, 0)
+	showHint()
 
 	// now loop to received and edit the input string until enter is pressed
 	for !n.BreakInterruptDetected {
@@ -452,16 +521,72 @@ func (n *Nimbus) Input(prepopulateBuffer string) string {
 			// nothing pressed so update vars an skip
 			continue
 		}
+		// TAB completes the word before the cursor
+		if char == keycode.Tab {
+			if n.inputComplete != nil {
+				completed, position := n.inputComplete(bufferString(), bufferPosition)
+				replaceBuffer(completed)
+				for bufferPosition > position && bufferPosition > 0 {
+					moveCursorBack(false)
+					bufferPosition--
+				}
+				showHint()
+			}
+			continue
+		}
+		// any key except F5 ends a history search
+		if char != keycode.F5 {
+			searching = false
+		}
+		// recall inputs from the history if there is one
+		if history != nil && (char == keycode.Up || char == keycode.Down || char == keycode.F5) {
+			if historyIndex == len(history) {
+				draft = bufferString()
+			}
+			switch char {
+			case keycode.Up:
+				// UP ARROW pressed so recall the previous input
+				if historyIndex > 0 {
+					historyIndex--
+					replaceBuffer(history[historyIndex])
+				}
+			case keycode.Down:
+				// DOWN ARROW pressed so recall the next input or go back to the new one
+				if historyIndex < len(history) {
+					historyIndex++
+					if historyIndex == len(history) {
+						replaceBuffer(draft)
+					} else {
+						replaceBuffer(history[historyIndex])
+					}
+				}
+			case keycode.F5:
+				// F5 pressed so search back for an input containing the text typed so far
+				if !searching {
+					searchText = bufferString()
+					searching = true
+				}
+				for i := historyIndex - 1; i >= 0; i-- {
+					if strings.Contains(strings.ToUpper(history[i]), strings.ToUpper(searchText)) {
+						historyIndex = i
+						replaceBuffer(history[i])
+						break
+					}
+				}
+			}
+			showHint()
+			continue
+		}
 		// handle control keys if any
-		if char <= -10 {
+		if char <= keycode.Backspace {
 			// is control key
-			if char == -11 {
+			if char == keycode.Enter {
 				// ENTER pressed so echo buffer beyond current position
 				// one last time and break loop
 				echoBuffer(buffer, bufferPosition)
 				break
 			}
-			if char == -10 {
+			if char == keycode.Backspace {
 				// BACKSPACE pressed
 				// First switch delete mode off
 				n.deleteMode = false
@@ -481,7 +606,7 @@ func (n *Nimbus) Input(prepopulateBuffer string) string {
 					}
 				}
 			}
-			if char == -12 {
+			if char == keycode.Left {
 				// LEFT ARROW pressed
 				// only move left if not at beginning
 				if bufferPosition > 0 {
@@ -500,7 +625,7 @@ func (n *Nimbus) Input(prepopulateBuffer string) string {
 					}
 				}
 			}
-			if char == -13 {
+			if char == keycode.Right {
 				// RIGHT ARROW pressed
 				// only move/delete right if not at end of buffer
 				if bufferPosition < len(buffer) {
@@ -517,7 +642,7 @@ func (n *Nimbus) Input(prepopulateBuffer string) string {
 					}
 				}
 			}
-			if char == -14 {
+			if char == keycode.Up {
 				// UP ARROW pressed
 				switch n.deleteMode {
 				case true:
@@ -542,7 +667,7 @@ func (n *Nimbus) Input(prepopulateBuffer string) string {
 					}
 				}
 			}
-			if char == -15 {
+			if char == keycode.Down {
 				// DOWN ARROW pressed
 				switch n.deleteMode {
 				case true:
@@ -569,7 +694,7 @@ func (n *Nimbus) Input(prepopulateBuffer string) string {
 					}
 				}
 			}
-			if char == -16 {
+			if char == keycode.Home {
 				// HOME pressed
 				switch n.deleteMode {
 				case false:
@@ -598,7 +723,7 @@ func (n *Nimbus) Input(prepopulateBuffer string) string {
 					buffer = popBuffer(buffer, bufferPosition)
 				}
 			}
-			if char == -17 {
+			if char == keycode.End {
 				// END pressed
 				switch n.deleteMode {
 				case true:
@@ -617,7 +742,7 @@ func (n *Nimbus) Input(prepopulateBuffer string) string {
 					}
 				}
 			}
-			if char == -18 {
+			if char == keycode.PageUp {
 				// PG UP pressed
 				inWord := false
 				for bufferPosition > 0 {
@@ -631,7 +756,7 @@ func (n *Nimbus) Input(prepopulateBuffer string) string {
 					}
 				}
 			}
-			if char == -19 {
+			if char == keycode.PageDown {
 				// PG DOWN pressed
 				inWord := false
 				for bufferPosition < len(buffer)-1 {
@@ -649,11 +774,11 @@ func (n *Nimbus) Input(prepopulateBuffer string) string {
 					bufferPosition++
 				}
 			}
-			if char == -20 {
+			if char == keycode.Delete {
 				// DEL pressed - don't move but switch delete mode on
 				n.deleteMode = true
 			}
-			if char == -21 {
+			if char == keycode.Insert {
 				// INS pressed - don't move but switch delete mode off
 				n.deleteMode = false
 			}
@@ -676,6 +801,7 @@ func (n *Nimbus) Input(prepopulateBuffer string) string {
 				bufferPosition++
 			}
 		}
+		showHint()
 	}
 
 	// Enter was pressed so carriage return
@@ -443,6 +443,7 @@ func (n *Nimbus) updateVideoMemory() {
 	if n.cursorFlashEnabled {
 		n.drawCursor()
 	}
+	n.drawInputHint()
 	// flush drawQueue and update videoImage
 	n.drawQueue = []Sprite{}
 	n.updateVideoImage()